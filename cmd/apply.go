@@ -1,17 +1,21 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/spf13/cobra"
 	"github.com/theclifmeister/sample-shifter/internal/categorizer"
+	"github.com/theclifmeister/sample-shifter/internal/dupes"
+	"github.com/theclifmeister/sample-shifter/internal/incremental"
+	"github.com/theclifmeister/sample-shifter/internal/journal"
+	"github.com/theclifmeister/sample-shifter/internal/progress"
 	"github.com/theclifmeister/sample-shifter/internal/scanner"
 	"github.com/theclifmeister/sample-shifter/internal/stats"
+	"github.com/theclifmeister/sample-shifter/internal/transfer"
 )
 
 var (
@@ -21,6 +25,16 @@ var (
 	applyNormalizeFilenames bool
 	cleanTarget             bool
 	applyConfigFile         string
+	applyLayoutTemplate     string
+	applyCollisionPolicy    string
+	applyMode               string
+	applyJobs               int
+	applyIncremental        bool
+	applyChecksum           bool
+	applyOrphans            string
+	applyDedupe             bool
+	applyKeepPolicy         string
+	applyPathEvidence       bool
 )
 
 var applyCmd = &cobra.Command{
@@ -28,9 +42,15 @@ var applyCmd = &cobra.Command{
 	Short: "Apply categorization and copy files to target directory",
 	Long: `Copy audio files to their categorized folders in the target directory.
 You can either specify a source directory to scan and categorize on-the-fly,
-or use a previously generated preview file.`,
+or use a previously generated preview file.
+
+Use --mode to move, hardlink, symlink or reflink files instead of copying them.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		var categorized []categorizer.CategorizedFile
+		var cat *categorizer.Categorizer
+		// configDir is where the configuration of a preview file is discovered: the directory
+		// the preview scanned
+		configDir := "."
 
 		// Require --target flag in all cases
 		if applyTargetDir == "" {
@@ -38,17 +58,61 @@ or use a previously generated preview file.`,
 			os.Exit(1)
 		}
 
+		policy, err := categorizer.ParseCollisionPolicy(applyCollisionPolicy)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		mode, err := transfer.ParseMode(applyMode)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if applyJobs < 1 {
+			fmt.Println("Error: --jobs must be at least 1")
+			os.Exit(1)
+		}
+
+		keepPolicy, err := dupes.ParsePolicy(applyKeepPolicy)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if applyOrphans != "" && applyOrphans != orphansReport && applyOrphans != orphansPrune {
+			fmt.Printf("Error: unknown --orphans value %q (valid: report, prune)\n", applyOrphans)
+			os.Exit(1)
+		}
+		incrementalSync := applyIncremental || applyChecksum
+
 		// Load from preview file if provided
 		if previewFile != "" {
-			data, err := os.ReadFile(previewFile)
+			manifest, err := categorizer.LoadManifest(previewFile)
 			if err != nil {
-				fmt.Printf("Error reading preview file: %v\n", err)
+				fmt.Printf("Error parsing preview file: %v\n", err)
 				os.Exit(1)
 			}
+			categorized = manifest.Files
+			if manifest.SourceDir != "" {
+				configDir = manifest.SourceDir
+			}
 
-			if err := json.Unmarshal(data, &categorized); err != nil {
-				fmt.Printf("Error parsing preview file: %v\n", err)
-				os.Exit(1)
+			// Resolve collisions and place files the way the preview did
+			if manifest.OnCollision != "" {
+				if cmd.Flags().Changed("on-collision") && policy != manifest.OnCollision {
+					fmt.Printf("Error: the preview was made with --on-collision %s; preview again to use %s\n", manifest.OnCollision, policy)
+					os.Exit(1)
+				}
+				policy = manifest.OnCollision
+			}
+			if manifest.Mode != "" {
+				if cmd.Flags().Changed("mode") && mode != manifest.Mode {
+					fmt.Printf("Error: the preview was made with --mode %s; preview again to use %s\n", manifest.Mode, mode)
+					os.Exit(1)
+				}
+				mode = manifest.Mode
 			}
 
 			fmt.Printf("Loaded preview from: %s\n", previewFile)
@@ -67,6 +131,8 @@ or use a previously generated preview file.`,
 				os.Exit(1)
 			}
 
+			// Create categorizer with config
+			cat = loadCategorizer(applyConfigFile, sourceDir)
 			fmt.Printf("Scanning: %s\n", sourceDir)
 
 			samples, err := scanner.ScanDirectory(sourceDir)
@@ -75,11 +141,14 @@ or use a previously generated preview file.`,
 				os.Exit(1)
 			}
 
-			// Create categorizer with config
-			cat, err := categorizer.NewCategorizerFromFile(applyConfigFile)
-			if err != nil {
-				fmt.Printf("Error loading configuration: %v\n", err)
-				os.Exit(1)
+			if applyLayoutTemplate != "" {
+				if err := cat.SetLayout(applyLayoutTemplate); err != nil {
+					fmt.Printf("Error: invalid --layout: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			if cmd.Flags().Changed("path-evidence") {
+				cat.SetPathEvidence(applyPathEvidence)
 			}
 
 			categorized = cat.CategorizeBatch(samples, applyTargetDir, applyNormalizeFilenames)
@@ -90,6 +159,18 @@ or use a previously generated preview file.`,
 			return
 		}
 
+		// Keep only one canonical copy of files with identical content
+		duplicateCount := 0
+		if applyDedupe {
+			before := len(categorized)
+			if cat == nil && keepPolicy == dupes.PolicyCategoryPriority {
+				// Preview files carry no configuration; look it up from the scanned directory
+				cat = loadCategorizer(applyConfigFile, configDir)
+			}
+			categorized = dedupeCategorized(categorized, keepPolicy, cat)
+			duplicateCount = before - len(categorized)
+		}
+
 		// Clean target directory if requested
 		if cleanTarget && !dryRun {
 			if err := cleanDirectory(applyTargetDir); err != nil {
@@ -100,41 +181,157 @@ or use a previously generated preview file.`,
 			fmt.Printf("\n[DRY RUN] Would clean target directory: %s\n", applyTargetDir)
 		}
 
+		// Re-check collisions against the current target contents; a cleaned target has none.
+		// In incremental mode, targets an earlier run placed from the same source are
+		// compared below instead of renamed around.
+		var placedFrom categorizer.PlacedFromFunc
+		if incrementalSync {
+			if placedFrom, err = incremental.PlacedFrom(applyTargetDir); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		categorized = categorizer.ResolveCollisions(categorized, policy, !(cleanTarget && !dryRun), placedFrom)
+
+		// Find target files whose source no longer exists
+		var orphans []string
+		if applyOrphans != "" {
+			if orphans, err = incremental.Orphans(applyTargetDir, categorized); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			if applyOrphans == orphansPrune && len(orphans) > 0 && !dryRun {
+				if err := confirmPrune(len(orphans)); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+		}
+
 		if dryRun {
-			fmt.Println("\n=== DRY RUN MODE - No files will be copied ===")
+			fmt.Println("\n=== DRY RUN MODE - No files will be changed ===")
+		}
+		if mode != transfer.ModeCopy {
+			fmt.Printf("\nMode: %s\n", mode)
+		}
+		if mode.Destructive() {
+			fmt.Println("Source files will be removed after they are moved.")
 		}
 
-		fmt.Printf("\nProcessing %d file(s)...\n\n", len(categorized))
+		var runJournal *journal.Journal
+		if !dryRun {
+			if runJournal, err = journal.New(applyTargetDir, mode); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
 
-		// Copy files
-		successCount := 0
-		errorCount := 0
+		fmt.Printf("\nProcessing %d file(s)...\n\n", len(categorized))
 
+		// Report skipped files up front; the rest are transferred by the worker pool
+		var pending []transferJob
+		skippedCount := 0
+		unchangedCount := 0
 		for _, cat := range categorized {
-			fmt.Printf("Copying: %s\n  -> %s\n", cat.Sample.OriginalPath, cat.TargetPath)
+			if cat.Skipped() {
+				fmt.Printf("Skipping: %s\n  (%s)\n", cat.Sample.OriginalPath, cat.Collision.Reason)
+				skippedCount++
+				continue
+			}
+
+			job := transferJob{file: cat, overwrite: cat.AllowsOverwrite()}
+			if incrementalSync {
+				status, err := incremental.Compare(cat.Sample.OriginalPath, cat.TargetPath, applyChecksum)
+				if err != nil {
+					// Let the transfer attempt surface the problem
+					status = incremental.StatusUpdated
+				}
+				if status == incremental.StatusUnchanged {
+					unchangedCount++
+					continue
+				}
+				job.updated = status == incremental.StatusUpdated
+				job.overwrite = job.overwrite || job.updated
+			}
+			pending = append(pending, job)
+		}
 
-			if !dryRun {
-				if err := copyFile(cat.Sample.OriginalPath, cat.TargetPath); err != nil {
-					fmt.Printf("  ERROR: %v\n", err)
-					errorCount++
+		var counts transferCounts
+		if dryRun {
+			for _, job := range pending {
+				fmt.Printf("%s: %s\n  -> %s\n", mode.Verb(), job.file.Sample.OriginalPath, job.file.TargetPath)
+				if job.updated {
+					fmt.Println("  (skipped - dry run, would update)")
+					counts.updated++
 				} else {
-					fmt.Println("  ✓ Success")
-					successCount++
+					fmt.Println("  (skipped - dry run)")
+				}
+			}
+			counts.success = len(pending)
+		} else if len(pending) > 0 {
+			counts = runTransfers(pending, mode, applyJobs, runJournal)
+		}
+
+		prunedCount := 0
+		if len(orphans) > 0 {
+			fmt.Printf("\n=== ORPHANED TARGET FILES ===\n")
+			for _, orphan := range orphans {
+				switch {
+				case applyOrphans == orphansReport:
+					fmt.Printf("Orphan: %s\n", orphan)
+				case dryRun:
+					fmt.Printf("Would prune: %s\n", orphan)
+				default:
+					if err := runJournal.Prune(orphan); err != nil {
+						fmt.Printf("ERROR pruning %s: %v\n", orphan, err)
+						counts.errors++
+						continue
+					}
+					incremental.RemoveEmptyParents(orphan, applyTargetDir)
+					fmt.Printf("Pruned: %s\n", orphan)
+					prunedCount++
 				}
-			} else {
-				fmt.Println("  (skipped - dry run)")
-				successCount++
 			}
 		}
 
 		fmt.Printf("\n=== Summary ===\n")
 		fmt.Printf("Total files: %d\n", len(categorized))
-		fmt.Printf("Successful: %d\n", successCount)
-		if errorCount > 0 {
-			fmt.Printf("Errors: %d\n", errorCount)
+		fmt.Printf("Successful: %d\n", counts.success)
+		if incrementalSync {
+			fmt.Printf("  New: %d\n", counts.success-counts.updated)
+			fmt.Printf("  Updated: %d\n", counts.updated)
+			fmt.Printf("Skipped (unchanged): %d\n", unchangedCount)
+		}
+		if skippedCount > 0 {
+			fmt.Printf("Skipped (collisions): %d\n", skippedCount)
+		}
+		if duplicateCount > 0 {
+			fmt.Printf("Skipped (duplicates): %d\n", duplicateCount)
+		}
+		if applyOrphans == orphansReport && len(orphans) > 0 {
+			fmt.Printf("Orphaned target files: %d (use --orphans prune to remove them)\n", len(orphans))
+		} else if applyOrphans == orphansPrune && len(orphans) > 0 {
+			if dryRun {
+				fmt.Printf("Would prune: %d\n", len(orphans))
+			} else {
+				fmt.Printf("Pruned: %d\n", prunedCount)
+			}
+		}
+		if counts.fallback > 0 {
+			fmt.Printf("Fell back to copy: %d\n", counts.fallback)
+		}
+		if counts.errors > 0 {
+			fmt.Printf("Errors: %d\n", counts.errors)
 		}
 		if dryRun {
-			fmt.Println("\nThis was a dry run. Use without --dry-run to actually copy files.")
+			fmt.Println("\nThis was a dry run. Use without --dry-run to actually apply changes.")
+		} else {
+			if err := runJournal.Close(); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+			if len(runJournal.Entries) > 0 {
+				fmt.Printf("\nRun ID: %s (revert with 'sample-shifter undo %s --target %s')\n", runJournal.RunID, runJournal.RunID, applyTargetDir)
+			}
 		}
 
 		// Display statistics
@@ -143,6 +340,153 @@ or use a previously generated preview file.`,
 	},
 }
 
+// Values accepted by --orphans
+const (
+	orphansReport = "report"
+	orphansPrune  = "prune"
+)
+
+// transferJob is a file queued for transfer
+type transferJob struct {
+	file categorizer.CategorizedFile
+	// overwrite allows replacing an existing target file
+	overwrite bool
+	// updated marks an incremental update of an outdated target file
+	updated bool
+}
+
+// transferCounts tallies the outcome of a batch of transfers
+type transferCounts struct {
+	success  int
+	updated  int
+	fallback int
+	errors   int
+	// bytes is the size of the files transferred successfully
+	bytes int64
+}
+
+// runTransfers places files with a pool of jobs workers, reporting progress as a single
+// status line on a terminal and as one log line per file otherwise
+func runTransfers(jobsToRun []transferJob, mode transfer.Mode, jobs int, runJournal *journal.Journal) transferCounts {
+	// Sizes are read up front since a move removes the source
+	sizes := make([]int64, len(jobsToRun))
+	var totalBytes int64
+	for i, job := range jobsToRun {
+		if info, err := os.Stat(job.file.Sample.OriginalPath); err == nil {
+			sizes[i] = info.Size()
+			totalBytes += info.Size()
+		}
+	}
+
+	reporter := progress.New(os.Stdout, len(jobsToRun), totalBytes, progress.IsTerminal(os.Stdout))
+
+	var mu sync.Mutex
+	var counts transferCounts
+	work := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				job := jobsToRun[i]
+				cat := job.file
+				used, err := runJournal.Transfer(cat.Sample.OriginalPath, cat.TargetPath, mode, job.overwrite)
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					counts.errors++
+				case used != mode:
+					counts.fallback++
+					counts.success++
+				default:
+					counts.success++
+				}
+				if err == nil {
+					counts.bytes += sizes[i]
+				}
+				if err == nil && job.updated {
+					counts.updated++
+				}
+				mu.Unlock()
+
+				switch {
+				case err != nil:
+					reporter.Fail(sizes[i], "ERROR: %s\n  -> %s\n  %v", cat.Sample.OriginalPath, cat.TargetPath, err)
+				case used != mode:
+					reporter.Done(sizes[i], "%s: %s\n  -> %s (%s not supported here, used %s)", mode.Verb(), cat.Sample.OriginalPath, cat.TargetPath, mode, used)
+				default:
+					reporter.Done(sizes[i], "%s: %s\n  -> %s", mode.Verb(), cat.Sample.OriginalPath, cat.TargetPath)
+				}
+			}
+		}()
+	}
+
+	for i := range jobsToRun {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	elapsed := reporter.Finish()
+	fmt.Printf("\nTransferred %s in %s\n", progress.FormatBytes(counts.bytes), progress.FormatDuration(elapsed))
+	return counts
+}
+
+// dedupeCategorized drops every file that has identical content to another file in the
+// batch, keeping the canonical copy chosen by the policy; cat ranks categories for the
+// category-priority policy
+func dedupeCategorized(categorized []categorizer.CategorizedFile, policy dupes.Policy, cat *categorizer.Categorizer) []categorizer.CategorizedFile {
+	samples := make([]scanner.SampleFile, len(categorized))
+	categories := make(map[string]categorizer.Category)
+	for i, file := range categorized {
+		samples[i] = file.Sample
+		categories[file.Sample.OriginalPath] = file.Category
+	}
+
+	var rank dupes.Ranker
+	if policy == dupes.PolicyCategoryPriority {
+		rank = func(sample scanner.SampleFile) int {
+			return cat.Priority(categories[sample.OriginalPath])
+		}
+	}
+
+	fmt.Printf("\nHashing candidate files for duplicates...\n")
+	groups, errs := dupes.Find(samples, applyJobs)
+	for _, err := range errs {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	dupes.Canonicalize(groups, policy, rank)
+	redundant := dupes.Redundant(groups)
+
+	kept := make([]categorizer.CategorizedFile, 0, len(categorized))
+	for _, cat := range categorized {
+		if keep, ok := redundant[cat.Sample.OriginalPath]; ok {
+			fmt.Printf("Skipping duplicate: %s\n  (same content as %s)\n", cat.Sample.OriginalPath, keep)
+			continue
+		}
+		kept = append(kept, cat)
+	}
+	return kept
+}
+
+// confirmPrune asks before orphaned target files are removed
+func confirmPrune(count int) error {
+	fmt.Printf("\n⚠️  WARNING: %d file(s) in the target directory have no source and will be pruned.\n", count)
+	fmt.Println("   They are kept in the run's backups and can be restored with 'undo'.")
+	fmt.Print("Are you sure you want to continue? Type 'yes' to confirm: ")
+
+	var response string
+	fmt.Scanln(&response)
+
+	if strings.ToLower(strings.TrimSpace(response)) != "yes" {
+		return fmt.Errorf("pruning cancelled by user")
+	}
+	return nil
+}
+
 func cleanDirectory(targetDir string) error {
 	// Check if directory exists
 	if _, err := os.Stat(targetDir); os.IsNotExist(err) {
@@ -152,7 +496,8 @@ func cleanDirectory(targetDir string) error {
 
 	// Ask for confirmation
 	fmt.Printf("\n⚠️  WARNING: This will delete all contents in:\n")
-	fmt.Printf("   %s\n\n", targetDir)
+	fmt.Printf("   %s\n", targetDir)
+	fmt.Printf("   (the %s folder with undo journals is kept)\n\n", journal.DirName)
 	fmt.Print("Are you sure you want to continue? Type 'yes' to confirm: ")
 
 	var response string
@@ -162,42 +507,22 @@ func cleanDirectory(targetDir string) error {
 		return fmt.Errorf("cleaning cancelled by user")
 	}
 
-	// Remove the directory and all its contents
+	// Remove the directory contents, keeping the journals of earlier runs
 	fmt.Printf("\nCleaning target directory: %s\n", targetDir)
-	if err := os.RemoveAll(targetDir); err != nil {
-		return fmt.Errorf("failed to clean directory: %w", err)
-	}
-
-	fmt.Println("Target directory cleaned successfully.")
-	return nil
-}
-
-func copyFile(src, dst string) error {
-	// Create target directory if it doesn't exist
-	targetDir := filepath.Dir(dst)
-	if err := os.MkdirAll(targetDir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
-	}
-
-	// Open source file
-	sourceFile, err := os.Open(src)
+	entries, err := os.ReadDir(targetDir)
 	if err != nil {
-		return fmt.Errorf("failed to open source file: %w", err)
-	}
-	defer sourceFile.Close()
-
-	// Create destination file
-	destFile, err := os.Create(dst)
-	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
+		return fmt.Errorf("failed to clean directory: %w", err)
 	}
-	defer destFile.Close()
-
-	// Copy contents
-	if _, err := io.Copy(destFile, sourceFile); err != nil {
-		return fmt.Errorf("failed to copy file: %w", err)
+	for _, entry := range entries {
+		if entry.Name() == journal.DirName {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(targetDir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to clean directory: %w", err)
+		}
 	}
 
+	fmt.Println("Target directory cleaned successfully.")
 	return nil
 }
 
@@ -207,5 +532,15 @@ func init() {
 	applyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview what would be done without actually copying files")
 	applyCmd.Flags().BoolVar(&applyNormalizeFilenames, "normalize", false, "Normalize filenames (lowercase, spaces and underscores to dashes)")
 	applyCmd.Flags().BoolVar(&cleanTarget, "clean", false, "Clean target directory before copying files (requires confirmation)")
-	applyCmd.Flags().StringVarP(&applyConfigFile, "config", "c", "", "Path to category configuration JSON file (optional, uses default if not provided)")
+	applyCmd.Flags().StringVarP(&applyConfigFile, "config", "c", "", "Path to category configuration file (JSON, YAML or TOML; optional, discovered if not provided)")
+	applyCmd.Flags().StringVar(&applyLayoutTemplate, "layout", "", "Target path template, e.g. '{category}/{subcategory}/{bpm}/{name}{ext}' (overrides the config layout)")
+	applyCmd.Flags().BoolVar(&applyPathEvidence, "path-evidence", false, "Also match keywords against folder names, at a lower weight than the filename (overrides the config)")
+	applyCmd.Flags().StringVar(&applyMode, "mode", string(transfer.ModeCopy), "How files are placed in the target: copy, move, hardlink, symlink, symlink-relative, reflink")
+	applyCmd.Flags().IntVarP(&applyJobs, "jobs", "j", 4, "Number of files to transfer in parallel")
+	applyCmd.Flags().BoolVar(&applyIncremental, "incremental", false, "Skip files whose target already exists with the same size and modification time")
+	applyCmd.Flags().BoolVar(&applyChecksum, "checksum", false, "Like --incremental, but compare content hashes instead of modification times")
+	applyCmd.Flags().StringVar(&applyOrphans, "orphans", "", "Handle target files whose source no longer exists: report, prune")
+	applyCmd.Flags().BoolVar(&applyDedupe, "dedupe", false, "Transfer only one copy of files with identical content")
+	applyCmd.Flags().StringVar(&applyKeepPolicy, "keep", string(dupes.DefaultPolicy), "Which duplicate to keep with --dedupe: shortest-path, first-pack, category-priority")
+	applyCmd.Flags().StringVar(&applyCollisionPolicy, "on-collision", string(categorizer.DefaultCollisionPolicy), "How to handle files mapping to the same target path: skip, overwrite, rename, rename-with-pack-name, skip-if-identical")
 }
@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/theclifmeister/sample-shifter/internal/config"
+	"github.com/theclifmeister/sample-shifter/internal/lint"
+)
+
+var (
+	lintConfigFile string
+	lintJSON       bool
+	lintInfo       bool
+
+	showConfigFile string
+	showResolved   bool
+
+	dumpOutput string
+
+	schemaOutput string
+
+	migrateConfigFile string
+	migrateOutput     string
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and check category configurations",
+}
+
+var configLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Report rules of a configuration that can't fire or fire unpredictably",
+	Long: `Check a category configuration for:
+
+  duplicate-keyword              a keyword listed in two categories, or twice in one
+  shadowed-keyword               a file named after a keyword goes to another category
+  uncovered-subcategory-keyword  a subcategory keyword that no keyword of its category
+                                 matches, so it only fires together with one of them
+  duplicate-priority             two categories with the same priority
+  empty-subcategory              a subcategory without keywords
+  config-warning                 warnings found while loading the configuration
+
+uncovered-subcategory-keyword is informational, since subcategory keywords often
+only refine their category on purpose; list these notes with --info. Exits with
+status 1 when any other issue is found, so it can run in CI.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Keep JSON output clean for CI by reporting the configuration on stderr
+		out := io.Writer(os.Stdout)
+		if lintJSON {
+			out = os.Stderr
+		}
+		location := discoverConfig(lintConfigFile, ".", out)
+
+		cfg, err := config.LoadConfig(location.Path)
+		if err != nil {
+			fmt.Printf("Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		issues := lint.Lint(cfg)
+
+		if lintJSON {
+			if issues == nil {
+				issues = []lint.Issue{}
+			}
+			data, err := json.MarshalIndent(issues, "", "  ")
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+		} else {
+			for _, issue := range issues {
+				if issue.Severity == lint.SeverityWarning || lintInfo {
+					fmt.Println(issue)
+				}
+			}
+			if warnings := lint.Warnings(issues); warnings == 0 {
+				fmt.Println("No issues found")
+			} else {
+				fmt.Printf("\n%d issue(s) found\n", warnings)
+			}
+			if notes := len(issues) - lint.Warnings(issues); notes > 0 && !lintInfo {
+				fmt.Printf("%d informational note(s) not shown (use --info to list them)\n", notes)
+			}
+		}
+
+		if lint.Warnings(issues) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print a configuration as JSON",
+	Long: `Print a configuration file as JSON, whatever format it is written in.
+
+With --resolved the configurations it extends are merged in first and the
+result is validated, so the output is the effective configuration that
+preview and apply use. Without --config the configuration is discovered from
+the working directory like preview does; the file used is reported on stderr.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		location := discoverConfig(showConfigFile, ".", os.Stderr)
+
+		var cfg *config.CategoryConfig
+		var err error
+		if showResolved || location.Path == "" {
+			cfg, err = config.LoadConfig(location.Path)
+		} else {
+			cfg, err = config.ReadConfig(location.Path)
+		}
+		if err != nil {
+			fmt.Printf("Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		data, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	},
+}
+
+var configDumpDefaultCmd = &cobra.Command{
+	Use:   "dump-default",
+	Short: "Print the built-in default configuration",
+	Long: `Print the built-in default configuration exactly as it is embedded in the
+binary, as a starting point for a custom configuration. config-example.json in
+the repository is written with:
+
+  sample-shifter config dump-default --output config-example.json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		data := config.DefaultConfigJSON()
+		if dumpOutput == "" {
+			fmt.Print(string(data))
+			return
+		}
+
+		if err := os.WriteFile(dumpOutput, data, 0644); err != nil {
+			fmt.Printf("Error writing default configuration: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Default configuration written to: %s\n", dumpOutput)
+	},
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of configuration files",
+	Long: `Print a JSON Schema describing configuration files, for autocompletion and
+validation in editors. Point a JSON configuration at it with a "$schema" field:
+
+  sample-shifter config schema --output sample-shifter.schema.json
+
+  {
+    "$schema": "./sample-shifter.schema.json",
+    "categories": [...]
+  }
+
+For YAML, the YAML language server reads a comment at the top of the file:
+
+  # yaml-language-server: $schema=./sample-shifter.schema.json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		data := config.Schema()
+		if schemaOutput == "" {
+			fmt.Print(string(data))
+			return
+		}
+
+		if err := os.WriteFile(schemaOutput, data, 0644); err != nil {
+			fmt.Printf("Error writing schema: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Schema written to: %s\n", schemaOutput)
+	},
+}
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade a configuration file to the latest version",
+	Long: `Rewrite a configuration file in the latest version of the configuration
+format, in place or to --output. The file keeps its format (JSON, YAML or TOML)
+and the order of its categories, subcategories and keywords; comments in YAML and
+TOML files are not kept, with a warning. A file rewritten in place is first
+copied to <file>.bak, and the new file replaces it in a single rename. Older
+files are also upgraded in memory whenever they are loaded, with a warning.
+
+The configurations a file extends are not migrated with it; migrate them
+separately.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		location := discoverConfig(migrateConfigFile, ".", os.Stdout)
+		if location.Path == "" {
+			fmt.Printf("The built-in default configuration is always version %d\n", config.CurrentVersion)
+			return
+		}
+
+		info, err := os.Stat(location.Path)
+		if err != nil {
+			fmt.Printf("Error reading configuration: %v\n", err)
+			os.Exit(1)
+		}
+		data, err := os.ReadFile(location.Path)
+		if err != nil {
+			fmt.Printf("Error reading configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		migrated, from, changes, err := config.Migrate(data, config.FormatOf(location.Path))
+		if err != nil {
+			fmt.Printf("Error migrating configuration: %v\n", err)
+			os.Exit(1)
+		}
+		if from == config.CurrentVersion {
+			fmt.Printf("%s is already version %d\n", location.Path, from)
+			return
+		}
+
+		output := migrateOutput
+		if output == "" {
+			output = location.Path
+		}
+		inPlace := filepath.Clean(output) == filepath.Clean(location.Path)
+
+		// Keep the original when rewriting it, as comments and formatting are not kept
+		backup := location.Path + ".bak"
+		if inPlace {
+			if err := writeFileAtomic(backup, data, info.Mode().Perm()); err != nil {
+				fmt.Printf("Error backing up configuration: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if err := writeFileAtomic(output, migrated, info.Mode().Perm()); err != nil {
+			fmt.Printf("Error writing configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Migrated %s from version %d to version %d\n", location.Path, from, config.CurrentVersion)
+		for _, change := range changes {
+			fmt.Printf("  %s\n", change)
+		}
+		if inPlace {
+			fmt.Printf("Original kept as: %s\n", backup)
+		} else {
+			fmt.Printf("Written to: %s\n", output)
+		}
+		if config.HasComments(data, config.FormatOf(location.Path)) {
+			fmt.Printf("Warning: the comments in %s were not kept in the migrated file\n", location.Path)
+		}
+	},
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into place,
+// so that path is never left half written
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func init() {
+	configCmd.AddCommand(configLintCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configDumpDefaultCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	configCmd.AddCommand(configMigrateCmd)
+
+	configLintCmd.Flags().StringVarP(&lintConfigFile, "config", "c", "", "Path to category configuration file (JSON, YAML or TOML; optional, discovered like preview if not provided)")
+	configLintCmd.Flags().BoolVar(&lintJSON, "json", false, "Print the issues as JSON")
+	configLintCmd.Flags().BoolVar(&lintInfo, "info", false, "Also list informational notes")
+
+	configShowCmd.Flags().StringVarP(&showConfigFile, "config", "c", "", "Path to category configuration file (JSON, YAML or TOML; optional, discovered like preview if not provided)")
+	configShowCmd.Flags().BoolVar(&showResolved, "resolved", false, "Merge in the configurations it extends and validate the result")
+
+	configDumpDefaultCmd.Flags().StringVarP(&dumpOutput, "output", "o", "", "Write the configuration to a file instead of stdout")
+
+	configSchemaCmd.Flags().StringVarP(&schemaOutput, "output", "o", "", "Write the schema to a file instead of stdout")
+
+	configMigrateCmd.Flags().StringVarP(&migrateConfigFile, "config", "c", "", "Path to category configuration file (JSON, YAML or TOML; optional, discovered like preview if not provided)")
+	configMigrateCmd.Flags().StringVarP(&migrateOutput, "output", "o", "", "Write the migrated configuration to a file instead of rewriting it")
+}
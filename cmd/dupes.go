@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/theclifmeister/sample-shifter/internal/categorizer"
+	"github.com/theclifmeister/sample-shifter/internal/dupes"
+	"github.com/theclifmeister/sample-shifter/internal/progress"
+	"github.com/theclifmeister/sample-shifter/internal/scanner"
+)
+
+var (
+	dupesKeepPolicy string
+	dupesConfigFile string
+	dupesJobs       int
+)
+
+var dupesCmd = &cobra.Command{
+	Use:   "dupes [directory]",
+	Short: "Find audio files with identical content",
+	Long: `Find exact duplicates in a sample library by content hash (SHA-256).
+Only files that share their size with another file are hashed.
+
+For each group of duplicates one canonical copy is chosen with --keep; the
+other copies are listed as redundant. Use 'apply --dedupe' to transfer only
+the canonical copies.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sourceDir := args[0]
+
+		policy, err := dupes.ParsePolicy(dupesKeepPolicy)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Verify source directory exists
+		if _, err := os.Stat(sourceDir); os.IsNotExist(err) {
+			fmt.Printf("Error: Directory '%s' does not exist\n", sourceDir)
+			os.Exit(1)
+		}
+
+		cat := loadCategorizer(dupesConfigFile, sourceDir)
+		fmt.Printf("Scanning directory: %s\n", sourceDir)
+
+		samples, err := scanner.ScanDirectory(sourceDir)
+		if err != nil {
+			fmt.Printf("Error scanning directory: %v\n", err)
+			os.Exit(1)
+		}
+
+		groups, errs := findDuplicates(samples, policy, cat, dupesJobs)
+
+		if len(groups) == 0 {
+			fmt.Println("\nNo duplicates found.")
+		} else {
+			fmt.Printf("\n=== DUPLICATES ===\n\n")
+		}
+
+		var redundantFiles int
+		var wasted int64
+		for i, group := range groups {
+			fmt.Printf("Group %d: %d copies, %s each (sha256 %s)\n", i+1, len(group.Files), progress.FormatBytes(group.Size), group.Hash[:12])
+			fmt.Printf("  keep: %s\n", group.Files[0].OriginalPath)
+			for _, file := range group.Files[1:] {
+				fmt.Printf("  dupe: %s\n", file.OriginalPath)
+			}
+			fmt.Println()
+			redundantFiles += len(group.Files) - 1
+			wasted += group.Wasted()
+		}
+
+		if len(errs) > 0 {
+			fmt.Printf("Warning: %d file(s) could not be read:\n\n", len(errs))
+			for _, err := range errs {
+				fmt.Printf("  - %v\n", err)
+			}
+			fmt.Println()
+		}
+
+		fmt.Printf("=== Summary ===\n")
+		fmt.Printf("Files scanned: %d\n", len(samples))
+		fmt.Printf("Duplicate groups: %d\n", len(groups))
+		fmt.Printf("Redundant copies: %d\n", redundantFiles)
+		fmt.Printf("Reclaimable space: %s\n", progress.FormatBytes(wasted))
+	},
+}
+
+// findDuplicates groups identical samples and orders each group so the copy to keep comes
+// first. category-priority ranks files by the priority of the category they fall into.
+func findDuplicates(samples []scanner.SampleFile, policy dupes.Policy, cat *categorizer.Categorizer, jobs int) ([]dupes.Group, []error) {
+	fmt.Printf("Hashing candidate files...\n")
+
+	groups, errs := dupes.Find(samples, jobs)
+	dupes.Canonicalize(groups, policy, func(sample scanner.SampleFile) int {
+		return cat.Priority(cat.Categorize(sample, "", false).Category)
+	})
+	return groups, errs
+}
+
+func init() {
+	dupesCmd.Flags().StringVar(&dupesKeepPolicy, "keep", string(dupes.DefaultPolicy), "Which copy to keep: shortest-path, first-pack, category-priority")
+	dupesCmd.Flags().StringVarP(&dupesConfigFile, "config", "c", "", "Path to category configuration file (JSON, YAML or TOML; used by --keep category-priority, discovered if not provided)")
+	dupesCmd.Flags().IntVarP(&dupesJobs, "jobs", "j", 4, "Number of files to hash in parallel")
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/theclifmeister/sample-shifter/internal/categorizer"
+	"github.com/theclifmeister/sample-shifter/internal/scanner"
+	"github.com/theclifmeister/sample-shifter/internal/stats"
+)
+
+var (
+	explainConfigFile   string
+	explainPathEvidence bool
+	explainRoot         string
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <filename>",
+	Short: "Show how a filename is categorized, rule by rule",
+	Long: `Walk through every category and subcategory of the configuration for one
+filename and show which keywords matched, which exclusions rejected it and how
+the categories scored.
+
+The filename may include folders relative to the library root (--root, the
+current directory by default), e.g. 'Drums/Kicks/001.wav'; they are used as
+evidence with --path-evidence or for categories with a match_source. An
+absolute filename must be inside the library root. If the file exists,
+duration rules are evaluated with the length read from its audio header.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, relPath, err := explainPaths(explainRoot, args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		cat := loadCategorizer(explainConfigFile, filepath.Dir(path))
+		if cmd.Flags().Changed("path-evidence") {
+			cat.SetPathEvidence(explainPathEvidence)
+		}
+
+		sample := scanner.SampleFile{
+			OriginalPath: path,
+			FileName:     filepath.Base(path),
+			Extension:    filepath.Ext(path),
+			RelativePath: relPath,
+		}
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			sample.Audio, _ = scanner.ReadAudioInfo(path)
+		}
+
+		explanation := cat.Explain(sample)
+
+		fmt.Printf("Explaining: %s\n\n", relPath)
+		for _, category := range explanation.Categories {
+			printCategoryExplanation(category)
+		}
+
+		result := explanation.Result
+		fmt.Printf("\nResult: %s", result.Category)
+		if result.Subcategory != "" {
+			fmt.Printf("/%s", result.Subcategory)
+		}
+		fmt.Println()
+		if result.MatchSource != "" {
+			fmt.Printf("  decided by: %s\n", result.MatchSource)
+			fmt.Printf("  confidence: %.0f%%\n", result.Confidence*100)
+		}
+		if len(result.Matches) > 0 {
+			fmt.Printf("  matches:    %s\n", stats.FormatMatches(result.Matches))
+		}
+	},
+}
+
+// explainPaths resolves the filename to explain against the library root. It returns the
+// path of the file and its path relative to the root, whose folders count as evidence.
+func explainPaths(root, name string) (path, relPath string, err error) {
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(root, name)
+	}
+	path = filepath.Clean(name)
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve library root: %w", err)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve filename: %w", err)
+	}
+	relPath, err = filepath.Rel(absRoot, absPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", "", fmt.Errorf("%s is not inside the library root %s (set it with --root)", path, absRoot)
+	}
+	return path, relPath, nil
+}
+
+// printCategoryExplanation prints one category's evaluation: its score and the keywords,
+// exclusions and subcategories involved
+func printCategoryExplanation(category categorizer.CategoryExplanation) {
+	fmt.Printf("%s (priority %d, %s): ", category.Category, category.Priority, category.Source)
+	if category.Score > 0 {
+		fmt.Printf("score %.2f\n", category.Score)
+	} else {
+		fmt.Println("no match")
+	}
+
+	for _, match := range category.Matches {
+		fmt.Printf("  + %s (%.2f)\n", stats.FormatMatch(match), match.Score)
+	}
+	for _, match := range category.Excluded {
+		fmt.Printf("  - excluded by %s\n", stats.FormatMatch(match))
+	}
+
+	for _, sub := range category.Subcategories {
+		if len(sub.Matches) == 0 && len(sub.Excluded) == 0 {
+			continue
+		}
+		fmt.Printf("  subcategory %s:\n", sub.Name)
+		for _, match := range sub.Matches {
+			fmt.Printf("    + %s\n", stats.FormatMatch(match))
+		}
+		for _, match := range sub.Excluded {
+			fmt.Printf("    - excluded by %s\n", stats.FormatMatch(match))
+		}
+	}
+}
+
+func init() {
+	explainCmd.Flags().StringVarP(&explainConfigFile, "config", "c", "", "Path to category configuration file (JSON, YAML or TOML; optional, discovered if not provided)")
+	explainCmd.Flags().BoolVar(&explainPathEvidence, "path-evidence", false, "Also match keywords against the folders in the filename (overrides the config)")
+	explainCmd.Flags().StringVar(&explainRoot, "root", ".", "Library root that the folders of the filename are relative to")
+}
@@ -1,15 +1,16 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"github.com/theclifmeister/sample-shifter/internal/categorizer"
+	"github.com/theclifmeister/sample-shifter/internal/config"
 	"github.com/theclifmeister/sample-shifter/internal/scanner"
 	"github.com/theclifmeister/sample-shifter/internal/stats"
+	"github.com/theclifmeister/sample-shifter/internal/transfer"
 )
 
 var (
@@ -17,6 +18,12 @@ var (
 	outputFile         string
 	normalizeFilenames bool
 	configFile         string
+	layoutTemplate     string
+	collisionPolicy    string
+	pathEvidence       bool
+	minConfidence      float64
+	explainMatches     bool
+	previewMode        string
 )
 
 var previewCmd = &cobra.Command{
@@ -39,6 +46,8 @@ This shows where each file will be copied to when you run the apply command.`,
 			os.Exit(1)
 		}
 
+		// Create categorizer with config
+		cat := loadCategorizer(configFile, sourceDir)
 		fmt.Printf("Scanning: %s\n", sourceDir)
 		fmt.Printf("Target: %s\n\n", targetDir)
 
@@ -54,39 +63,74 @@ This shows where each file will be copied to when you run the apply command.`,
 			return
 		}
 
-		// Create categorizer with config
-		cat, err := categorizer.NewCategorizerFromFile(configFile)
+		if unreadable := countAudioErrors(samples); unreadable > 0 {
+			fmt.Printf("Warning: %d file(s) have unreadable audio headers (run 'scan' for details)\n\n", unreadable)
+		}
+
+		if layoutTemplate != "" {
+			if err := cat.SetLayout(layoutTemplate); err != nil {
+				fmt.Printf("Error: invalid --layout: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if cmd.Flags().Changed("path-evidence") {
+			cat.SetPathEvidence(pathEvidence)
+		}
+
+		if minConfidence < 0 || minConfidence > 1 {
+			fmt.Println("Error: --min-confidence must be between 0 and 1")
+			os.Exit(1)
+		}
+
+		policy, err := categorizer.ParseCollisionPolicy(collisionPolicy)
 		if err != nil {
-			fmt.Printf("Error loading configuration: %v\n", err)
+			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
 
-		// Categorize files
+		mode, err := transfer.ParseMode(previewMode)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Categorize files and resolve clashing target paths
 		categorized := cat.CategorizeBatch(samples, targetDir, normalizeFilenames)
+		categorized = categorizer.ResolveCollisions(categorized, policy, true, nil)
 
 		// Display initial summary
 		fmt.Printf("Preview: Found %d file(s) to categorize\n\n", len(categorized))
 
 		// Display detailed file list first
-		stats.DisplayDetailedFileList(categorized)
+		stats.DisplayDetailedFileList(categorized, explainMatches)
+
+		// List uncertain categorizations for review
+		threshold := cat.Threshold()
+		if cmd.Flags().Changed("min-confidence") {
+			threshold = minConfidence
+		}
+		stats.DisplayLowConfidence(categorized, threshold)
+
+		// Report target path collisions before the summary
+		stats.DisplayCollisions(categorized)
 
 		// Display statistics summary at the end
 		stats.DisplayStats(categorized)
 
 		// Save preview to file if requested
 		if outputFile != "" {
-			savePreview(categorized, outputFile)
+			absSource, err := filepath.Abs(sourceDir)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			settings := categorizer.ManifestSettings{OnCollision: policy, Mode: mode, SourceDir: absSource}
+			savePreview(categorizer.Manifest{ManifestSettings: settings, Files: categorized}, outputFile)
 		}
 	},
 }
 
-func savePreview(categorized []categorizer.CategorizedFile, filename string) {
-	data, err := json.MarshalIndent(categorized, "", "  ")
-	if err != nil {
-		fmt.Printf("Error creating preview file: %v\n", err)
-		return
-	}
-
+func savePreview(manifest categorizer.Manifest, filename string) {
 	// Ensure directory exists
 	dir := filepath.Dir(filename)
 	if dir != "." && dir != "" {
@@ -96,12 +140,12 @@ func savePreview(categorized []categorizer.CategorizedFile, filename string) {
 		}
 	}
 
-	if err := os.WriteFile(filename, data, 0644); err != nil {
+	if err := categorizer.SaveManifest(filename, manifest); err != nil {
 		fmt.Printf("Error saving preview file: %v\n", err)
 		return
 	}
 
-	fmt.Printf("Preview saved to: %s\n", filename)
+	fmt.Printf("Preview saved to: %s (settings in %s)\n", filename, categorizer.SettingsPath(filename))
 	fmt.Println("Use this file with the 'apply' command to execute the categorization.")
 }
 
@@ -109,5 +153,11 @@ func init() {
 	previewCmd.Flags().StringVarP(&targetDir, "target", "t", "", "Target directory for organized samples (required)")
 	previewCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Save preview to JSON file for later use with apply command")
 	previewCmd.Flags().BoolVar(&normalizeFilenames, "normalize", false, "Normalize filenames (lowercase, spaces and underscores to dashes)")
-	previewCmd.Flags().StringVarP(&configFile, "config", "c", "", "Path to category configuration JSON file (optional, uses default if not provided)")
+	previewCmd.Flags().StringVarP(&configFile, "config", "c", "", "Path to category configuration file (JSON, YAML or TOML; optional, discovered if not provided)")
+	previewCmd.Flags().StringVar(&layoutTemplate, "layout", "", "Target path template, e.g. '{category}/{subcategory}/{bpm}/{name}{ext}' (overrides the config layout)")
+	previewCmd.Flags().BoolVar(&pathEvidence, "path-evidence", false, "Also match keywords against folder names, at a lower weight than the filename (overrides the config)")
+	previewCmd.Flags().Float64Var(&minConfidence, "min-confidence", config.DefaultConfidenceThreshold, "List files categorized with a lower confidence (0-1) for review (overrides the config)")
+	previewCmd.Flags().BoolVar(&explainMatches, "explain", false, "Show the keywords that decided each file's category and subcategory")
+	previewCmd.Flags().StringVar(&previewMode, "mode", string(transfer.ModeCopy), "How apply --preview-file places files: copy, move, hardlink, symlink, symlink-relative, reflink (saved with --output)")
+	previewCmd.Flags().StringVar(&collisionPolicy, "on-collision", string(categorizer.DefaultCollisionPolicy), "How to handle files mapping to the same target path: skip, overwrite, rename, rename-with-pack-name, skip-if-identical")
 }
@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/theclifmeister/sample-shifter/internal/categorizer"
+	"github.com/theclifmeister/sample-shifter/internal/config"
 )
 
 var rootCmd = &cobra.Command{
@@ -23,8 +26,39 @@ func Execute() {
 	}
 }
 
+// discoverConfig chooses the configuration file for a command working on startDir (see
+// config.Discover), exiting on errors, and prints which one is used to out
+func discoverConfig(flagPath, startDir string, out io.Writer) config.Location {
+	location, err := config.Discover(flagPath, startDir)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(out, "Using configuration: %s\n", location)
+	return location
+}
+
+// loadCategorizer loads the configuration discovered for startDir, exiting on errors, and
+// prints the warnings found while validating it
+func loadCategorizer(flagPath, startDir string) *categorizer.Categorizer {
+	location := discoverConfig(flagPath, startDir, os.Stdout)
+	cat, err := categorizer.NewCategorizerFromFile(location.Path)
+	if err != nil {
+		fmt.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+	for _, warning := range cat.Warnings() {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	return cat
+}
+
 func init() {
 	rootCmd.AddCommand(scanCmd)
 	rootCmd.AddCommand(previewCmd)
 	rootCmd.AddCommand(applyCmd)
+	rootCmd.AddCommand(undoCmd)
+	rootCmd.AddCommand(dupesCmd)
+	rootCmd.AddCommand(explainCmd)
+	rootCmd.AddCommand(configCmd)
 }
@@ -32,8 +32,46 @@ var scanCmd = &cobra.Command{
 
 		fmt.Printf("Found %d audio sample file(s):\n\n", len(samples))
 
+		var unreadable []scanner.SampleFile
 		for _, sample := range samples {
-			fmt.Printf("  - %s\n", sample.OriginalPath)
+			if sample.Audio != nil {
+				fmt.Printf("  - %s (%s)\n", sample.OriginalPath, formatAudioInfo(sample.Audio))
+			} else {
+				fmt.Printf("  - %s\n", sample.OriginalPath)
+			}
+			if sample.AudioError != "" {
+				unreadable = append(unreadable, sample)
+			}
+		}
+
+		if len(unreadable) > 0 {
+			fmt.Printf("\nWarning: %d file(s) have unreadable audio headers:\n\n", len(unreadable))
+			for _, sample := range unreadable {
+				fmt.Printf("  - %s: %s\n", sample.OriginalPath, sample.AudioError)
+			}
 		}
 	},
 }
+
+// formatAudioInfo renders audio header metadata as a short human-readable string
+func formatAudioInfo(info *scanner.AudioInfo) string {
+	channels := fmt.Sprintf("%dch", info.Channels)
+	switch info.Channels {
+	case 1:
+		channels = "mono"
+	case 2:
+		channels = "stereo"
+	}
+	return fmt.Sprintf("%.2fs, %d Hz, %d-bit, %s", info.Duration.Seconds(), info.SampleRate, info.BitDepth, channels)
+}
+
+// countAudioErrors returns how many samples had a supported but unreadable audio header
+func countAudioErrors(samples []scanner.SampleFile) int {
+	count := 0
+	for _, sample := range samples {
+		if sample.AudioError != "" {
+			count++
+		}
+	}
+	return count
+}
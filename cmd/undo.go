@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/theclifmeister/sample-shifter/internal/journal"
+)
+
+var (
+	undoTargetDir string
+	undoDryRun    bool
+	undoList      bool
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo [run-id]",
+	Short: "Revert a previous apply run",
+	Long: `Revert an apply run using the journal it wrote into the target directory.
+Files created by the run are removed (moved files are moved back to their source),
+overwritten files are restored and directories left empty are removed.
+
+Files that were modified after the run are left untouched. Without a run ID the
+most recent run is reverted.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if undoTargetDir == "" {
+			fmt.Println("Error: --target flag is required")
+			os.Exit(1)
+		}
+
+		if undoList {
+			listRuns(undoTargetDir)
+			return
+		}
+
+		var j *journal.Journal
+		var err error
+		if len(args) == 1 {
+			j, err = journal.Load(undoTargetDir, args[0])
+		} else {
+			j, err = journal.Latest(undoTargetDir)
+		}
+		if errors.Is(err, journal.ErrNoJournal) {
+			fmt.Println("No apply runs to undo.")
+			return
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if undoDryRun {
+			fmt.Println("=== DRY RUN MODE - No files will be changed ===")
+		}
+		fmt.Printf("Undoing run %s (%s, %s, %d file(s))\n\n", j.RunID, j.Timestamp.Format("2006-01-02 15:04:05"), j.Mode, len(j.Entries))
+
+		results, err := journal.Undo(j, undoDryRun)
+
+		counts := make(map[string]int)
+		for _, result := range results {
+			counts[result.Action]++
+			switch result.Action {
+			case journal.UndoRemoved:
+				fmt.Printf("Removing: %s\n", result.Entry.Target)
+			case journal.UndoRestored:
+				if result.Entry.Pruned {
+					fmt.Printf("Restoring pruned: %s\n", result.Entry.Target)
+					break
+				}
+				fmt.Printf("Moving back: %s\n  -> %s\n", result.Entry.Target, result.Entry.Source)
+			case journal.UndoMissing:
+				fmt.Printf("Already gone: %s\n", result.Entry.Target)
+			default:
+				fmt.Printf("Keeping: %s\n  (%s: %s)\n", result.Entry.Target, result.Action, result.Reason)
+			}
+			if result.Entry.Backup != "" && !result.Entry.Pruned && result.Done() {
+				fmt.Println("  (previous version restored)")
+			}
+		}
+
+		fmt.Printf("\n=== Summary ===\n")
+		if counts[journal.UndoRemoved] > 0 || counts[journal.UndoRestored] == 0 {
+			fmt.Printf("Removed: %d\n", counts[journal.UndoRemoved])
+		}
+		if counts[journal.UndoRestored] > 0 {
+			fmt.Printf("Restored: %d\n", counts[journal.UndoRestored])
+		}
+		if counts[journal.UndoMissing] > 0 {
+			fmt.Printf("Already gone: %d\n", counts[journal.UndoMissing])
+		}
+		if kept := counts[journal.UndoRefused] + counts[journal.UndoFailed]; kept > 0 {
+			fmt.Printf("Kept (modified or failed): %d\n", kept)
+			if !undoDryRun {
+				fmt.Println("\nThe run's journal was kept; fix the files above and run undo again to finish.")
+			}
+		}
+		if undoDryRun {
+			fmt.Println("\nThis was a dry run. Use without --dry-run to actually undo the run.")
+		}
+
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// listRuns prints the apply runs recorded in a target directory
+func listRuns(targetDir string) {
+	journals, err := journal.List(targetDir)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(journals) == 0 {
+		fmt.Println("No apply runs recorded.")
+		return
+	}
+
+	fmt.Printf("%-24s %-20s %-17s %s\n", "Run ID", "Time", "Mode", "Files")
+	for _, j := range journals {
+		fmt.Printf("%-24s %-20s %-17s %d\n", j.RunID, j.Timestamp.Format("2006-01-02 15:04:05"), j.Mode, len(j.Entries))
+	}
+}
+
+func init() {
+	undoCmd.Flags().StringVarP(&undoTargetDir, "target", "t", "", "Target directory the run was applied to (required)")
+	undoCmd.Flags().BoolVar(&undoDryRun, "dry-run", false, "Show what would be undone without changing anything")
+	undoCmd.Flags().BoolVar(&undoList, "list", false, "List the recorded runs instead of undoing one")
+}
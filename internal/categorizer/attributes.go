@@ -0,0 +1,229 @@
+package categorizer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MusicalAttributes holds tempo and key information extracted from a filename
+type MusicalAttributes struct {
+	BPM     int
+	Key     string
+	Camelot string
+
+	// keyStart and keyEnd locate the key notation in the parsed name (-1 when absent)
+	keyStart int
+	keyEnd   int
+}
+
+// Tempo range accepted for BPM values
+const (
+	minBPM = 40
+	maxBPM = 300
+	// bare numbers without a "bpm" marker are only trusted within a narrower range
+	minBareBPM = 60
+	maxBareBPM = 200
+)
+
+var (
+	attributeSeparators = regexp.MustCompile(`[^\s_\-.,()\[\]{}]+`)
+	bpmSuffixPattern    = regexp.MustCompile(`(?i)^(\d{2,3})bpm$`)
+	bpmPrefixPattern    = regexp.MustCompile(`(?i)^bpm(\d{2,3})$`)
+	numberPattern       = regexp.MustCompile(`^\d{2,3}$`)
+	keyPattern          = regexp.MustCompile(`^([A-Ga-g])(#|♯|b|♭|sharp|flat)?(maj|major|min|minor|m)?$`)
+	camelotPattern      = regexp.MustCompile(`^(1[0-2]|[1-9])([AB])$`)
+)
+
+// notePitchClasses maps note letters to their pitch class (C = 0)
+var notePitchClasses = map[byte]int{
+	'C': 0, 'D': 2, 'E': 4, 'F': 5, 'G': 7, 'A': 9, 'B': 11,
+}
+
+// camelotMajorKeys and camelotMinorKeys list the conventional key names for Camelot codes 1-12
+var (
+	camelotMajorKeys = []string{"B", "F#", "Db", "Ab", "Eb", "Bb", "F", "C", "G", "D", "A", "E"}
+	camelotMinorKeys = []string{"G#m", "Ebm", "Bbm", "Fm", "Cm", "Gm", "Dm", "Am", "Em", "Bm", "F#m", "C#m"}
+)
+
+// attributeToken is a word of a filename together with its byte offsets
+type attributeToken struct {
+	text  string
+	start int
+	end   int
+}
+
+// ParseMusicalAttributes extracts BPM and musical key from a filename (without extension).
+// Recognized notations include "128bpm", "bpm128", "128 bpm", "Am", "F#min", "Bb",
+// "C major", "Ebm" and Camelot codes such as "8A". Bare numbers are only read as a
+// tempo when next to a key, and bare note letters only as a key when next to a tempo.
+func ParseMusicalAttributes(name string) MusicalAttributes {
+	attrs := MusicalAttributes{keyStart: -1, keyEnd: -1}
+
+	var tokens []attributeToken
+	for _, loc := range attributeSeparators.FindAllStringIndex(name, -1) {
+		tokens = append(tokens, attributeToken{text: name[loc[0]:loc[1]], start: loc[0], end: loc[1]})
+	}
+
+	// First pass: explicit tempo markers
+	bpmIndex := -1
+	for i, token := range tokens {
+		if bpm := parseExplicitBPM(tokens, i); bpm > 0 {
+			attrs.BPM = bpm
+			bpmIndex = i
+			if strings.EqualFold(token.text, "bpm") {
+				bpmIndex = i - 1
+			}
+			break
+		}
+	}
+
+	// Second pass: key notation, allowing bare notes only next to an explicit tempo
+	keyIndex, keyLast := -1, -1
+	for i, token := range tokens {
+		if camelot := camelotPattern.FindStringSubmatch(token.text); camelot != nil {
+			number, _ := strconv.Atoi(camelot[1])
+			attrs.Camelot = token.text
+			if camelot[2] == "A" {
+				attrs.Key = camelotMinorKeys[number-1]
+			} else {
+				attrs.Key = camelotMajorKeys[number-1]
+			}
+			attrs.keyStart, attrs.keyEnd = token.start, token.end
+			keyIndex, keyLast = i, i
+			break
+		}
+
+		// Two-word notations such as "A minor" or "C maj"
+		if i+1 < len(tokens) {
+			if quality := parseQualityWord(tokens[i+1].text); quality != "" {
+				if key, ok := parseKey(token.text+quality, true); ok {
+					attrs.Key = key
+					attrs.keyStart, attrs.keyEnd = token.start, tokens[i+1].end
+					keyIndex, keyLast = i, i+1
+					break
+				}
+			}
+		}
+
+		adjacentToTempo := bpmIndex >= 0 && (i == bpmIndex-1 || i == bpmIndex+1 || i == bpmIndex+2 && strings.EqualFold(tokens[bpmIndex+1].text, "bpm"))
+		if key, ok := parseKey(token.text, adjacentToTempo); ok {
+			attrs.Key = key
+			attrs.keyStart, attrs.keyEnd = token.start, token.end
+			keyIndex, keyLast = i, i
+			break
+		}
+	}
+
+	if attrs.Key != "" && attrs.Camelot == "" {
+		attrs.Camelot = camelotCode(attrs.Key)
+	}
+
+	// Third pass: a bare number right next to the key is read as the tempo
+	if attrs.BPM == 0 && keyIndex >= 0 {
+		for _, i := range []int{keyIndex - 1, keyLast + 1} {
+			if i < 0 || i >= len(tokens) || !numberPattern.MatchString(tokens[i].text) {
+				continue
+			}
+			if bpm, _ := strconv.Atoi(tokens[i].text); bpm >= minBareBPM && bpm <= maxBareBPM {
+				attrs.BPM = bpm
+				break
+			}
+		}
+	}
+
+	return attrs
+}
+
+// parseExplicitBPM returns the tempo if the token at index i carries a "bpm" marker
+func parseExplicitBPM(tokens []attributeToken, i int) int {
+	text := tokens[i].text
+	var digits string
+	if match := bpmSuffixPattern.FindStringSubmatch(text); match != nil {
+		digits = match[1]
+	} else if match := bpmPrefixPattern.FindStringSubmatch(text); match != nil {
+		digits = match[1]
+	} else if strings.EqualFold(text, "bpm") && i > 0 && numberPattern.MatchString(tokens[i-1].text) {
+		digits = tokens[i-1].text
+	}
+	if digits == "" {
+		return 0
+	}
+
+	bpm, _ := strconv.Atoi(digits)
+	if bpm < minBPM || bpm > maxBPM {
+		return 0
+	}
+	return bpm
+}
+
+// parseQualityWord returns the key quality suffix for a standalone "major"/"minor" word
+func parseQualityWord(word string) string {
+	switch strings.ToLower(word) {
+	case "min", "minor":
+		return "min"
+	case "maj", "major":
+		return "maj"
+	}
+	return ""
+}
+
+// parseKey parses a single token as a key and returns its canonical form (e.g. "F#m", "Bb").
+// Lowercase note letters and bare note letters are ambiguous with ordinary words, so
+// they are only accepted with an explicit accidental or quality, or when allowBare is set.
+func parseKey(token string, allowBare bool) (string, bool) {
+	match := keyPattern.FindStringSubmatch(token)
+	if match == nil {
+		return "", false
+	}
+	letter, accidental, quality := match[1], match[2], match[3]
+
+	upper := letter == strings.ToUpper(letter)
+	explicitQuality := quality != "" && quality != "m"
+	switch {
+	case accidental == "" && quality == "":
+		if !upper || !allowBare {
+			return "", false
+		}
+	case !upper && !explicitQuality && accidental != "#" && accidental != "♯":
+		return "", false
+	}
+
+	key := strings.ToUpper(letter)
+	switch accidental {
+	case "#", "♯", "sharp":
+		key += "#"
+	case "b", "♭", "flat":
+		key += "b"
+	}
+	if quality == "m" || quality == "min" || quality == "minor" {
+		key += "m"
+	}
+	return key, true
+}
+
+// camelotCode returns the Camelot wheel code for a canonical key name
+func camelotCode(key string) string {
+	minor := strings.HasSuffix(key, "m")
+	note := strings.TrimSuffix(key, "m")
+
+	pitchClass := notePitchClasses[note[0]]
+	if len(note) > 1 {
+		if note[1] == '#' {
+			pitchClass++
+		} else {
+			pitchClass--
+		}
+	}
+	if minor {
+		// Minor keys share their wheel position with the relative major
+		pitchClass += 3
+	}
+	pitchClass = (pitchClass%12 + 12) % 12
+
+	number := (pitchClass*7%12+7)%12 + 1
+	if minor {
+		return strconv.Itoa(number) + "A"
+	}
+	return strconv.Itoa(number) + "B"
+}
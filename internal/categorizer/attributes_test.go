@@ -0,0 +1,66 @@
+package categorizer
+
+import "testing"
+
+func TestParseMusicalAttributes(t *testing.T) {
+	tests := []struct {
+		name    string
+		bpm     int
+		key     string
+		camelot string
+	}{
+		{"Bass_Loop_Am_128bpm", 128, "Am", "8A"},
+		{"Lead 140 F#min", 140, "F#m", "11A"},
+		{"Pad_Cmaj_90BPM", 90, "C", "8B"},
+		{"Keys Bb 120 bpm", 120, "Bb", "6B"},
+		{"Arp_Ebm_bpm174", 174, "Ebm", "2A"},
+		{"Chords A minor 100", 100, "Am", "8A"},
+		{"Synth_8A_124bpm", 124, "Am", "8A"},
+		{"Pluck 2B", 0, "F#", "2B"},
+		{"Lead_128bpm_G", 128, "G", "9B"},
+		{"Guitar_c#m", 0, "C#m", "12A"},
+		{"Stab_Eb_minor", 0, "Ebm", "2A"},
+		// Ambiguous fragments are not treated as keys or tempos
+		{"Kick 01", 0, "", ""},
+		{"Snare B 100", 0, "", ""},
+		{"Tom 140", 0, "", ""},
+		{"FM Bass", 0, "", ""},
+		{"bass am i", 0, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := ParseMusicalAttributes(tt.name)
+			if attrs.BPM != tt.bpm {
+				t.Errorf("Expected BPM %d, got %d", tt.bpm, attrs.BPM)
+			}
+			if attrs.Key != tt.key {
+				t.Errorf("Expected key %q, got %q", tt.key, attrs.Key)
+			}
+			if attrs.Camelot != tt.camelot {
+				t.Errorf("Expected Camelot code %q, got %q", tt.camelot, attrs.Camelot)
+			}
+		})
+	}
+}
+
+func TestNormalizeFileNamePreservesKey(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Bass_Loop_Am_128bpm.wav", "bass-loop-Am-128bpm.wav"},
+		{"Lead 140 F#min.wav", "lead-140-F#m.wav"},
+		{"Synth_8A_Pluck.wav", "synth-8A-pluck.wav"},
+		{"Kick_Hard_01.WAV", "kick-hard-01.WAV"},
+		{"  Snare__Top  .wav", "snare-top.wav"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := NormalizeFileName(tt.input); got != tt.expected {
+				t.Errorf("NormalizeFileName(%q) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
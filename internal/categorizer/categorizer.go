@@ -1,12 +1,18 @@
 package categorizer
 
 import (
+	"fmt"
+	"math"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/theclifmeister/sample-shifter/internal/config"
+	"github.com/theclifmeister/sample-shifter/internal/keyword"
+	"github.com/theclifmeister/sample-shifter/internal/layout"
 	"github.com/theclifmeister/sample-shifter/internal/scanner"
 )
 
@@ -35,18 +41,226 @@ type CategorizedFile struct {
 	Category    Category
 	Subcategory string
 	TargetPath  string
+	// BPM, Key and Camelot are extracted from the filename; zero values mean not found
+	BPM     int    `json:",omitempty"`
+	Key     string `json:",omitempty"`
+	Camelot string `json:",omitempty"`
+	// MatchSource tells what decided the category: SourceFilename, SourcePath or
+	// SourceDuration; empty for uncategorized files
+	MatchSource string `json:",omitempty"`
+	// Confidence (0-1) rates how clearly Category beat the other Candidates, which are
+	// ordered by score with the chosen category first
+	Confidence float64     `json:",omitempty"`
+	Candidates []Candidate `json:",omitempty"`
+	// Matches are the rules that decided Category and Subcategory
+	Matches []KeywordMatch `json:",omitempty"`
+	// Tags are every matching category and subcategory plus the tempo and key, for
+	// searching; the folder placement only uses Category (see collectTags)
+	Tags []string `json:",omitempty"`
+	// Collision is set when the target path clashed with another file (see ResolveCollisions)
+	Collision *CollisionInfo `json:",omitempty"`
 }
 
+// Sources of a categorization, see CategorizedFile.MatchSource
+const (
+	SourceFilename = "filename"
+	SourcePath     = "path"
+	SourceDuration = "duration"
+)
+
 // Categorizer handles the categorization of sample files using a configuration
 type Categorizer struct {
 	config *config.CategoryConfig
+	layout *layout.Template
+	// pathEvidence is the config-wide default for matching folder names
+	pathEvidence bool
+	// categories holds the compiled keyword matchers, sorted by priority
+	categories []compiledCategory
+}
+
+// compiledCategory is a category definition with its keywords compiled for matching
+type compiledCategory struct {
+	def           config.CategoryDefinition
+	keywords      []*keyword.Matcher
+	excludes      []*keyword.Matcher
+	subcategories []compiledSubcategory
+}
+
+// compiledSubcategory is a subcategory with its keywords compiled for matching
+type compiledSubcategory struct {
+	// path is the slash-separated path below the category, e.g. "cymbal/crash"
+	path string
+	def  config.SubcategoryDefinition
+	// keywords holds the subcategory's own keywords followed by those of its nested
+	// subcategories, so "crash" also selects "cymbal"
+	keywords []*keyword.Matcher
+	excludes []*keyword.Matcher
+	children []compiledSubcategory
 }
 
-// NewCategorizer creates a new Categorizer with the given configuration
-func NewCategorizer(cfg *config.CategoryConfig) *Categorizer {
+// walk calls fn for the subcategory and every nested subcategory, parents first
+func (sub compiledSubcategory) walk(fn func(compiledSubcategory)) {
+	fn(sub)
+	for _, child := range sub.children {
+		child.walk(fn)
+	}
+}
+
+// NewCategorizer creates a new Categorizer with the given configuration.
+// The configured layout is used for target paths, or the default layout when none is
+// set; an invalid layout is an error rather than a silent fallback.
+func NewCategorizer(cfg *config.CategoryConfig) (*Categorizer, error) {
+	source := cfg.Layout
+	if source == "" {
+		source = layout.DefaultTemplate
+	}
+	template, err := layout.Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid layout: %w", err)
+	}
+	categories, err := compileCategories(cfg)
+	if err != nil {
+		return nil, err
+	}
 	return &Categorizer{
-		config: cfg,
+		config:       cfg,
+		layout:       template,
+		pathEvidence: cfg.UsesPathEvidence(),
+		categories:   categories,
+	}, nil
+}
+
+// compileCategories compiles every keyword once and sorts categories by priority.
+// A keyword that cannot be compiled is an error naming its category, as configurations
+// built in code are not validated like files.
+func compileCategories(cfg *config.CategoryConfig) ([]compiledCategory, error) {
+	compiled := make([]compiledCategory, 0, len(cfg.Categories))
+	for _, def := range cfg.Categories {
+		keywords, err := compileKeywords(def.Keywords)
+		if err != nil {
+			return nil, fmt.Errorf("category %q: %w", def.Name, err)
+		}
+		excludes, err := compileKeywords(def.ExcludeKeywords)
+		if err != nil {
+			return nil, fmt.Errorf("category %q: exclude_keywords: %w", def.Name, err)
+		}
+		subcategories, err := compileSubcategories(def.Subcategories, "")
+		if err != nil {
+			return nil, fmt.Errorf("category %q: %w", def.Name, err)
+		}
+
+		compiled = append(compiled, compiledCategory{
+			def:           def,
+			keywords:      keywords,
+			excludes:      excludes,
+			subcategories: subcategories,
+		})
+	}
+
+	sort.SliceStable(compiled, func(i, j int) bool {
+		return compiled[i].def.Priority < compiled[j].def.Priority
+	})
+	return compiled, nil
+}
+
+// compileSubcategories compiles subcategories at any depth, keeping their order;
+// prefix is the path of the parent subcategory
+func compileSubcategories(subcategories config.Subcategories, prefix string) ([]compiledSubcategory, error) {
+	var compiled []compiledSubcategory
+	for _, sub := range subcategories {
+		path := prefix + sub.Name
+		children, err := compileSubcategories(sub.Subcategories, path+"/")
+		if err != nil {
+			return nil, err
+		}
+
+		keywords, err := compileKeywords(sub.Keywords)
+		if err != nil {
+			return nil, fmt.Errorf("subcategory %q: %w", path, err)
+		}
+		for _, child := range children {
+			keywords = append(keywords, child.keywords...)
+		}
+		excludes, err := compileKeywords(sub.ExcludeKeywords)
+		if err != nil {
+			return nil, fmt.Errorf("subcategory %q: exclude_keywords: %w", path, err)
+		}
+
+		compiled = append(compiled, compiledSubcategory{
+			path:     path,
+			def:      sub,
+			keywords: keywords,
+			excludes: excludes,
+			children: children,
+		})
+	}
+	return compiled, nil
+}
+
+// compileKeywords compiles a keyword list; the error names the first invalid keyword
+func compileKeywords(keywords []string) ([]*keyword.Matcher, error) {
+	matchers := make([]*keyword.Matcher, 0, len(keywords))
+	for _, kw := range keywords {
+		m, err := keyword.Compile(kw)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+// matchesAny reports whether any of the matchers matches the name
+func matchesAny(matchers []*keyword.Matcher, name keyword.Name) bool {
+	for _, m := range matchers {
+		if m.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetLayout overrides the target path template, e.g. from the --layout flag
+func (c *Categorizer) SetLayout(template string) error {
+	parsed, err := layout.Parse(template)
+	if err != nil {
+		return err
+	}
+	c.layout = parsed
+	return nil
+}
+
+// SetPathEvidence enables or disables matching folder names for categories without their
+// own match_source, e.g. from the --path-evidence flag
+func (c *Categorizer) SetPathEvidence(enabled bool) {
+	c.pathEvidence = enabled
+}
+
+// source returns where a category's keywords are matched
+func (c *Categorizer) source(cat compiledCategory) config.MatchSource {
+	return cat.def.Source(c.pathEvidence)
+}
+
+// Threshold returns the confidence below which a categorization should be reviewed
+func (c *Categorizer) Threshold() float64 {
+	return c.config.Threshold()
+}
+
+// Warnings returns the problems found while validating the configuration that don't
+// make it invalid
+func (c *Categorizer) Warnings() []string {
+	return c.config.Warnings
+}
+
+// Priority returns the configured priority of a category (lower values are checked first).
+// Uncategorized and unknown categories rank after every configured category.
+func (c *Categorizer) Priority(category Category) int {
+	for _, cat := range c.config.Categories {
+		if cat.Name == string(category) {
+			return cat.Priority
+		}
 	}
+	return math.MaxInt32
 }
 
 // NewCategorizerFromFile creates a new Categorizer loading config from a file
@@ -56,447 +270,7 @@ func NewCategorizerFromFile(configPath string) (*Categorizer, error) {
 	if err != nil {
 		return nil, err
 	}
-	return NewCategorizer(cfg), nil
-}
-
-// categoryPriority defines the order in which categories are checked
-// Categories earlier in the list have higher priority
-var categoryPriority = []Category{
-	CategoryOneShot,     // Check one-shots first (shots/hits/stabs)
-	CategoryDrum,
-	CategoryBass,
-	CategoryPercussion,
-	CategoryVocal,
-	CategorySynth,
-	CategoryMelodic,
-	CategoryFX,
-	CategoryTransition,
-	CategoryAmbiance,
-	CategoryFoley,
-	CategoryLoop,
-}
-
-// keywords maps category keywords to categories
-var keywords = map[Category][]string{
-	CategoryDrum: {
-		"kick", "snare", "hihat", "hi-hat", "hi_hat", "hi hat", "hats", "clap", "tom", "cymbal", "crash", "ride",
-		"drum", "bd", "sd", "hh", "closed hat", "open hat", "hat closed", "hat open",
-		"sidestick", "side stick", "rimshot", "rim shot", "cup", "rim", "cym", "china",
-		"crossstick", "cross stick",
-	},
-	CategoryBass: {
-		"bass", "sub", "808", "909",
-	},
-	CategorySynth: {
-		"synth", "lead", "pad", "pluck", "saw", "square", "sine",
-	},
-	CategoryVocal: {
-		"vocal", "vox", "voice", "acapella", "choir", "shout", "chant", "adlib",
-	},
-	CategoryFX: {
-		"fx", "sfx", "riser", "downsweep", "whoosh", "impact", "sweep", "noise",
-		"white", "reverse", "rev", "glitch", "tone", "envelope", "pulse",
-		"ufo", "bleeps", "sync", "click",
-	},
-	CategoryPercussion: {
-		"perc", "percussion", "shaker", "conga", "bongo", "tambourine", "tamb", "cowbell",
-		"cabasa", "clave", "claves", "agogo", "timbale", "timpani", "maracas", "maraca",
-		"woodblock", "wood block", "triangle", "guiro", "djembe", "udu",
-		"brush", "chk", "cowb",
-	},
-	CategoryMelodic: {
-		"piano", "guitar", "bell", "marimba", "xylophone", "harp", "strings",
-		"violin", "cello", "flute", "horn", "trumpet", "sax", "saxophone",
-		"organ", "keys", "brass", "woodwind", "arpeggio", "arpeggiated", "melody",
-		"oud", "bouzouki", "duduk", "glissentar", "joombush", "mandolin", "mandolino",
-		"wurli", "wurlitzer", "clav", "clavinet", "accordion", "chime", "chimes",
-	},
-	CategoryTransition: {
-		"fill", "transition", "build", "buildup", "build-up", "breakdown", "break-down",
-		"downlifter", "stop",
-	},
-	CategoryAmbiance: {
-		"ambiance", "ambient", "atmosphere", "drone", "texture", "atmospheric",
-	},
-	CategoryFoley: {
-		"foley", "bird", "animal", "water", "splash", "scratch", "vinyl", "snap",
-		"whistle", "ocean", "nature", "wind",
-	},
-	CategoryLoop: {
-		"loop", "phrase", "bar", "beat",
-	},
-	CategoryOneShot: {
-		"oneshot", "one-shot", "hit", "stab", "shot",
-	},
-}
-
-// subcategoryKeywords maps specific keywords to subcategory folder names
-var subcategoryKeywords = map[Category]map[string]string{
-	CategoryDrum: {
-		// Existing subcategories
-		"kick":  "kick",
-		"bd":    "kick",
-		"snare": "snare",
-		"sd":    "snare",
-		"hihat":       "hihat",
-		"hi-hat":      "hihat",
-		"hi_hat":      "hihat",
-		"hi hat":      "hihat",
-		"hh":          "hihat",
-		"hats":        "hihat",
-		"closed hat":  "hihat",
-		"open hat":    "hihat",
-		"hat closed":  "hihat",
-		"hat open":    "hihat",
-		"clap":   "clap",
-		"tom":    "tom",
-		"toms":   "tom",
-		"cymbal": "cymbal",
-		"crash":  "cymbal",
-		"ride":   "cymbal",
-		"cup":    "cymbal",
-		"cym":    "cymbal",
-		"china":  "cymbal",
-		"sidestick":  "rimshot",
-		"side stick": "rimshot",
-		"rimshot":    "rimshot",
-		"rim shot":   "rimshot",
-		"crossstick": "rimshot",
-		"cross stick": "rimshot",
-		"rim":        "rimshot",
-		// New subcategories
-		"drum fill":       "fill",
-		"drum_fill":       "fill",
-		"drum loop":       "loop",
-		"drum_loop":       "loop",
-		"beat loop":       "loop",
-		"beat_loop":       "loop",
-		"ethnic drum":     "ethnic",
-		"ethnic_drum":     "ethnic",
-		"indian drum":     "ethnic",
-		"indian_drum":     "ethnic",
-		"tribal drum":     "ethnic",
-		"tribal_drum":     "ethnic",
-		"acoustic drum":   "acoustic",
-		"acoustic_drum":   "acoustic",
-		"cinematic drum":  "cinematic",
-		"cinematic_drum":  "cinematic",
-		"cinematic":       "cinematic",
-	},
-	CategoryBass: {
-		// Existing subcategories
-		"sub":      "sub",
-		"subbass":  "sub",
-		"sub-bass": "sub",
-		"sub_bass": "sub",
-		"808": "808",
-		"909": "909",
-		// New subcategories
-		"growl":  "growl",
-		"wobble": "growl",
-		"whomp":  "growl",
-		"freak":  "growl",
-		"bass loop":  "loop",
-		"bass_loop":  "loop",
-		"bassloop":   "loop",
-		"psy":        "psy",
-		"psy bass":   "psy",
-		"psy_bass":   "psy",
-		"psybass":    "psy",
-		"bass pluck":   "pluck",
-		"bass_pluck":   "pluck",
-		"pluck bass":   "pluck",
-		"pluck_bass":   "pluck",
-		"plucked bass": "pluck",
-		"plucked_bass": "pluck",
-	},
-	CategorySynth: {
-		// Existing subcategories
-		"lead":        "lead",
-		"leads":       "lead",
-		"synth lead":  "lead",
-		"synth_lead":  "lead",
-		"pad":         "pad",
-		"pads":        "pad",
-		"synth pad":   "pad",
-		"synth_pad":   "pad",
-		"pluck":        "pluck",
-		"plucks":       "pluck",
-		"plucked":      "pluck",
-		"synth pluck":  "pluck",
-		"synth_pluck":  "pluck",
-		"saw":      "saw",
-		"sawtooth": "saw",
-		"square": "square",
-		"sine":   "sine",
-		// New subcategories
-		"synth loop":  "loop",
-		"synth_loop":  "loop",
-		"synthloop":   "loop",
-		"reverse synth": "reverse",
-		"reverse_synth": "reverse",
-		"reversed":      "reverse",
-		"synth fill":  "fill",
-		"synth_fill":  "fill",
-		"synthfill":   "fill",
-		"arp":         "arp",
-		"arpeggio":    "arp",
-		"arpeggiated": "arp",
-		"blip":  "blip",
-		"beep":  "blip",
-		"bleep": "blip",
-	},
-	CategoryVocal: {
-		"vocal":    "vocal",
-		"vox":      "vox",
-		"voice":    "voice",
-		"acapella": "acapella",
-		"choir":    "choir",
-		"chorus":   "choir",
-		"ensemble": "choir",
-		"shout":    "shout",
-		"yell":     "shout",
-		"scream":   "shout",
-		"chant":    "chant",
-		"chanting": "chant",
-		"adlib":    "adlib",
-		"ad-lib":   "adlib",
-		"ad lib":   "adlib",
-	},
-	CategoryFX: {
-		// Existing subcategories
-		"riser":     "riser",
-		"uplift":    "riser",
-		"risefx":    "riser",
-		"downsweep": "downsweep",
-		"whoosh":    "whoosh",
-		"impact":    "impact",
-		"boom":      "impact",
-		"slam":      "impact",
-		"sweep":     "sweep",
-		"uplifter":  "sweep",
-		"noise":       "noise",
-		"white":       "noise",
-		"white noise": "noise",
-		"pink noise":  "noise",
-		"reverse": "reverse",
-		"rev":     "reverse",
-		// New subcategories
-		"game":       "game",
-		"video game": "game",
-		"psy":        "psy",
-		"psychedelic": "psy",
-		"transformer": "transformer",
-		"robot":       "transformer",
-		"laser":  "laser",
-		"lazer":  "laser",
-		"water":  "water",
-		"splash": "water",
-		"ocean":  "water",
-		"glitch": "glitch",
-		"tone":   "tone",
-		"envelope": "envelope",
-		"pulse":  "pulse",
-		"ufo":    "ufo",
-		"bleeps": "blip",
-		"sync":   "sync",
-		"click":  "click",
-	},
-	CategoryPercussion: {
-		// Existing subcategories
-		"shaker": "shaker",
-		"shake":  "shaker",
-		"conga":  "conga",
-		"congas": "conga",
-		"bongo":      "bongo",
-		"tambourine": "tambourine",
-		"tamb":       "tambourine",
-		"cowbell":    "cowbell",
-		"cow bell":   "cowbell",
-		"cowb":       "cowbell",
-		"cabasa":     "cabasa",
-		"clave":      "clave",
-		"claves":     "clave",
-		"agogo":      "agogo",
-		"timbale":    "timbale",
-		"timpani":    "timpani",
-		"maracas":    "maracas",
-		"maraca":     "maracas",
-		"woodblock":  "woodblock",
-		"wood block": "woodblock",
-		"triangle":   "triangle",
-		"guiro":      "guiro",
-		"djembe":     "djembe",
-		"udu":        "udu",
-		"brush":      "brush",
-		"chk":        "miscellaneous",
-		// New subcategories
-		"hi perc":           "high",
-		"hi_perc":           "high",
-		"high perc":         "high",
-		"high_perc":         "high",
-		"high percussion":   "high",
-		"high_percussion":   "high",
-		"percussion high":   "high",
-		"percussion_high":   "high",
-		"low perc":          "low",
-		"low_perc":          "low",
-		"low percussion":    "low",
-		"low_percussion":    "low",
-		"percussion low":    "low",
-		"percussion_low":    "low",
-		"mid perc":          "mid",
-		"mid_perc":          "mid",
-		"mid percussion":    "mid",
-		"mid_percussion":    "mid",
-		"percussion mid":    "mid",
-		"percussion_mid":    "mid",
-		"percussion loop":   "loop",
-		"percussion_loop":   "loop",
-		"perc loop":         "loop",
-		"perc_loop":         "loop",
-		"rimshot":           "rimshot",
-		"rim shot":          "rimshot",
-		"rim_shot":          "rimshot",
-		"rim":               "rimshot",
-		"clank":             "clank",
-		"metal perc":        "clank",
-		"metal_perc":        "clank",
-		"metallic":          "clank",
-		"wooden":            "wood",
-		"wood perc":         "wood",
-		"wood_perc":         "wood",
-		"wooden perc":       "wood",
-		"wooden_perc":       "wood",
-		"slap":              "slap",
-		"percussion slap":   "slap",
-		"percussion_slap":   "slap",
-		"knock":             "knock",
-		"percussion knock":  "knock",
-		"percussion_knock":  "knock",
-		"beatbox":           "beatbox",
-		"beat box":          "beatbox",
-		"beat_box":          "beatbox",
-		"ethnic perc":       "ethnic",
-		"ethnic_perc":       "ethnic",
-		"tribal perc":       "ethnic",
-		"tribal_perc":       "ethnic",
-		"african perc":      "ethnic",
-		"african_perc":      "ethnic",
-		"indian perc":       "ethnic",
-		"indian_perc":       "ethnic",
-	},
-	CategoryMelodic: {
-		// Existing subcategories
-		"piano":   "piano",
-		"guitar":  "guitar",
-		"gtr":     "guitar",
-		"acoustic guitar": "guitar",
-		"electric guitar": "guitar",
-		"bell":      "bell",
-		"chime":     "bell",
-		"chimes":    "bell",
-		"marimba":   "marimba",
-		"xylophone": "xylophone",
-		"harp":    "harp",
-		"strings": "strings",
-		"string":  "strings",
-		"violin":  "strings",
-		"cello":   "strings",
-		"viola":   "strings",
-		"flute":     "woodwind",
-		"clarinet":  "woodwind",
-		"oboe":      "woodwind",
-		"sax":       "woodwind",
-		"saxophone": "woodwind",
-		"horn":     "brass",
-		"trumpet":  "brass",
-		"trombone": "brass",
-		"organ":    "keys",
-		"keys":     "keys",
-		"keyboard": "keys",
-		"brass":    "brass",
-		"woodwind": "woodwind",
-		// New subcategories
-		"oud":        "oud",
-		"bouzouki":   "bouzouki",
-		"duduk":      "duduk",
-		"glissentar": "glissentar",
-		"joombush":   "joombush",
-		"mandolin":   "mandolin",
-		"mandolino":  "mandolin",
-		"wurli":      "keys",
-		"wurlitzer":  "keys",
-		"clav":       "keys",
-		"clavinet":   "keys",
-		"accordion":  "accordion",
-	},
-	CategoryTransition: {
-		"fill":       "fill",
-		"transition": "transition",
-		"build":      "buildup",
-		"buildup":    "buildup",
-		"build-up":   "buildup",
-		"breakdown":  "breakdown",
-		"break-down": "breakdown",
-		"downlifter": "downlifter",
-		"stop":       "stop",
-	},
-	CategoryAmbiance: {
-		"dark":        "dark",
-		"bright":      "bright",
-		"space":       "space",
-		"nature":      "nature",
-		"industrial":  "industrial",
-	},
-	CategoryFoley: {
-		"bird":      "nature",
-		"animal":    "animal",
-		"water":     "water",
-		"splash":    "water",
-		"ocean":     "water",
-		"scratch":   "vinyl",
-		"vinyl":     "vinyl",
-		"snap":      "human",
-		"whistle":   "human",
-		"wind":      "nature",
-		"mechanical": "mechanical",
-	},
-	CategoryLoop: {
-		"loop":   "loop",
-		"phrase": "phrase",
-		"bar":    "bar",
-		"beat":   "beat",
-	},
-	CategoryOneShot: {
-		// Restructured for instrument-specific subcategories
-		"bass shot":    "bass",
-		"bass_shot":    "bass",
-		"bass stab":    "bass",
-		"bass_stab":    "bass",
-		"bass hit":     "bass",
-		"bass_hit":     "bass",
-		"bassshot":     "bass",
-		"synth shot":   "synth",
-		"synth_shot":   "synth",
-		"synth stab":   "synth",
-		"synth_stab":   "synth",
-		"synthshot":    "synth",
-		"vocal shot":   "vocal",
-		"vocal_shot":   "vocal",
-		"drum hit":     "drum",
-		"drum_hit":     "drum",
-		"drum stab":    "drum",
-		"drum_stab":    "drum",
-		"melodic stab": "melodic",
-		"melodic_stab": "melodic",
-		"oneshot":      "general",
-		"one-shot":     "general",
-		"one_shot":     "general",
-		"hit":          "general",
-		"stab":         "general",
-		"shot":         "general",
-	},
+	return NewCategorizer(cfg)
 }
 
 // NormalizeFileName normalizes a filename by applying transformations:
@@ -505,14 +279,40 @@ var subcategoryKeywords = map[Category]map[string]string{
 // - replaces underscores with dashes
 // - removes consecutive dashes
 // - preserves the file extension
+// - keeps a detected musical key in its canonical case (e.g. "Am", "F#m", "8A")
 func NormalizeFileName(fileName string) string {
 	ext := filepath.Ext(fileName)
 	nameWithoutExt := strings.TrimSuffix(fileName, ext)
 
+	// Lowercasing would make "Am" indistinguishable from "A", so normalize around the key
+	attrs := ParseMusicalAttributes(nameWithoutExt)
+	if attrs.keyStart >= 0 {
+		key := attrs.Key
+		if camelotPattern.MatchString(nameWithoutExt[attrs.keyStart:attrs.keyEnd]) {
+			key = attrs.Camelot
+		}
+		var parts []string
+		for _, part := range []string{
+			normalizeNamePart(nameWithoutExt[:attrs.keyStart]),
+			key,
+			normalizeNamePart(nameWithoutExt[attrs.keyEnd:]),
+		} {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+		return strings.Join(parts, "-") + ext
+	}
+
+	return normalizeNamePart(nameWithoutExt) + ext
+}
+
+// normalizeNamePart applies the NormalizeFileName transformations to a fragment of a name
+func normalizeNamePart(nameWithoutExt string) string {
 	// Apply normalization transformations
-	normalized := strings.ToLower(nameWithoutExt)           // lowercase
-	normalized = strings.ReplaceAll(normalized, " ", "-")   // spaces to dashes
-	normalized = strings.ReplaceAll(normalized, "_", "-")   // underscores to dashes
+	normalized := strings.ToLower(nameWithoutExt)         // lowercase
+	normalized = strings.ReplaceAll(normalized, " ", "-") // spaces to dashes
+	normalized = strings.ReplaceAll(normalized, "_", "-") // underscores to dashes
 
 	// Remove consecutive dashes
 	dashRegex := regexp.MustCompile(`-+`)
@@ -521,59 +321,102 @@ func NormalizeFileName(fileName string) string {
 	// Remove leading/trailing dashes
 	normalized = strings.Trim(normalized, "-")
 
-	return normalized + ext
+	return normalized
 }
 
-// determineSubcategory checks the filename for subcategory keywords
-// Returns the subcategory based on the longest matching keyword (most specific match)
-func (c *Categorizer) determineSubcategory(categoryName string, fileName, nameWithoutExt string) string {
-	// Find the category definition
-	var subcatMap map[string][]string
-	for _, cat := range c.config.Categories {
-		if cat.Name == categoryName {
-			if cat.Subcategories == nil {
-				return ""
+// determineSubcategory checks the evidence for subcategory keywords, filename first and
+// then the folder names, closest first, as far as the category's match source allows
+func (c *Categorizer) determineSubcategory(categoryName string, all []evidence) (string, *KeywordMatch) {
+	for _, cat := range c.categories {
+		if cat.def.Name != categoryName {
+			continue
+		}
+		for _, ev := range all {
+			if !c.allows(cat, ev) {
+				continue
+			}
+			if sub, match := bestSubcategory(cat.subcategories, ev); sub != "" {
+				return sub, match
 			}
-			subcatMap = cat.Subcategories
-			break
 		}
+		break
 	}
+	return "", nil
+}
 
-	if subcatMap == nil {
-		return ""
-	}
-
-	// Find all matching keywords and prefer the longest one (most specific)
-	var bestMatch string
-	var bestKeyword string
-	for subfolder, keywords := range subcatMap {
-		for _, keyword := range keywords {
-			keywordLower := strings.ToLower(keyword)
-			if strings.Contains(fileName, keywordLower) || strings.Contains(nameWithoutExt, keywordLower) {
-				// Prefer longer keywords (more specific matches)
-				if len(keyword) > len(bestKeyword) {
-					bestKeyword = keyword
-					bestMatch = subfolder
-				}
+// bestSubcategory returns the subcategory with the longest matching keyword (most
+// specific match) and that keyword, or "" if none matches. Sibling subcategories whose
+// best keywords have the same length are ranked by precedes, so the result never depends
+// on the order of the configuration. Nested subcategories are resolved the same way below
+// the chosen one, as deep as their keywords match, and the full path is returned, e.g.
+// "cymbal/crash".
+func bestSubcategory(subcategories []compiledSubcategory, ev evidence) (string, *KeywordMatch) {
+	var best *compiledSubcategory
+	var bestKeyword *keyword.Matcher
+	for i, sub := range subcategories {
+		if matchesAny(sub.excludes, ev.name) {
+			continue
+		}
+		for _, m := range sub.keywords {
+			if !m.Match(ev.name) {
+				continue
+			}
+			// Prefer longer keywords (more specific matches), then the higher-ranked sibling
+			if bestKeyword == nil || m.Len() > bestKeyword.Len() ||
+				(m.Len() == bestKeyword.Len() && best != &subcategories[i] && sub.def.Precedes(best.def)) {
+				bestKeyword = m
+				best = &subcategories[i]
 			}
 		}
 	}
 
-	return bestMatch
+	if best == nil {
+		return "", nil
+	}
+	if path, match := bestSubcategory(best.children, ev); path != "" {
+		return path, match
+	}
+	return best.path, &KeywordMatch{Subcategory: best.path, Keyword: bestKeyword.Keyword, Source: ev.source, In: ev.text}
+}
+
+// folderNames returns the folders between the scan root and the sample, closest first
+func folderNames(sample scanner.SampleFile) []string {
+	dir := filepath.Dir(sample.RelativePath)
+	if sample.RelativePath == "" || dir == "." {
+		return nil
+	}
+
+	parts := strings.Split(filepath.ToSlash(dir), "/")
+	names := make([]string, 0, len(parts))
+	for i := len(parts) - 1; i >= 0; i-- {
+		names = append(names, parts[i])
+	}
+	return names
+}
+
+// trimExt returns the filename without its extension
+func trimExt(fileName string) string {
+	return strings.TrimSuffix(fileName, filepath.Ext(fileName))
 }
 
 // Categorize determines the category of a sample file based on its name
 func (c *Categorizer) Categorize(sample scanner.SampleFile, targetDir string, normalize bool) CategorizedFile {
-	fileName := strings.ToLower(sample.FileName)
-	nameWithoutExt := strings.ToLower(strings.TrimSuffix(sample.FileName, sample.Extension))
+	all := gatherEvidence(sample)
 
-	category := c.determineCategory(fileName, nameWithoutExt)
-	subcategory := c.determineSubcategory(category, fileName, nameWithoutExt)
+	candidates, matches := c.scoreCategories(all, sample.Audio)
+	category, source := "uncategorized", ""
+	if len(candidates) > 0 {
+		category, source = string(candidates[0].Category), candidates[0].Source
+	}
+	subcategory, subMatch := c.determineSubcategory(category, all)
+	if subMatch != nil {
+		matches = append(matches, *subMatch)
+	}
 
 	// If no subcategory found but category has subcategory support, use "uncategorized"
 	if subcategory == "" && category != "uncategorized" {
 		for _, cat := range c.config.Categories {
-			if cat.Name == category && cat.Subcategories != nil && len(cat.Subcategories) > 0 {
+			if cat.Name == category && len(cat.Subcategories) > 0 {
 				subcategory = "uncategorized"
 				break
 			}
@@ -586,41 +429,107 @@ func (c *Categorizer) Categorize(sample scanner.SampleFile, targetDir string, no
 		targetFileName = NormalizeFileName(sample.FileName)
 	}
 
-	// Build target path with subcategory
-	var targetPath string
-	if subcategory != "" {
-		targetPath = filepath.Join(targetDir, category, subcategory, targetFileName)
-	} else {
-		targetPath = filepath.Join(targetDir, category, targetFileName)
-	}
+	attrs := ParseMusicalAttributes(trimExt(sample.FileName))
+
+	// Build target path from the layout template
+	values := layoutValues(sample, category, subcategory, targetFileName, attrs)
+	targetPath := filepath.Join(targetDir, filepath.FromSlash(c.layout.Render(values)))
 
 	return CategorizedFile{
 		Sample:      sample,
 		Category:    Category(category),
 		Subcategory: subcategory,
 		TargetPath:  targetPath,
+		BPM:         attrs.BPM,
+		Key:         attrs.Key,
+		Camelot:     attrs.Camelot,
+		MatchSource: source,
+		Confidence:  confidence(candidates),
+		Candidates:  candidates,
+		Matches:     matches,
+		Tags:        c.collectTags(candidates, all, attrs),
 	}
 }
 
-// determineCategory checks the filename against category keywords in priority order
-func (c *Categorizer) determineCategory(fileName, nameWithoutExt string) string {
-	// Sort categories by priority
-	sortedCategories := make([]config.CategoryDefinition, len(c.config.Categories))
-	copy(sortedCategories, c.config.Categories)
-	sort.Slice(sortedCategories, func(i, j int) bool {
-		return sortedCategories[i].Priority < sortedCategories[j].Priority
-	})
+// layoutValues collects the placeholder values available to layout templates
+func layoutValues(sample scanner.SampleFile, category, subcategory, targetFileName string, attrs MusicalAttributes) map[string]string {
+	ext := filepath.Ext(targetFileName)
+	values := map[string]string{
+		"category":    category,
+		"subcategory": subcategory,
+		"filename":    targetFileName,
+		"name":        strings.TrimSuffix(targetFileName, ext),
+		"ext":         ext,
+		"key":         attrs.Key,
+		"camelot":     attrs.Camelot,
+		"pack":        sample.Pack(),
+	}
+	if attrs.BPM > 0 {
+		values["bpm"] = strconv.Itoa(attrs.BPM)
+	}
+	if sample.Audio != nil {
+		values["samplerate"] = strconv.Itoa(sample.Audio.SampleRate)
+		values["bitdepth"] = strconv.Itoa(sample.Audio.BitDepth)
+		values["channels"] = strconv.Itoa(sample.Audio.Channels)
+	}
+	return values
+}
 
-	// Check categories in priority order to ensure deterministic results
-	for _, cat := range sortedCategories {
-		for _, keyword := range cat.Keywords {
-			keywordLower := strings.ToLower(keyword)
-			if strings.Contains(fileName, keywordLower) || strings.Contains(nameWithoutExt, keywordLower) {
-				return cat.Name
-			}
+// barAlignmentTolerance is how far a duration may drift from an exact bar length
+// and still count as bar-aligned (covers rounding to whole sample frames)
+const barAlignmentTolerance = 2 * time.Millisecond
+
+// matchesDuration reports whether a duration satisfies the category's duration rules.
+// Categories without any duration rule never match.
+func matchesDuration(cat config.CategoryDefinition, duration time.Duration) bool {
+	if !cat.HasDurationRule() {
+		return false
+	}
+
+	seconds := duration.Seconds()
+	if cat.MinDuration > 0 && seconds < cat.MinDuration {
+		return false
+	}
+	if cat.MaxDuration > 0 && seconds > cat.MaxDuration {
+		return false
+	}
+	if cat.RequiresBarAligned() && !isBarAligned(duration) {
+		return false
+	}
+	return true
+}
+
+// describeDurationRule renders the category's duration rules, e.g. "duration >= 2s, bar-aligned"
+func describeDurationRule(cat config.CategoryDefinition) string {
+	var parts []string
+	if cat.MinDuration > 0 {
+		parts = append(parts, fmt.Sprintf("duration >= %gs", cat.MinDuration))
+	}
+	if cat.MaxDuration > 0 {
+		parts = append(parts, fmt.Sprintf("duration <= %gs", cat.MaxDuration))
+	}
+	if cat.RequiresBarAligned() {
+		parts = append(parts, "bar-aligned")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// isBarAligned reports whether a duration is a power-of-two number of 4/4 bars
+// (1 to 32 bars) at a whole-number tempo between 60 and 200 BPM
+func isBarAligned(duration time.Duration) bool {
+	seconds := duration.Seconds()
+	for bars := 1; bars <= 32; bars *= 2 {
+		beats := float64(bars * 4)
+		bpm := math.Round(beats * 60 / seconds)
+		if bpm < 60 || bpm > 200 {
+			continue
+		}
+		expected := beats * 60 / bpm
+		if math.Abs(expected-seconds) <= barAlignmentTolerance.Seconds() {
+			return true
 		}
 	}
-	return "uncategorized"
+	return false
 }
 
 // CategorizeBatch categorizes multiple sample files
@@ -638,12 +547,20 @@ func (c *Categorizer) CategorizeBatch(samples []scanner.SampleFile, targetDir st
 
 // Categorize determines the category of a sample file based on its name using the default configuration
 func Categorize(sample scanner.SampleFile, targetDir string, normalize bool) CategorizedFile {
-	c := NewCategorizer(config.GetDefaultConfig())
-	return c.Categorize(sample, targetDir, normalize)
+	return defaultCategorizer().Categorize(sample, targetDir, normalize)
 }
 
 // CategorizeBatch categorizes multiple sample files using the default configuration
 func CategorizeBatch(samples []scanner.SampleFile, targetDir string, normalize bool) []CategorizedFile {
-	c := NewCategorizer(config.GetDefaultConfig())
-	return c.CategorizeBatch(samples, targetDir, normalize)
+	return defaultCategorizer().CategorizeBatch(samples, targetDir, normalize)
+}
+
+// defaultCategorizer returns a Categorizer for the built-in default configuration, whose
+// layout is always valid
+func defaultCategorizer() *Categorizer {
+	c, err := NewCategorizer(config.GetDefaultConfig())
+	if err != nil {
+		panic(fmt.Sprintf("invalid default configuration: %v", err))
+	}
+	return c
 }
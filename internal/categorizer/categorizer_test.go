@@ -1,8 +1,13 @@
 package categorizer
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/theclifmeister/sample-shifter/internal/config"
 	"github.com/theclifmeister/sample-shifter/internal/scanner"
 )
 
@@ -118,14 +123,408 @@ func TestCategorizeCaseInsensitive(t *testing.T) {
 }
 
 func TestCategoryKeywords(t *testing.T) {
-	// Verify that each category has keywords defined
-	if len(keywords) == 0 {
-		t.Error("Keywords map should not be empty")
+	// Verify that each default category has keywords that compile
+	c := newCategorizer(t, config.GetDefaultConfig())
+	if len(c.categories) == 0 {
+		t.Fatal("Default categories should not be empty")
 	}
 
-	for category, words := range keywords {
-		if len(words) == 0 {
-			t.Errorf("Category %s has no keywords", category)
+	for _, cat := range c.categories {
+		if len(cat.keywords) == 0 || len(cat.keywords) != len(cat.def.Keywords) {
+			t.Errorf("Category %s has %d compiled keywords out of %d", cat.def.Name, len(cat.keywords), len(cat.def.Keywords))
 		}
 	}
 }
+
+func TestCategorizeByDuration(t *testing.T) {
+	tests := []struct {
+		fileName string
+		audio    *scanner.AudioInfo
+		expected Category
+	}{
+		// No keyword, short file falls into oneshots
+		{"untitled_01.wav", &scanner.AudioInfo{Duration: 800 * time.Millisecond}, CategoryOneShot},
+		// No keyword, two bars at 120 BPM falls into loops
+		{"untitled_02.wav", &scanner.AudioInfo{Duration: 4 * time.Second}, CategoryLoop},
+		// No keyword, four bars at 128 BPM (rounded to whole frames) falls into loops
+		{"untitled_03.wav", &scanner.AudioInfo{Duration: 7499977 * time.Microsecond}, CategoryLoop},
+		// No keyword, long but not bar-aligned stays uncategorized
+		{"untitled_04.wav", &scanner.AudioInfo{Duration: 3217 * time.Millisecond}, CategoryUncategorized},
+		// Keywords always win over duration rules
+		{"kick_01.wav", &scanner.AudioInfo{Duration: 300 * time.Millisecond}, CategoryDrum},
+		{"pad_evolving.wav", &scanner.AudioInfo{Duration: 4 * time.Second}, CategorySynth},
+		// Unsupported formats have no audio info and fall back gracefully
+		{"untitled_05.mp3", nil, CategoryUncategorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			sample := scanner.SampleFile{
+				OriginalPath: "/test/" + tt.fileName,
+				FileName:     tt.fileName,
+				Extension:    filepath.Ext(tt.fileName),
+				Audio:        tt.audio,
+			}
+
+			result := Categorize(sample, "/tmp/test-target", false)
+
+			if result.Category != tt.expected {
+				t.Errorf("Expected category %s for %s, got %s", tt.expected, tt.fileName, result.Category)
+			}
+		})
+	}
+}
+
+func TestCategorizeWithLayout(t *testing.T) {
+	sample := scanner.SampleFile{
+		OriginalPath: "/samples/PackA/Loops/Bass_Loop_Am_128bpm.wav",
+		FileName:     "Bass_Loop_Am_128bpm.wav",
+		Extension:    ".wav",
+		RelativePath: filepath.Join("PackA", "Loops", "Bass_Loop_Am_128bpm.wav"),
+	}
+
+	tests := []struct {
+		layout   string
+		expected string
+	}{
+		{"{category}/{subcategory}/{filename}", "bass/loop/Bass_Loop_Am_128bpm.wav"},
+		{"{category}/{subcategory}/{bpm}/{key}/{name}{ext}", "bass/loop/128/Am/Bass_Loop_Am_128bpm.wav"},
+		{"{pack}/{category}/{name}", "PackA/bass/Bass_Loop_Am_128bpm"},
+		{"{category}/{samplerate}/{filename}", "bass/Bass_Loop_Am_128bpm.wav"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			c := newCategorizer(t, config.GetDefaultConfig())
+			if err := c.SetLayout(tt.layout); err != nil {
+				t.Fatalf("SetLayout failed: %v", err)
+			}
+
+			result := c.Categorize(sample, "/target", false)
+			expected := filepath.Join("/target", filepath.FromSlash(tt.expected))
+			if result.TargetPath != expected {
+				t.Errorf("Expected target path %s, got %s", expected, result.TargetPath)
+			}
+		})
+	}
+}
+
+func TestCategorizeLayoutFromConfig(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	cfg.Layout = "{category}/{name}{ext}"
+	c := newCategorizer(t, cfg)
+
+	sample := scanner.SampleFile{OriginalPath: "/samples/Kick 01.wav", FileName: "Kick 01.wav", Extension: ".wav"}
+	result := c.Categorize(sample, "/target", true)
+
+	expected := filepath.Join("/target", "drums", "kick-01.wav")
+	if result.TargetPath != expected {
+		t.Errorf("Expected target path %s, got %s", expected, result.TargetPath)
+	}
+
+	if err := c.SetLayout("{category}/{bogus}/{filename}"); err == nil {
+		t.Error("SetLayout should reject unknown placeholders")
+	}
+}
+
+func TestCategorizerPriority(t *testing.T) {
+	c := newCategorizer(t, config.GetDefaultConfig())
+
+	if c.Priority(CategoryDrum) >= c.Priority(CategoryBass) {
+		t.Errorf("Expected drums to outrank bass, got %d and %d", c.Priority(CategoryDrum), c.Priority(CategoryBass))
+	}
+	if c.Priority(CategoryUncategorized) <= c.Priority(CategoryFoley) {
+		t.Error("Uncategorized files should rank after every configured category")
+	}
+}
+
+func TestCategorizeExclusions(t *testing.T) {
+	cfg := &config.CategoryConfig{
+		Categories: []config.CategoryDefinition{
+			{
+				Name:     "drums",
+				Priority: 1,
+				Keywords: []string{"hat", "kick"},
+				Subcategories: config.Subcategories{
+					{Name: "hihat", Keywords: []string{"hat"}, ExcludeKeywords: []string{"top hat"}},
+					{Name: "kick", Keywords: []string{"kick"}},
+				},
+			},
+			{
+				Name:            "bass",
+				Priority:        2,
+				Keywords:        []string{"substr:sub"},
+				ExcludeKeywords: []string{"subtle", "submix"},
+			},
+		},
+	}
+	c := newCategorizer(t, cfg)
+
+	tests := []struct {
+		fileName            string
+		expectedCategory    string
+		expectedSubcategory string
+	}{
+		{"Closed_Hat_01.wav", "drums", "hihat"},
+		// The subcategory exclusion only rejects the subcategory match
+		{"Top_Hat_Kick.wav", "drums", "kick"},
+		{"Top_Hat_Shuffle.wav", "drums", "uncategorized"},
+		{"Sub_Rumble.wav", "bass", ""},
+		{"Subtle_Texture.wav", "uncategorized", ""},
+		{"Deep_Submix.wav", "uncategorized", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			sample := scanner.SampleFile{OriginalPath: "/samples/" + tt.fileName, FileName: tt.fileName, Extension: ".wav"}
+			result := c.Categorize(sample, "/target", false)
+
+			if string(result.Category) != tt.expectedCategory {
+				t.Errorf("Expected category %s, got %s", tt.expectedCategory, result.Category)
+			}
+			if result.Subcategory != tt.expectedSubcategory {
+				t.Errorf("Expected subcategory %q, got %q", tt.expectedSubcategory, result.Subcategory)
+			}
+		})
+	}
+}
+
+func TestCategorizePathEvidence(t *testing.T) {
+	tests := []struct {
+		relativePath        string
+		pathEvidence        bool
+		matchSource         config.MatchSource
+		expectedCategory    Category
+		expectedSubcategory string
+		expectedSource      string
+	}{
+		// Filename only by default
+		{"Drums/Kicks/001.wav", false, "", CategoryUncategorized, "", ""},
+		{"Drums/Kicks/001.wav", true, "", CategoryDrum, "kick", SourcePath},
+		// The filename is stronger evidence than the folders
+		{"Drums/Kicks/Snare 02.wav", true, "", CategoryDrum, "snare", SourceFilename},
+		{"Bass/Pad_Warm.wav", true, "", CategorySynth, "pad", SourceFilename},
+		// The closest folder wins
+		{"Vocals/Claps/003.wav", true, "", CategoryDrum, "clap", SourcePath},
+		// Per-category match source overrides the config-wide setting
+		{"Drums/Kicks/001.wav", false, config.MatchBoth, CategoryDrum, "kick", SourcePath},
+		// Path-only categories ignore the filename, also for subcategories
+		{"Drums/Kick 01.wav", true, config.MatchPath, CategoryDrum, "uncategorized", SourcePath},
+		{"Loops/Kick 01.wav", true, config.MatchPath, CategoryLoop, "", SourcePath},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%v/%s", tt.relativePath, tt.pathEvidence, tt.matchSource), func(t *testing.T) {
+			cfg := config.GetDefaultConfig()
+			cfg.PathEvidence = config.Bool(tt.pathEvidence)
+			for i := range cfg.Categories {
+				if cfg.Categories[i].Name == string(CategoryDrum) {
+					cfg.Categories[i].MatchSource = tt.matchSource
+				}
+			}
+			c := newCategorizer(t, cfg)
+
+			sample := scanner.SampleFile{
+				OriginalPath: filepath.Join("/samples", filepath.FromSlash(tt.relativePath)),
+				FileName:     filepath.Base(tt.relativePath),
+				Extension:    ".wav",
+				RelativePath: filepath.FromSlash(tt.relativePath),
+			}
+			result := c.Categorize(sample, "/target", false)
+
+			if result.Category != tt.expectedCategory {
+				t.Errorf("Expected category %s, got %s", tt.expectedCategory, result.Category)
+			}
+			if tt.expectedSubcategory != "" && result.Subcategory != tt.expectedSubcategory {
+				t.Errorf("Expected subcategory %s, got %s", tt.expectedSubcategory, result.Subcategory)
+			}
+			if result.MatchSource != tt.expectedSource {
+				t.Errorf("Expected match source %q, got %q", tt.expectedSource, result.MatchSource)
+			}
+		})
+	}
+}
+
+func TestSetPathEvidence(t *testing.T) {
+	c := newCategorizer(t, config.GetDefaultConfig())
+	sample := scanner.SampleFile{
+		OriginalPath: "/samples/Percussion/001.wav",
+		FileName:     "001.wav",
+		Extension:    ".wav",
+		RelativePath: filepath.Join("Percussion", "001.wav"),
+	}
+
+	if result := c.Categorize(sample, "/target", false); result.Category != CategoryUncategorized {
+		t.Errorf("Expected folder names to be ignored by default, got %s", result.Category)
+	}
+	c.SetPathEvidence(true)
+	if result := c.Categorize(sample, "/target", false); result.Category != CategoryPercussion {
+		t.Errorf("Expected percussion from the folder name, got %s", result.Category)
+	}
+}
+
+func TestCategorizeNestedSubcategories(t *testing.T) {
+	cfg := &config.CategoryConfig{
+		Categories: []config.CategoryDefinition{
+			{
+				Name:     "drums",
+				Priority: 1,
+				Keywords: []string{"kick", "cymbal", "crash", "ride"},
+				Subcategories: config.Subcategories{
+					{Name: "kick", Keywords: []string{"kick"}},
+					{
+						Name:     "cymbal",
+						Keywords: []string{"cymbal"},
+						Subcategories: config.Subcategories{
+							{Name: "crash", Keywords: []string{"crash"}},
+							{Name: "ride", Keywords: []string{"ride"}},
+						},
+					},
+				},
+			},
+			{
+				Name:     "synth",
+				Priority: 2,
+				Keywords: []string{"pad"},
+				Subcategories: config.Subcategories{
+					{Name: "pad", Keywords: []string{"pad"}, Subcategories: config.Subcategories{
+						{Name: "evolving", Keywords: []string{"evolving"}},
+					}},
+				},
+			},
+		},
+	}
+	c := newCategorizer(t, cfg)
+
+	tests := []struct {
+		fileName    string
+		subcategory string
+	}{
+		{"Kick 01.wav", "kick"},
+		// Keywords of nested subcategories also select their parent
+		{"Crash 01.wav", "cymbal/crash"},
+		{"Cymbal Ride.wav", "cymbal/ride"},
+		// No nested keyword matches: the file stays in the parent
+		{"Cymbal Swell.wav", "cymbal"},
+		{"Evolving Pad.wav", "pad/evolving"},
+		{"Warm Pad.wav", "pad"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			result := categorizeName(c, tt.fileName)
+			if result.Subcategory != tt.subcategory {
+				t.Errorf("Expected subcategory %s, got %s", tt.subcategory, result.Subcategory)
+			}
+			expected := filepath.Join("/target", string(result.Category), filepath.FromSlash(tt.subcategory), tt.fileName)
+			if result.TargetPath != expected {
+				t.Errorf("Expected target path %s, got %s", expected, result.TargetPath)
+			}
+		})
+	}
+
+	result := categorizeName(c, "Crash 01.wav")
+	for _, tag := range []string{"drums/cymbal", "drums/cymbal/crash"} {
+		found := false
+		for _, got := range result.Tags {
+			found = found || got == tag
+		}
+		if !found {
+			t.Errorf("Expected tag %s in %v", tag, result.Tags)
+		}
+	}
+}
+
+func TestCategorizeSubcategoryTies(t *testing.T) {
+	tests := []struct {
+		name          string
+		subcategories config.Subcategories
+		subcategory   string
+	}{
+		{"name decides", config.Subcategories{
+			{Name: "snare", Keywords: []string{"rim"}},
+			{Name: "rim", Keywords: []string{"rim"}},
+		}, "rim"},
+		{"name decides in any order", config.Subcategories{
+			{Name: "rim", Keywords: []string{"rim"}},
+			{Name: "snare", Keywords: []string{"rim"}},
+		}, "rim"},
+		{"priority decides", config.Subcategories{
+			{Name: "rim", Keywords: []string{"rim"}},
+			{Name: "snare", Keywords: []string{"rim"}, Priority: 1},
+		}, "snare"},
+		{"longer keyword beats priority", config.Subcategories{
+			{Name: "rim", Keywords: []string{"rim shot"}},
+			{Name: "snare", Keywords: []string{"rim"}, Priority: 1},
+		}, "rim"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.CategoryConfig{
+				Categories: []config.CategoryDefinition{
+					{Name: "drums", Priority: 1, Keywords: []string{"rim"}, Subcategories: tt.subcategories},
+				},
+			}
+			result := categorizeName(newCategorizer(t, cfg), "Rim Shot 01.wav")
+			if result.Subcategory != tt.subcategory {
+				t.Errorf("Expected subcategory %s, got %s", tt.subcategory, result.Subcategory)
+			}
+		})
+	}
+}
+
+// newCategorizer creates a Categorizer for a configuration that is expected to be valid
+func newCategorizer(t *testing.T, cfg *config.CategoryConfig) *Categorizer {
+	t.Helper()
+	c, err := NewCategorizer(cfg)
+	if err != nil {
+		t.Fatalf("NewCategorizer should not error: %v", err)
+	}
+	return c
+}
+
+func TestNewCategorizerInvalidLayout(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	cfg.Layout = "{category}/{nope}/{filename}"
+	if _, err := NewCategorizer(cfg); err == nil {
+		t.Error("NewCategorizer should error on an invalid layout")
+	}
+}
+
+func TestNewCategorizerInvalidKeywords(t *testing.T) {
+	tests := []struct {
+		name     string
+		category config.CategoryDefinition
+		expected string
+	}{
+		{
+			name:     "keyword",
+			category: config.CategoryDefinition{Name: "drums", Priority: 1, Keywords: []string{"kick", "re:kick("}},
+			expected: `category "drums": keyword "re:kick(" is not a valid regular expression`,
+		},
+		{
+			name:     "exclusion",
+			category: config.CategoryDefinition{Name: "drums", Priority: 1, Keywords: []string{"kick"}, ExcludeKeywords: []string{"glob:[kick"}},
+			expected: `category "drums": exclude_keywords: keyword "glob:[kick" is not a valid glob`,
+		},
+		{
+			name: "nested subcategory",
+			category: config.CategoryDefinition{Name: "drums", Priority: 1, Keywords: []string{"crash"}, Subcategories: config.Subcategories{
+				{Name: "cymbal", Keywords: []string{"cymbal"}, Subcategories: config.Subcategories{
+					{Name: "crash", Keywords: []string{"re:crash["}},
+				}},
+			}},
+			expected: `category "drums": subcategory "cymbal/crash": keyword "re:crash[" is not a valid regular expression`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCategorizer(&config.CategoryConfig{Categories: []config.CategoryDefinition{tt.category}})
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package categorizer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/theclifmeister/sample-shifter/internal/hashing"
+)
+
+// CollisionPolicy decides what happens when two files map to the same target path
+type CollisionPolicy string
+
+const (
+	// CollisionSkip keeps the first file and skips later ones
+	CollisionSkip CollisionPolicy = "skip"
+	// CollisionOverwrite lets the last file win
+	CollisionOverwrite CollisionPolicy = "overwrite"
+	// CollisionRename appends a numeric suffix (_2, _3, ...) to later files
+	CollisionRename CollisionPolicy = "rename"
+	// CollisionRenameWithPack appends the pack name, falling back to a numeric suffix
+	CollisionRenameWithPack CollisionPolicy = "rename-with-pack-name"
+	// CollisionSkipIfIdentical skips files with identical content and renames the rest
+	CollisionSkipIfIdentical CollisionPolicy = "skip-if-identical"
+)
+
+// DefaultCollisionPolicy never loses data and never writes over an existing file
+const DefaultCollisionPolicy = CollisionRename
+
+// CollisionPolicies lists all valid collision policies
+var CollisionPolicies = []CollisionPolicy{
+	CollisionSkip, CollisionOverwrite, CollisionRename, CollisionRenameWithPack, CollisionSkipIfIdentical,
+}
+
+// ParseCollisionPolicy validates a collision policy name
+func ParseCollisionPolicy(name string) (CollisionPolicy, error) {
+	for _, policy := range CollisionPolicies {
+		if string(policy) == name {
+			return policy, nil
+		}
+	}
+
+	names := make([]string, len(CollisionPolicies))
+	for i, policy := range CollisionPolicies {
+		names[i] = string(policy)
+	}
+	return "", fmt.Errorf("unknown collision policy %q (valid: %s)", name, strings.Join(names, ", "))
+}
+
+// Collision actions recorded on a CategorizedFile
+const (
+	CollisionActionSkip      = "skip"
+	CollisionActionOverwrite = "overwrite"
+	CollisionActionRename    = "rename"
+)
+
+// existingTarget is used as ConflictsWith when the clash is with a file already in the target directory
+const existingTarget = "(existing file in target)"
+
+// CollisionInfo describes how a clash on a target path was resolved
+type CollisionInfo struct {
+	// ConflictsWith is the source that claimed the path first, or a marker for an existing target file
+	ConflictsWith string
+	// OriginalTarget is the target path before any rename
+	OriginalTarget string
+	// Action is one of CollisionActionSkip, CollisionActionOverwrite or CollisionActionRename
+	Action string
+	// Reason explains the action in human-readable form
+	Reason string
+}
+
+// Skipped reports whether collision resolution decided this file must not be written
+func (f CategorizedFile) Skipped() bool {
+	return f.Collision != nil && f.Collision.Action == CollisionActionSkip
+}
+
+// AllowsOverwrite reports whether writing this file may replace an existing target file
+func (f CategorizedFile) AllowsOverwrite() bool {
+	return f.Collision != nil && f.Collision.Action == CollisionActionOverwrite
+}
+
+// PlacedFromFunc reports whether the file at target was placed there from source by an
+// earlier run
+type PlacedFromFunc func(target, source string) bool
+
+// ResolveCollisions detects files that map to the same target path and applies the policy.
+// Paths are compared case-insensitively so results are safe on case-insensitive filesystems.
+// When checkExisting is set, files already present in the target directory count as
+// collisions too, except those placedFrom reports as placed from the same source; placedFrom
+// may be nil. Files already marked as skipped (e.g. from a saved preview) are left alone.
+func ResolveCollisions(files []CategorizedFile, policy CollisionPolicy, checkExisting bool, placedFrom PlacedFromFunc) []CategorizedFile {
+	resolved := make([]CategorizedFile, len(files))
+	copy(resolved, files)
+
+	claimed := make(map[string]int)
+	for i := range resolved {
+		file := &resolved[i]
+		if file.Skipped() {
+			continue
+		}
+
+		key := collisionKey(file.TargetPath)
+		owner, takenInBatch := claimed[key]
+		takenOnDisk := !takenInBatch && checkExisting && fileExists(file.TargetPath) &&
+			!ownTarget(placedFrom, file.TargetPath, file)
+		if !takenInBatch && !takenOnDisk {
+			claimed[key] = i
+			continue
+		}
+
+		conflictsWith := existingTarget
+		conflictSource := file.TargetPath
+		if takenInBatch {
+			conflictsWith = resolved[owner].Sample.OriginalPath
+			conflictSource = resolved[owner].Sample.OriginalPath
+		}
+		info := &CollisionInfo{ConflictsWith: conflictsWith, OriginalTarget: file.TargetPath}
+
+		effective := policy
+		if policy == CollisionSkipIfIdentical {
+			if same, err := hashing.SameContent(file.Sample.OriginalPath, conflictSource); err == nil && same {
+				info.Action = CollisionActionSkip
+				info.Reason = "identical content already at target"
+				file.Collision = info
+				continue
+			}
+			effective = CollisionRename
+		}
+
+		switch effective {
+		case CollisionSkip:
+			info.Action = CollisionActionSkip
+			info.Reason = "target path already taken"
+		case CollisionOverwrite:
+			info.Action = CollisionActionOverwrite
+			info.Reason = "overwrites existing target file"
+			if takenInBatch {
+				// The earlier file would be replaced anyway, so don't write it at all
+				info.Reason = "replaces " + resolved[owner].Sample.OriginalPath
+				resolved[owner].Collision = &CollisionInfo{
+					ConflictsWith:  file.Sample.OriginalPath,
+					OriginalTarget: resolved[owner].TargetPath,
+					Action:         CollisionActionSkip,
+					Reason:         "superseded by a later file (overwrite policy)",
+				}
+			}
+			claimed[key] = i
+		case CollisionRename, CollisionRenameWithPack:
+			target, identical := uniqueTargetPath(file, effective, claimed, checkExisting, policy == CollisionSkipIfIdentical, placedFrom)
+			if identical {
+				// A previous run already placed this exact file under a renamed path
+				info.Action = CollisionActionSkip
+				info.Reason = "identical content already at " + filepath.Base(target)
+				break
+			}
+			file.TargetPath = target
+			claimed[collisionKey(file.TargetPath)] = i
+			info.Action = CollisionActionRename
+			info.Reason = "renamed to " + filepath.Base(file.TargetPath)
+		}
+		file.Collision = info
+	}
+
+	return resolved
+}
+
+// uniqueTargetPath finds a free target path for a colliding file. A candidate placed from the
+// same source by an earlier run counts as free. With matchIdentical, an existing candidate
+// file with the same content is returned instead and identical is true.
+func uniqueTargetPath(file *CategorizedFile, policy CollisionPolicy, claimed map[string]int, checkExisting, matchIdentical bool, placedFrom PlacedFromFunc) (target string, identical bool) {
+	dir := filepath.Dir(file.TargetPath)
+	ext := filepath.Ext(file.TargetPath)
+	base := strings.TrimSuffix(filepath.Base(file.TargetPath), ext)
+
+	// check reports whether a candidate can be used and whether it already holds identical content
+	check := func(path string) (free, same bool) {
+		if _, taken := claimed[collisionKey(path)]; taken {
+			return false, false
+		}
+		if !checkExisting || !fileExists(path) || ownTarget(placedFrom, path, file) {
+			return true, false
+		}
+		if matchIdentical {
+			if same, err := hashing.SameContent(file.Sample.OriginalPath, path); err == nil && same {
+				return false, true
+			}
+		}
+		return false, false
+	}
+
+	if policy == CollisionRenameWithPack {
+		if pack := file.Sample.Pack(); pack != "" {
+			base = base + "_" + pack
+			candidate := filepath.Join(dir, base+ext)
+			if free, same := check(candidate); free || same {
+				return candidate, same
+			}
+		}
+	}
+
+	for suffix := 2; ; suffix++ {
+		candidate := filepath.Join(dir, base+"_"+strconv.Itoa(suffix)+ext)
+		if free, same := check(candidate); free || same {
+			return candidate, same
+		}
+	}
+}
+
+// ownTarget reports whether the existing file at target was placed there from file's source
+func ownTarget(placedFrom PlacedFromFunc, target string, file *CategorizedFile) bool {
+	return placedFrom != nil && placedFrom(target, file.Sample.OriginalPath)
+}
+
+// collisionKey normalizes a path for collision comparison
+func collisionKey(path string) string {
+	return strings.ToLower(filepath.Clean(path))
+}
+
+// fileExists reports whether anything exists at path (including dangling symlinks)
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
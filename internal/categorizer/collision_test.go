@@ -0,0 +1,164 @@
+package categorizer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/theclifmeister/sample-shifter/internal/scanner"
+)
+
+// writeSamples creates source files under root and returns them as scanned samples
+func writeSamples(t *testing.T, root string, contents map[string]string, order []string) []scanner.SampleFile {
+	t.Helper()
+	var samples []scanner.SampleFile
+	for _, rel := range order {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(contents[rel]), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		samples = append(samples, scanner.SampleFile{
+			OriginalPath: path,
+			FileName:     filepath.Base(path),
+			Extension:    filepath.Ext(path),
+			RelativePath: filepath.FromSlash(rel),
+		})
+	}
+	return samples
+}
+
+func TestResolveCollisionsPolicies(t *testing.T) {
+	root := t.TempDir()
+	contents := map[string]string{
+		"packA/Kick.wav":  "kick A",
+		"packB/Kick.wav":  "kick B",
+		"packC/Kick.wav":  "kick A",
+		"packA/Snare.wav": "snare",
+	}
+	order := []string{"packA/Kick.wav", "packB/Kick.wav", "packC/Kick.wav", "packA/Snare.wav"}
+	samples := writeSamples(t, root, contents, order)
+
+	targetDir := filepath.Join(root, "target")
+	kickPath := filepath.Join(targetDir, "drums", "kick", "Kick.wav")
+
+	tests := []struct {
+		policy  CollisionPolicy
+		targets []string
+		skipped []bool
+	}{
+		{
+			CollisionSkip,
+			[]string{kickPath, kickPath, kickPath},
+			[]bool{false, true, true},
+		},
+		{
+			CollisionOverwrite,
+			[]string{kickPath, kickPath, kickPath},
+			[]bool{true, true, false},
+		},
+		{
+			CollisionRename,
+			[]string{kickPath, filepath.Join(targetDir, "drums", "kick", "Kick_2.wav"), filepath.Join(targetDir, "drums", "kick", "Kick_3.wav")},
+			[]bool{false, false, false},
+		},
+		{
+			CollisionRenameWithPack,
+			[]string{kickPath, filepath.Join(targetDir, "drums", "kick", "Kick_packB.wav"), filepath.Join(targetDir, "drums", "kick", "Kick_packC.wav")},
+			[]bool{false, false, false},
+		},
+		{
+			CollisionSkipIfIdentical,
+			[]string{kickPath, filepath.Join(targetDir, "drums", "kick", "Kick_2.wav"), kickPath},
+			[]bool{false, false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			categorized := CategorizeBatch(samples, targetDir, false)
+			resolved := ResolveCollisions(categorized, tt.policy, true, nil)
+
+			for i := range tt.targets {
+				if resolved[i].TargetPath != tt.targets[i] {
+					t.Errorf("File %d: expected target %s, got %s", i, tt.targets[i], resolved[i].TargetPath)
+				}
+				if resolved[i].Skipped() != tt.skipped[i] {
+					t.Errorf("File %d: expected skipped=%v, got %v", i, tt.skipped[i], resolved[i].Skipped())
+				}
+			}
+
+			// The snare never collides
+			if resolved[3].Collision != nil {
+				t.Errorf("Snare should not have a collision, got %+v", resolved[3].Collision)
+			}
+
+			// Input must not be modified
+			if categorized[1].TargetPath != kickPath || categorized[1].Collision != nil {
+				t.Error("ResolveCollisions should not modify its input")
+			}
+		})
+	}
+}
+
+func TestResolveCollisionsExistingTarget(t *testing.T) {
+	root := t.TempDir()
+	samples := writeSamples(t, root, map[string]string{"src/Kick.wav": "kick"}, []string{"src/Kick.wav"})
+
+	targetDir := filepath.Join(root, "target")
+	existing := filepath.Join(targetDir, "drums", "kick", "Kick.wav")
+	if err := os.MkdirAll(filepath.Dir(existing), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(existing, []byte("kick"), 0644); err != nil {
+		t.Fatalf("Failed to write existing file: %v", err)
+	}
+
+	categorized := CategorizeBatch(samples, targetDir, false)
+
+	// Without checking the disk there is nothing to resolve
+	if resolved := ResolveCollisions(categorized, CollisionSkip, false, nil); resolved[0].Collision != nil {
+		t.Error("Expected no collision when not checking existing files")
+	}
+
+	resolved := ResolveCollisions(categorized, CollisionSkipIfIdentical, true, nil)
+	if !resolved[0].Skipped() {
+		t.Errorf("Expected identical existing target to be skipped, got %+v", resolved[0].Collision)
+	}
+
+	resolved = ResolveCollisions(categorized, CollisionOverwrite, true, nil)
+	if !resolved[0].AllowsOverwrite() {
+		t.Errorf("Expected overwrite of existing target, got %+v", resolved[0].Collision)
+	}
+
+	resolved = ResolveCollisions(categorized, CollisionRename, true, nil)
+	if filepath.Base(resolved[0].TargetPath) != "Kick_2.wav" {
+		t.Errorf("Expected rename to Kick_2.wav, got %s", resolved[0].TargetPath)
+	}
+}
+
+func TestResolveCollisionsCaseInsensitive(t *testing.T) {
+	files := []CategorizedFile{
+		{Sample: scanner.SampleFile{OriginalPath: "/a/Kick.wav"}, TargetPath: "/t/drums/Kick.wav"},
+		{Sample: scanner.SampleFile{OriginalPath: "/b/kick.wav"}, TargetPath: "/t/drums/kick.wav"},
+	}
+
+	resolved := ResolveCollisions(files, CollisionRename, false, nil)
+	if resolved[1].TargetPath != "/t/drums/kick_2.wav" {
+		t.Errorf("Expected case-insensitive collision to be renamed, got %s", resolved[1].TargetPath)
+	}
+}
+
+func TestParseCollisionPolicy(t *testing.T) {
+	for _, policy := range CollisionPolicies {
+		if parsed, err := ParseCollisionPolicy(string(policy)); err != nil || parsed != policy {
+			t.Errorf("ParseCollisionPolicy(%q) = %q, %v", policy, parsed, err)
+		}
+	}
+
+	if _, err := ParseCollisionPolicy("clobber"); err == nil {
+		t.Error("ParseCollisionPolicy should reject unknown policies")
+	}
+}
@@ -0,0 +1,74 @@
+package categorizer
+
+import (
+	"testing"
+
+	"github.com/theclifmeister/sample-shifter/internal/scanner"
+)
+
+// TestRealWorldNameCorpus guards keyword matching against a corpus of sample names taken
+// from commercial packs, including names that substring matching used to get wrong.
+func TestRealWorldNameCorpus(t *testing.T) {
+	corpus := []struct {
+		fileName    string
+		category    Category
+		subcategory string
+	}{
+		// Short keywords inside longer words must not match
+		{"Subtle Pad C.wav", CategorySynth, "pad"},
+		{"Custom Lead 01.wav", CategorySynth, "lead"},
+		{"Atmosphere Drone.wav", CategoryAmbiance, "uncategorized"},
+		{"Bottom End Bass.wav", CategoryBass, "uncategorized"},
+		{"Prime Lead.wav", CategorySynth, "lead"},
+		{"Tomato.wav", CategoryUncategorized, ""},
+		{"Pride.wav", CategoryUncategorized, ""},
+		{"Percolator.wav", CategoryUncategorized, ""},
+		{"Snapshot.wav", CategoryUncategorized, ""},
+		{"Stopwatch.wav", CategoryUncategorized, ""},
+		{"Barrel.wav", CategoryUncategorized, ""},
+		{"Kickstart FX.wav", CategoryFX, "uncategorized"},
+		// Only words ending in s, x, z, ch or sh take an "es" plural
+		{"Hates.wav", CategoryUncategorized, ""},
+		{"Tomes.wav", CategoryUncategorized, ""},
+		{"Rimes.wav", CategoryUncategorized, ""},
+		{"Crashes Long.wav", CategoryDrum, "cymbal"},
+		// Abbreviations and separators
+		{"BD_Heavy_01.wav", CategoryDrum, "kick"},
+		{"SD Tight.wav", CategoryDrum, "snare"},
+		{"HH_Closed_03.wav", CategoryDrum, "hihat"},
+		{"Rim_01.wav", CategoryDrum, "rimshot"},
+		{"Rimshot Dry.wav", CategoryDrum, "rimshot"},
+		{"Cup Bell.wav", CategoryDrum, "cymbal"},
+		{"Sub Drop.wav", CategoryBass, "sub"},
+		// camelCase and digit boundaries
+		{"OpenHat.wav", CategoryDrum, "hihat"},
+		{"HiHat_Open.wav", CategoryDrum, "hihat"},
+		{"Hi-Hat 2.wav", CategoryDrum, "hihat"},
+		{"KickDrum.wav", CategoryDrum, "kick"},
+		{"DeepHouseKick.wav", CategoryDrum, "kick"},
+		{"TR909Clap.wav", CategoryDrum, "clap"},
+		{"SubBass_C.wav", CategoryBass, "sub"},
+		{"Shaker16th.wav", CategoryPercussion, "shaker"},
+		{"Kick_808_Long.wav", CategoryDrum, "kick"},
+		// Plurals and multi-word keywords
+		{"Kicks Layered.wav", CategoryDrum, "kick"},
+		{"Toms Fill.wav", CategoryDrum, "tom"},
+		{"Drum Loop 120.wav", CategoryDrum, "loop"},
+		{"Perc Loop.wav", CategoryPercussion, "loop"},
+		{"Bassline 128 Am.wav", CategoryBass, "uncategorized"},
+		{"Vox_Chop_Am.wav", CategoryVocal, "vox"},
+		{"Guitar Strum.wav", CategoryMelodic, "guitar"},
+		{"Hybrid Riser.wav", CategoryFX, "riser"},
+		{"Ride Bell.wav", CategoryDrum, "cymbal"},
+	}
+
+	for _, tt := range corpus {
+		t.Run(tt.fileName, func(t *testing.T) {
+			sample := scanner.SampleFile{OriginalPath: "/samples/" + tt.fileName, FileName: tt.fileName, Extension: ".wav"}
+			result := Categorize(sample, "/target", false)
+			if result.Category != tt.category || result.Subcategory != tt.subcategory {
+				t.Errorf("Expected %s/%s, got %s/%s", tt.category, tt.subcategory, result.Category, result.Subcategory)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package categorizer
+
+import (
+	"github.com/theclifmeister/sample-shifter/internal/config"
+	"github.com/theclifmeister/sample-shifter/internal/scanner"
+)
+
+// Explanation walks through every category and subcategory evaluation for one file
+type Explanation struct {
+	// Result is the categorization of the file, as Categorize returns it
+	Result CategorizedFile
+	// Categories are the evaluations of every category, in priority order
+	Categories []CategoryExplanation
+}
+
+// CategoryExplanation is the evaluation of one category
+type CategoryExplanation struct {
+	Category Category
+	Priority int
+	Source   config.MatchSource
+	// Score is zero when no keyword matched
+	Score float64
+	// Matches are the keywords that counted towards the score
+	Matches []KeywordMatch
+	// Excluded are the exclusions that rejected the filename or a folder name
+	Excluded      []KeywordMatch
+	Subcategories []SubcategoryExplanation
+}
+
+// SubcategoryExplanation is the evaluation of one subcategory
+type SubcategoryExplanation struct {
+	// Name is the path of the subcategory, e.g. "cymbal/crash"
+	Name     string
+	Matches  []KeywordMatch
+	Excluded []KeywordMatch
+}
+
+// Explain categorizes a file and records how every category and subcategory of the
+// configuration evaluated it
+func (c *Categorizer) Explain(sample scanner.SampleFile) Explanation {
+	explanation := Explanation{Result: c.Categorize(sample, "", false)}
+
+	all := gatherEvidence(sample)
+	for _, cat := range c.categories {
+		eval := c.evaluateCategory(cat, all)
+		catExplanation := CategoryExplanation{
+			Category: Category(cat.def.Name),
+			Priority: cat.def.Priority,
+			Source:   c.source(cat),
+			Score:    eval.score,
+			Matches:  eval.matches,
+			Excluded: eval.excluded,
+		}
+
+		for _, top := range cat.subcategories {
+			top.walk(func(sub compiledSubcategory) {
+				catExplanation.Subcategories = append(catExplanation.Subcategories, c.explainSubcategory(cat, sub, all))
+			})
+		}
+
+		explanation.Categories = append(explanation.Categories, catExplanation)
+	}
+
+	return explanation
+}
+
+// explainSubcategory records which keywords of a subcategory, including those of its
+// nested subcategories, match the evidence and which exclusions reject it
+func (c *Categorizer) explainSubcategory(cat compiledCategory, sub compiledSubcategory, all []evidence) SubcategoryExplanation {
+	explanation := SubcategoryExplanation{Name: sub.path}
+	for _, ev := range all {
+		if !c.allows(cat, ev) {
+			continue
+		}
+		if ex := firstMatch(sub.excludes, ev); ex != nil {
+			ex.Subcategory = sub.path
+			explanation.Excluded = append(explanation.Excluded, *ex)
+			continue
+		}
+		for _, m := range sub.keywords {
+			if m.Match(ev.name) {
+				explanation.Matches = append(explanation.Matches, KeywordMatch{
+					Subcategory: sub.path,
+					Keyword:     m.Keyword,
+					Source:      ev.source,
+					In:          ev.text,
+				})
+			}
+		}
+	}
+	return explanation
+}
@@ -0,0 +1,84 @@
+package categorizer
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/theclifmeister/sample-shifter/internal/config"
+	"github.com/theclifmeister/sample-shifter/internal/scanner"
+)
+
+func TestCategorizeRecordsMatches(t *testing.T) {
+	c := newCategorizer(t, config.GetDefaultConfig())
+
+	result := categorizeName(c, "Closed_Hat_01.wav")
+	if len(result.Matches) != 2 {
+		t.Fatalf("Expected a category and a subcategory match, got %+v", result.Matches)
+	}
+	if match := result.Matches[0]; match.Keyword != "closed hat" || match.Source != SourceFilename || match.Subcategory != "" {
+		t.Errorf("Expected the category to be decided by \"closed hat\" in the filename, got %+v", match)
+	}
+	if match := result.Matches[1]; match.Subcategory != "hihat" || match.Keyword != "closed hat" {
+		t.Errorf("Expected the hihat subcategory to be decided by \"closed hat\", got %+v", match)
+	}
+
+	sample := scanner.SampleFile{
+		OriginalPath: "/samples/untitled.wav",
+		FileName:     "untitled.wav",
+		Extension:    ".wav",
+		Audio:        &scanner.AudioInfo{Duration: 500 * time.Millisecond},
+	}
+	result = c.Categorize(sample, "/target", false)
+	if len(result.Matches) != 1 || result.Matches[0].Source != SourceDuration || result.Matches[0].Keyword != "duration <= 1.5s" {
+		t.Errorf("Expected the duration rule to be recorded, got %+v", result.Matches)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	cfg := &config.CategoryConfig{
+		PathEvidence: config.Bool(true),
+		Categories: []config.CategoryDefinition{
+			{
+				Name:     "drums",
+				Priority: 1,
+				Keywords: []string{"kick", "hat"},
+				Subcategories: config.Subcategories{
+					{Name: "kick", Keywords: []string{"kick"}},
+					{Name: "hihat", Keywords: []string{"hat"}, ExcludeKeywords: []string{"top hat"}},
+				},
+			},
+			{Name: "vocals", Priority: 2, Keywords: []string{"vocal"}, ExcludeKeywords: []string{"vocoder"}},
+			{Name: "synth", Priority: 3, Keywords: []string{"pad"}},
+		},
+	}
+	c := newCategorizer(t, cfg)
+
+	relativePath := filepath.Join("Vocals", "Top Hat Kick.wav")
+	sample := scanner.SampleFile{OriginalPath: relativePath, FileName: "Top Hat Kick.wav", Extension: ".wav", RelativePath: relativePath}
+	explanation := c.Explain(sample)
+
+	if explanation.Result.Category != "drums" || explanation.Result.Subcategory != "kick" {
+		t.Errorf("Expected drums/kick, got %s/%s", explanation.Result.Category, explanation.Result.Subcategory)
+	}
+	if len(explanation.Categories) != 3 {
+		t.Fatalf("Expected every category to be explained, got %d", len(explanation.Categories))
+	}
+
+	drums := explanation.Categories[0]
+	if drums.Score == 0 || len(drums.Matches) != 2 {
+		t.Errorf("Expected drums to score on \"hat\" and \"kick\", got %+v", drums.Matches)
+	}
+	if hihat := drums.Subcategories[1]; len(hihat.Excluded) != 1 || hihat.Excluded[0].Keyword != "top hat" {
+		t.Errorf("Expected the hihat subcategory to be excluded by \"top hat\", got %+v", hihat)
+	}
+
+	vocals := explanation.Categories[1]
+	if len(vocals.Matches) != 1 || vocals.Matches[0].Source != SourcePath || vocals.Matches[0].In != "Vocals" {
+		t.Errorf("Expected vocals to match the folder name, got %+v", vocals.Matches)
+	}
+
+	if synth := explanation.Categories[2]; synth.Score != 0 || len(synth.Matches) != 0 {
+		t.Errorf("Expected synth not to match, got %+v", synth)
+	}
+}
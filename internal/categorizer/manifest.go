@@ -0,0 +1,108 @@
+package categorizer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/theclifmeister/sample-shifter/internal/transfer"
+)
+
+// Manifest is a saved preview: the categorized files together with the settings apply
+// needs to place them exactly as the preview showed. The files are saved as a plain JSON
+// list, which other tools read, and the settings in a file next to it (see SettingsPath).
+type Manifest struct {
+	ManifestSettings
+	// Files are the categorized files, with their resolved target paths
+	Files []CategorizedFile
+}
+
+// ManifestSettings are the settings a preview was made with
+type ManifestSettings struct {
+	// OnCollision is the policy the preview resolved clashing target paths with
+	OnCollision CollisionPolicy `json:"on_collision"`
+	// Mode is how the preview said files would be placed (copy, move, ...)
+	Mode transfer.Mode `json:"mode,omitempty"`
+	// SourceDir is the absolute path of the scanned directory, where apply looks for the
+	// library configuration
+	SourceDir string `json:"source_dir,omitempty"`
+}
+
+// SettingsPath returns the path of the settings saved with the preview at path, e.g.
+// "review.settings.json" for "review.json"
+func SettingsPath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + ".settings" + ext
+}
+
+// SaveManifest writes the files of a preview to path as a JSON list and its settings to
+// SettingsPath(path)
+func SaveManifest(path string, manifest Manifest) error {
+	files, err := json.MarshalIndent(manifest.Files, "", "  ")
+	if err != nil {
+		return err
+	}
+	settings, err := json.MarshalIndent(manifest.ManifestSettings, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, files, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(SettingsPath(path), settings, 0644)
+}
+
+// LoadManifest reads a preview saved by SaveManifest. Previews saved without settings
+// load with empty settings.
+func LoadManifest(path string) (*Manifest, error) {
+	files, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := os.ReadFile(SettingsPath(path))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return ReadManifest(files, settings)
+}
+
+// ReadManifest decodes a saved preview from its list of files and its settings, which
+// are nil for previews saved without them. A preview holding its settings and files in
+// one object is read as well.
+func ReadManifest(files, settings []byte) (*Manifest, error) {
+	var manifest Manifest
+	if trimmed := bytes.TrimSpace(files); len(trimmed) > 0 && trimmed[0] == '{' {
+		var combined struct {
+			ManifestSettings
+			Files []CategorizedFile `json:"files"`
+		}
+		if err := json.Unmarshal(files, &combined); err != nil {
+			return nil, err
+		}
+		manifest = Manifest{ManifestSettings: combined.ManifestSettings, Files: combined.Files}
+	} else {
+		if err := json.Unmarshal(files, &manifest.Files); err != nil {
+			return nil, err
+		}
+		if settings != nil {
+			if err := json.Unmarshal(settings, &manifest.ManifestSettings); err != nil {
+				return nil, fmt.Errorf("settings: %w", err)
+			}
+		}
+	}
+
+	if manifest.OnCollision != "" {
+		if _, err := ParseCollisionPolicy(string(manifest.OnCollision)); err != nil {
+			return nil, fmt.Errorf("on_collision: %w", err)
+		}
+	}
+	if manifest.Mode != "" {
+		if _, err := transfer.ParseMode(string(manifest.Mode)); err != nil {
+			return nil, fmt.Errorf("mode: %w", err)
+		}
+	}
+	return &manifest, nil
+}
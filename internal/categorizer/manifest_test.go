@@ -0,0 +1,104 @@
+package categorizer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/theclifmeister/sample-shifter/internal/scanner"
+	"github.com/theclifmeister/sample-shifter/internal/transfer"
+)
+
+func TestReadManifest(t *testing.T) {
+	file := CategorizedFile{
+		Sample:     scanner.SampleFile{OriginalPath: "/src/kick.wav", FileName: "kick.wav"},
+		Category:   "drums",
+		TargetPath: "/dst/drums/kick.wav",
+	}
+
+	files, err := json.Marshal([]CategorizedFile{file})
+	if err != nil {
+		t.Fatalf("Failed to marshal files: %v", err)
+	}
+	settings := []byte(`{"on_collision": "skip", "mode": "move", "source_dir": "/src"}`)
+	combined := []byte(`{"on_collision": "skip", "mode": "move", "source_dir": "/src", "files": ` + string(files) + `}`)
+
+	tests := []struct {
+		name        string
+		files       []byte
+		settings    []byte
+		onCollision CollisionPolicy
+		mode        transfer.Mode
+		sourceDir   string
+		expectError bool
+	}{
+		{"files and settings", files, settings, CollisionSkip, transfer.ModeMove, "/src", false},
+		{"files without settings", files, nil, "", "", "", false},
+		{"settings and files in one object", combined, nil, CollisionSkip, transfer.ModeMove, "/src", false},
+		{"unknown policy", files, []byte(`{"on_collision": "explode"}`), "", "", "", true},
+		{"unknown mode", files, []byte(`{"mode": "teleport"}`), "", "", "", true},
+		{"invalid settings", files, []byte(`{"mode": `), "", "", "", true},
+		{"invalid JSON", []byte(`[{"Category": `), nil, "", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, err := ReadManifest(tt.files, tt.settings)
+			if tt.expectError {
+				if err == nil {
+					t.Error("ReadManifest should error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadManifest should not error: %v", err)
+			}
+			if manifest.OnCollision != tt.onCollision {
+				t.Errorf("Expected policy %q, got %q", tt.onCollision, manifest.OnCollision)
+			}
+			if manifest.Mode != tt.mode {
+				t.Errorf("Expected mode %q, got %q", tt.mode, manifest.Mode)
+			}
+			if manifest.SourceDir != tt.sourceDir {
+				t.Errorf("Expected source directory %q, got %q", tt.sourceDir, manifest.SourceDir)
+			}
+			if len(manifest.Files) != 1 || manifest.Files[0].TargetPath != file.TargetPath {
+				t.Errorf("Unexpected files: %+v", manifest.Files)
+			}
+		})
+	}
+}
+
+func TestSaveManifestKeepsFileList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "review.json")
+	file := CategorizedFile{Sample: scanner.SampleFile{OriginalPath: "/src/kick.wav"}, Category: "drums", TargetPath: "/dst/drums/kick.wav"}
+	manifest := Manifest{
+		ManifestSettings: ManifestSettings{OnCollision: CollisionSkip, Mode: transfer.ModeMove, SourceDir: "/src"},
+		Files:            []CategorizedFile{file},
+	}
+	if err := SaveManifest(path, manifest); err != nil {
+		t.Fatalf("SaveManifest failed: %v", err)
+	}
+
+	// Other tools read the preview as a plain list of files
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []CategorizedFile
+	if err := json.Unmarshal(data, &files); err != nil || len(files) != 1 {
+		t.Fatalf("Expected the preview to be a list of one file, got %s (%v)", data, err)
+	}
+	if SettingsPath(path) != filepath.Join(filepath.Dir(path), "review.settings.json") {
+		t.Errorf("Unexpected settings path %s", SettingsPath(path))
+	}
+
+	loaded, err := LoadManifest(path)
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	if loaded.ManifestSettings != manifest.ManifestSettings || len(loaded.Files) != 1 {
+		t.Errorf("Expected %+v, got %+v", manifest, loaded)
+	}
+}
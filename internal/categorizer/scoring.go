@@ -0,0 +1,210 @@
+package categorizer
+
+import (
+	"sort"
+
+	"github.com/theclifmeister/sample-shifter/internal/keyword"
+	"github.com/theclifmeister/sample-shifter/internal/scanner"
+)
+
+// Candidate is a category that matched a file, with the score of its evidence
+type Candidate struct {
+	Category Category
+	Score    float64
+	// Source is where the strongest evidence was found: SourceFilename, SourcePath or
+	// SourceDuration
+	Source string
+}
+
+// KeywordMatch records a rule that fired for a file: a keyword or exclusion found in the
+// filename or a folder name, or a duration rule
+type KeywordMatch struct {
+	// Subcategory is set for subcategory keywords
+	Subcategory string `json:",omitempty"`
+	// Keyword is the keyword as configured, or a description of the duration rule
+	Keyword string
+	// Source is SourceFilename, SourcePath or SourceDuration
+	Source string
+	// In is the filename or folder name the keyword was found in
+	In    string  `json:",omitempty"`
+	Score float64 `json:",omitempty"`
+}
+
+// Scoring weights. A keyword match scores its category's weight times
+// (1 + lengthBonus per character) times a position factor that falls from 1 at the start
+// of the name to 1-positionPenalty at its end, because packs tend to put the instrument
+// first ("Bass Loop", "Ride Bell"). Folder names count for pathFactor of the
+// filename, decaying by folderDecay per level above the file. A category's score is its
+// best match plus extraMatchFactor times each further match.
+const (
+	lengthBonus      = 0.1
+	positionPenalty  = 0.15
+	pathFactor       = 0.5
+	folderDecay      = 0.8
+	extraMatchFactor = 0.25
+)
+
+// Confidence of a category decided by each source when no other category competes
+var sourceConfidence = map[string]float64{
+	SourceFilename: 1,
+	SourcePath:     0.75,
+	SourceDuration: 0.5,
+}
+
+// evidence is a name keywords are matched against: the filename or one folder name
+type evidence struct {
+	name   keyword.Name
+	text   string
+	source string
+	// factor scales the score of matches, 1 for the filename
+	factor float64
+}
+
+// gatherEvidence returns the filename followed by the folder names between the scan root
+// and the sample, closest folder first
+func gatherEvidence(sample scanner.SampleFile) []evidence {
+	base := trimExt(sample.FileName)
+	all := []evidence{{name: keyword.NewName(base), text: base, source: SourceFilename, factor: 1}}
+
+	factor := pathFactor
+	for _, folder := range folderNames(sample) {
+		all = append(all, evidence{name: keyword.NewName(folder), text: folder, source: SourcePath, factor: factor})
+		factor *= folderDecay
+	}
+	return all
+}
+
+// categoryEvaluation is the outcome of matching one category against a file
+type categoryEvaluation struct {
+	score    float64
+	source   string
+	matches  []KeywordMatch
+	excluded []KeywordMatch
+}
+
+// evaluateCategory scores the category's keywords against every piece of evidence its
+// match source allows. Keywords matching at the same position of a name ("hihat",
+// "hi-hat", "hi hat") count once, with the best score. A name matching one of the
+// category's exclusions contributes nothing.
+func (c *Categorizer) evaluateCategory(cat compiledCategory, all []evidence) categoryEvaluation {
+	var eval categoryEvaluation
+	var best, rest float64
+	weight := cat.def.KeywordWeight()
+
+	for _, ev := range all {
+		if !c.allows(cat, ev) {
+			continue
+		}
+		if ex := firstMatch(cat.excludes, ev); ex != nil {
+			eval.excluded = append(eval.excluded, *ex)
+			continue
+		}
+
+		var positions []float64
+		byPosition := make(map[float64]KeywordMatch)
+		for _, m := range cat.keywords {
+			pos, ok := m.Find(ev.name)
+			if !ok {
+				continue
+			}
+			score := weight * ev.factor * (1 + lengthBonus*float64(m.Len())) * (1 - positionPenalty*pos)
+			prev, seen := byPosition[pos]
+			if !seen {
+				positions = append(positions, pos)
+			}
+			if !seen || score > prev.Score {
+				byPosition[pos] = KeywordMatch{Keyword: m.Keyword, Source: ev.source, In: ev.text, Score: score}
+			}
+		}
+
+		for _, pos := range positions {
+			match := byPosition[pos]
+			eval.matches = append(eval.matches, match)
+			if match.Score > best {
+				rest += best
+				best, eval.source = match.Score, match.Source
+			} else {
+				rest += match.Score
+			}
+		}
+	}
+
+	eval.score = best + extraMatchFactor*rest
+	return eval
+}
+
+// allows reports whether the category's match source covers the evidence
+func (c *Categorizer) allows(cat compiledCategory, ev evidence) bool {
+	if ev.source == SourcePath {
+		return c.source(cat).UsesPath()
+	}
+	return c.source(cat).UsesFilename()
+}
+
+// firstMatch returns the first matcher matching the evidence as a KeywordMatch, or nil
+func firstMatch(matchers []*keyword.Matcher, ev evidence) *KeywordMatch {
+	for _, m := range matchers {
+		if m.Match(ev.name) {
+			return &KeywordMatch{Keyword: m.Keyword, Source: ev.source, In: ev.text}
+		}
+	}
+	return nil
+}
+
+// scoreCategories scores every category against the filename and, for categories using
+// path evidence, the folder names. Candidates are ordered by score, ties by category
+// priority. When no keyword matches, the first category whose duration rules match is the
+// only candidate. It also returns the rules that decided the best candidate.
+func (c *Categorizer) scoreCategories(all []evidence, audio *scanner.AudioInfo) ([]Candidate, []KeywordMatch) {
+	var candidates []Candidate
+	matches := make(map[Category][]KeywordMatch)
+	for _, cat := range c.categories {
+		eval := c.evaluateCategory(cat, all)
+		if eval.score > 0 {
+			category := Category(cat.def.Name)
+			candidates = append(candidates, Candidate{Category: category, Score: eval.score, Source: eval.source})
+			matches[category] = eval.matches
+		}
+	}
+
+	// c.categories is sorted by priority, so a stable sort keeps priority order for ties
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	if len(candidates) > 0 {
+		return candidates, matches[candidates[0].Category]
+	}
+
+	// Fall back to duration rules; unsupported or unreadable formats have no audio info
+	if audio != nil && audio.Duration > 0 {
+		for _, cat := range c.categories {
+			if matchesDuration(cat.def, audio.Duration) && firstMatch(cat.excludes, all[0]) == nil {
+				match := KeywordMatch{Keyword: describeDurationRule(cat.def), Source: SourceDuration}
+				return []Candidate{{Category: Category(cat.def.Name), Source: SourceDuration}}, []KeywordMatch{match}
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// confidence rates how clearly the best candidate won, from 0 to 1: its share of the
+// top two scores, scaled by how reliable its source is
+func confidence(candidates []Candidate) float64 {
+	if len(candidates) == 0 {
+		return 0
+	}
+
+	best := candidates[0]
+	share := 1.0
+	if len(candidates) > 1 && best.Score > 0 {
+		share = best.Score / (best.Score + candidates[1].Score)
+	}
+	return share * sourceConfidence[best.Source]
+}
+
+// LowConfidence reports whether the file was categorized with a confidence below the
+// threshold and should be reviewed. Uncategorized files are never low-confidence.
+func (f CategorizedFile) LowConfidence(threshold float64) bool {
+	return f.Category != CategoryUncategorized && f.Confidence < threshold
+}
@@ -0,0 +1,103 @@
+package categorizer
+
+import (
+	"testing"
+
+	"github.com/theclifmeister/sample-shifter/internal/config"
+	"github.com/theclifmeister/sample-shifter/internal/scanner"
+)
+
+func categorizeName(c *Categorizer, fileName string) CategorizedFile {
+	sample := scanner.SampleFile{OriginalPath: "/samples/" + fileName, FileName: fileName, Extension: ".wav"}
+	return c.Categorize(sample, "/target", false)
+}
+
+func TestScoringCandidates(t *testing.T) {
+	c := newCategorizer(t, config.GetDefaultConfig())
+
+	tests := []struct {
+		fileName   string
+		candidates []Category
+	}{
+		{"Kick_01.wav", []Category{CategoryDrum}},
+		// Earlier and longer keywords score higher
+		{"Ride Bell.wav", []Category{CategoryDrum, CategoryMelodic}},
+		{"Vocal Chop FX Riser.wav", []Category{CategoryFX, CategoryVocal}},
+		// Loop keywords are weighted down in the default configuration
+		{"Bass Loop.wav", []Category{CategoryBass, CategoryLoop}},
+		{"random_sound.wav", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			result := categorizeName(c, tt.fileName)
+			if len(result.Candidates) != len(tt.candidates) {
+				t.Fatalf("Expected candidates %v, got %+v", tt.candidates, result.Candidates)
+			}
+			for i, expected := range tt.candidates {
+				if result.Candidates[i].Category != expected {
+					t.Errorf("Expected candidate %d to be %s, got %s", i, expected, result.Candidates[i].Category)
+				}
+			}
+			if len(tt.candidates) > 0 && result.Category != tt.candidates[0] {
+				t.Errorf("Expected category %s, got %s", tt.candidates[0], result.Category)
+			}
+		})
+	}
+}
+
+func TestScoringConfidence(t *testing.T) {
+	c := newCategorizer(t, config.GetDefaultConfig())
+
+	clear := categorizeName(c, "Kick_01.wav")
+	if clear.Confidence != 1 {
+		t.Errorf("Expected full confidence for an unambiguous name, got %v", clear.Confidence)
+	}
+	if clear.LowConfidence(c.Threshold()) {
+		t.Error("An unambiguous name should not be low-confidence")
+	}
+
+	ambiguous := categorizeName(c, "Vocal Chop FX Riser.wav")
+	if ambiguous.Confidence >= 0.6 || ambiguous.Confidence <= 0.5 {
+		t.Errorf("Expected a confidence just above 50%% for an ambiguous name, got %v", ambiguous.Confidence)
+	}
+	if !ambiguous.LowConfidence(c.Threshold()) {
+		t.Error("An ambiguous name should be low-confidence")
+	}
+
+	uncategorized := categorizeName(c, "random_sound.wav")
+	if uncategorized.Confidence != 0 || uncategorized.LowConfidence(c.Threshold()) {
+		t.Errorf("Uncategorized files should have no confidence and not be listed for review, got %v", uncategorized.Confidence)
+	}
+}
+
+func TestScoringWeightsAndTies(t *testing.T) {
+	cfg := &config.CategoryConfig{
+		Categories: []config.CategoryDefinition{
+			{Name: "first", Priority: 1, Keywords: []string{"alpha"}},
+			{Name: "second", Priority: 2, Keywords: []string{"omega", "re:alpha"}},
+		},
+	}
+
+	// Equal scores fall back to priority
+	result := categorizeName(newCategorizer(t, cfg), "alpha.wav")
+	if result.Category != "first" || result.Confidence != 0.5 {
+		t.Errorf("Expected the higher-priority category to win a tie at 50%%, got %s at %v", result.Category, result.Confidence)
+	}
+
+	// Earlier matches score higher
+	result = categorizeName(newCategorizer(t, cfg), "omega alpha.wav")
+	if result.Category != "second" {
+		t.Errorf("Expected the earlier keyword to win, got %s", result.Category)
+	}
+
+	cfg.Categories[1].Keywords = []string{"omega"}
+	cfg.Categories[1].Weight = 2
+	result = categorizeName(newCategorizer(t, cfg), "alpha omega.wav")
+	if result.Category != "second" {
+		t.Errorf("Expected the heavier category to win, got %s", result.Category)
+	}
+	if result.Candidates[0].Score <= result.Candidates[1].Score {
+		t.Errorf("Expected candidates ordered by score, got %+v", result.Candidates)
+	}
+}
@@ -0,0 +1,69 @@
+package categorizer
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Tag prefixes for attributes extracted from the filename
+const (
+	TagPrefixBPM = "bpm:"
+	TagPrefixKey = "key:"
+)
+
+// collectTags returns the sorted tags of a file: every candidate category, every
+// subcategory of a candidate whose keywords match as "category/subcategory" (nested ones
+// as "category/subcategory/nested"), and the
+// tempo and key as "bpm:128" and "key:Am"
+func (c *Categorizer) collectTags(candidates []Candidate, all []evidence, attrs MusicalAttributes) []string {
+	set := make(map[string]bool)
+	for _, candidate := range candidates {
+		set[string(candidate.Category)] = true
+
+		for _, cat := range c.categories {
+			if cat.def.Name != string(candidate.Category) {
+				continue
+			}
+			c.tagSubcategories(set, cat, cat.subcategories, all)
+		}
+	}
+
+	if attrs.BPM > 0 {
+		set[TagPrefixBPM+strconv.Itoa(attrs.BPM)] = true
+	}
+	if attrs.Key != "" {
+		set[TagPrefixKey+attrs.Key] = true
+	}
+
+	if len(set) == 0 {
+		return nil
+	}
+	tags := make([]string, 0, len(set))
+	for tag := range set {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// tagSubcategories adds a tag for every matching subcategory, descending into the nested
+// subcategories of those that match
+func (c *Categorizer) tagSubcategories(set map[string]bool, cat compiledCategory, subcategories []compiledSubcategory, all []evidence) {
+	for _, sub := range subcategories {
+		if c.subcategoryMatches(cat, sub, all) {
+			set[cat.def.Name+"/"+sub.path] = true
+			c.tagSubcategories(set, cat, sub.children, all)
+		}
+	}
+}
+
+// subcategoryMatches reports whether any of the subcategory's keywords matches evidence
+// allowed by the category's match source that none of its exclusions match
+func (c *Categorizer) subcategoryMatches(cat compiledCategory, sub compiledSubcategory, all []evidence) bool {
+	for _, ev := range all {
+		if c.allows(cat, ev) && !matchesAny(sub.excludes, ev.name) && matchesAny(sub.keywords, ev.name) {
+			return true
+		}
+	}
+	return false
+}
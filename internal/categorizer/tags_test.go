@@ -0,0 +1,72 @@
+package categorizer
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/theclifmeister/sample-shifter/internal/config"
+)
+
+func TestCategorizeTags(t *testing.T) {
+	c := newCategorizer(t, config.GetDefaultConfig())
+
+	tests := []struct {
+		fileName string
+		category Category
+		tags     []string
+	}{
+		{"Vocal FX Riser 128bpm.wav", CategoryFX, []string{"bpm:128", "fx", "fx/riser", "vocals", "vocals/vocal"}},
+		{"Kick_01.wav", CategoryDrum, []string{"drums", "drums/kick"}},
+		{"Pad_Am_90bpm.wav", CategorySynth, []string{"bpm:90", "key:Am", "synth", "synth/pad"}},
+		{"random_sound.wav", CategoryUncategorized, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			result := categorizeName(c, tt.fileName)
+			if result.Category != tt.category {
+				t.Errorf("Expected folder category %s, got %s", tt.category, result.Category)
+			}
+			if !reflect.DeepEqual(result.Tags, tt.tags) {
+				t.Errorf("Expected tags %v, got %v", tt.tags, result.Tags)
+			}
+		})
+	}
+}
+
+func TestTagsExcludedSubcategory(t *testing.T) {
+	cfg := &config.CategoryConfig{
+		Categories: []config.CategoryDefinition{
+			{
+				Name:     "drums",
+				Priority: 1,
+				Keywords: []string{"hat"},
+				Subcategories: config.Subcategories{
+					{Name: "hihat", Keywords: []string{"hat"}, ExcludeKeywords: []string{"top hat"}},
+				},
+			},
+		},
+	}
+
+	result := categorizeName(newCategorizer(t, cfg), "Top Hat.wav")
+	if !reflect.DeepEqual(result.Tags, []string{"drums"}) {
+		t.Errorf("Expected only the category tag, got %v", result.Tags)
+	}
+}
+
+func TestTagsInPreviewJSON(t *testing.T) {
+	result := categorizeName(newCategorizer(t, config.GetDefaultConfig()), "Kick_01.wav")
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded CategorizedFile
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Tags, result.Tags) {
+		t.Errorf("Expected tags %v to survive a round trip, got %v", result.Tags, decoded.Tags)
+	}
+}
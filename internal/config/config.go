@@ -1,296 +1,448 @@
 package config
 
 import (
+	_ "embed"
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+
+	"github.com/theclifmeister/sample-shifter/internal/keyword"
+	"github.com/theclifmeister/sample-shifter/internal/layout"
 )
 
 // CategoryConfig represents the configuration for categories and subcategories
 type CategoryConfig struct {
-	Categories []CategoryDefinition `json:"categories"`
+	// Schema is the JSON Schema URL or path that editors validate the file against; it is
+	// ignored when loading (see Schema)
+	Schema string `json:"$schema,omitempty"`
+	// Version is the version of the configuration format; files of earlier versions are
+	// migrated when loaded (see CurrentVersion and Migrate)
+	Version int `json:"version,omitempty"`
+	// Extends names the configuration this one is layered on: "default" for the built-in
+	// default or a file path, relative to this file (see Merge)
+	Extends string `json:"extends,omitempty"`
+	// Layout is the target path template, e.g. "{category}/{subcategory}/{filename}"
+	Layout string `json:"layout,omitempty"`
+	// PathEvidence also matches keywords against the folder names between the scan root
+	// and the file, for categories that don't set their own MatchSource. Nil means unset,
+	// so that a layer can turn it off again (see Merge); read it with UsesPathEvidence.
+	PathEvidence *bool `json:"path_evidence,omitempty"`
+	// ConfidenceThreshold is the confidence (0-1) below which preview lists a file for
+	// review; zero means DefaultConfidenceThreshold
+	ConfidenceThreshold float64              `json:"confidence_threshold,omitempty"`
+	Categories          []CategoryDefinition `json:"categories"`
+	// RemoveCategories drops categories of the extended configuration by name
+	RemoveCategories []string `json:"remove_categories,omitempty"`
+	// Warnings are problems found while loading that don't make the configuration
+	// invalid, e.g. sibling subcategories that can only be told apart by name, or files
+	// upgraded from an earlier version
+	Warnings []string `json:"-"`
+
+	// migrated describes the migrations of the files this configuration was read from;
+	// validation keeps them in Warnings
+	migrated []string
+}
+
+// Bool returns a pointer to v, for optional switches such as PathEvidence
+func Bool(v bool) *bool {
+	return &v
+}
+
+// UsesPathEvidence reports whether folder names are matched for categories without their
+// own MatchSource
+func (c *CategoryConfig) UsesPathEvidence() bool {
+	return c.PathEvidence != nil && *c.PathEvidence
+}
+
+// DefaultConfidenceThreshold is used when the configuration sets no confidence_threshold
+const DefaultConfidenceThreshold = 0.6
+
+// Threshold returns the effective low-confidence threshold
+func (c *CategoryConfig) Threshold() float64 {
+	if c.ConfidenceThreshold > 0 {
+		return c.ConfidenceThreshold
+	}
+	return DefaultConfidenceThreshold
+}
+
+// MatchSource selects what a category's keywords are matched against
+type MatchSource string
+
+const (
+	// MatchFilename matches the filename only
+	MatchFilename MatchSource = "filename"
+	// MatchPath matches the folder names below the scan root only
+	MatchPath MatchSource = "path"
+	// MatchBoth matches the filename and the folder names, which count for less
+	MatchBoth MatchSource = "both"
+)
+
+// UsesFilename reports whether keywords are matched against the filename
+func (s MatchSource) UsesFilename() bool {
+	return s != MatchPath
+}
+
+// UsesPath reports whether keywords are matched against the folder names
+func (s MatchSource) UsesPath() bool {
+	return s == MatchPath || s == MatchBoth
 }
 
 // CategoryDefinition defines a single category with its keywords and subcategories
 type CategoryDefinition struct {
-	Name         string                `json:"name"`
-	Priority     int                   `json:"priority"`
-	Keywords     []string              `json:"keywords"`
-	Subcategories map[string][]string  `json:"subcategories,omitempty"`
+	Name     string   `json:"name"`
+	Priority int      `json:"priority"`
+	Keywords []string `json:"keywords"`
+	// MinDuration and MaxDuration (in seconds) let files without matching keywords
+	// fall into this category based on their length read from the audio header
+	MinDuration float64 `json:"min_duration,omitempty"`
+	MaxDuration float64 `json:"max_duration,omitempty"`
+	// BarAligned additionally requires the duration to be a whole number of 4/4 bars; nil
+	// means unset (see Merge). Read it with RequiresBarAligned.
+	BarAligned *bool `json:"bar_aligned,omitempty"`
+	// ExcludeKeywords reject a keyword match, e.g. "subtle" for a bass category matching "sub"
+	ExcludeKeywords []string `json:"exclude_keywords,omitempty"`
+	// Weight scales the score of this category's keyword matches; zero means 1
+	Weight float64 `json:"weight,omitempty"`
+	// MatchSource overrides the config-wide path_evidence setting for this category
+	MatchSource   MatchSource   `json:"match_source,omitempty"`
+	Subcategories Subcategories `json:"subcategories,omitempty"`
+	// RemoveKeywords, RemoveExcludeKeywords and RemoveSubcategories drop keywords, exclude
+	// keywords and subcategories (by path, e.g. "cymbal/crash") of the same category in the
+	// extended configuration
+	RemoveKeywords        []string `json:"remove_keywords,omitempty"`
+	RemoveExcludeKeywords []string `json:"remove_exclude_keywords,omitempty"`
+	RemoveSubcategories   []string `json:"remove_subcategories,omitempty"`
+}
+
+// RequiresBarAligned reports whether the duration rule requires whole 4/4 bars
+func (c CategoryDefinition) RequiresBarAligned() bool {
+	return c.BarAligned != nil && *c.BarAligned
+}
+
+// Source returns the match source of the category: its own setting, otherwise both the
+// filename and the path when path evidence is enabled, otherwise the filename only
+func (c CategoryDefinition) Source(pathEvidence bool) MatchSource {
+	switch {
+	case c.MatchSource != "":
+		return c.MatchSource
+	case pathEvidence:
+		return MatchBoth
+	default:
+		return MatchFilename
+	}
 }
 
-// LoadConfig loads the configuration from a JSON file
-// If configPath is empty, returns the default configuration
+// KeywordWeight returns the effective weight of the category's keywords
+func (c CategoryDefinition) KeywordWeight() float64 {
+	if c.Weight > 0 {
+		return c.Weight
+	}
+	return 1
+}
+
+// HasDurationRule reports whether the category defines any duration-based matching rule
+func (c CategoryDefinition) HasDurationRule() bool {
+	return c.MinDuration > 0 || c.MaxDuration > 0 || c.RequiresBarAligned()
+}
+
+// LoadConfig loads the configuration from a JSON, YAML or TOML file (see FormatOf) and
+// resolves the configurations it extends. If configPath is empty, returns the default
+// configuration
 func LoadConfig(configPath string) (*CategoryConfig, error) {
 	if configPath == "" {
 		return GetDefaultConfig(), nil
 	}
 
+	config, err := resolveConfig(configPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate configuration
+	if err := validateConfig(config); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return config, nil
+}
+
+// ReadConfig reads a configuration file as written, without resolving the configuration
+// it extends or validating it. Fields that no configuration object has are errors. Files
+// of an earlier version are migrated to CurrentVersion, with a warning.
+func ReadConfig(configPath string) (*CategoryConfig, error) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	format := FormatOf(configPath)
+	converted, err := toJSON(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	doc, err := readDocument(data, converted, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	from, changes, err := migrate(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate config file: %w", err)
+	}
+	if from < CurrentVersion {
+		if converted, err = doc.json(); err != nil {
+			return nil, fmt.Errorf("failed to migrate config file: %w", err)
+		}
+	}
+
 	var config CategoryConfig
-	if err := json.Unmarshal(data, &config); err != nil {
+	if err := json.Unmarshal(converted, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", fieldError(doc, err))
+	}
+
+	// Unknown fields are most likely typos that would silently change nothing
+	if err := checkFields(doc); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
-	// Validate configuration
-	if err := validateConfig(&config); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
+	if from < CurrentVersion {
+		config.migrated = append(config.migrated, fmt.Sprintf(
+			"%s is configuration version %d and was upgraded to version %d when loading; run \"sample-shifter config migrate --config %s\" to update the file",
+			configPath, from, CurrentVersion, configPath))
+		for _, change := range changes {
+			config.migrated = append(config.migrated, fmt.Sprintf("%s: %s", configPath, change))
+		}
 	}
+	config.Warnings = append([]string(nil), config.migrated...)
 
 	return &config, nil
 }
 
+// Validate checks a configuration built in code the way LoadConfig checks files, and
+// records warnings in c.Warnings
+func (c *CategoryConfig) Validate() error {
+	return validateConfig(c)
+}
+
 // validateConfig checks that the given CategoryConfig is valid.
-// It ensures that there is at least one category, each category has a non-empty name,
-// no duplicate category names exist, and each category has at least one keyword.
+// It ensures that there is at least one category, category and subcategory names are
+// usable as folder names and unique among their siblings, each category has a positive
+// priority and at least one keyword, no keyword is an empty string,
+// every keyword has something to match, no exclusion shadows a keyword,
+// duration rules are non-negative with min_duration not exceeding max_duration,
+// match_source is a known value, weights, subcategory priorities and the confidence
+// threshold are in range, and the layout template (if set) parses.
 // Returns an error describing the first validation failure encountered, or nil if valid.
+// Ambiguous subcategory keywords are recorded in config.Warnings.
 func validateConfig(config *CategoryConfig) error {
 	if len(config.Categories) == 0 {
 		return fmt.Errorf("configuration must contain at least one category")
 	}
 
+	if config.Layout != "" {
+		if _, err := layout.Parse(config.Layout); err != nil {
+			return fmt.Errorf("invalid layout: %w", err)
+		}
+	}
+
+	if config.ConfidenceThreshold < 0 || config.ConfidenceThreshold > 1 {
+		return fmt.Errorf("confidence_threshold must be between 0 and 1, got %g", config.ConfidenceThreshold)
+	}
+
 	seenNames := make(map[string]bool)
 	for _, cat := range config.Categories {
 		if cat.Name == "" {
 			return fmt.Errorf("category name cannot be empty")
 		}
+		if err := validateName(cat.Name); err != nil {
+			return fmt.Errorf("category %q: %w", cat.Name, err)
+		}
 		if seenNames[cat.Name] {
 			return fmt.Errorf("duplicate category name: %s", cat.Name)
 		}
 		seenNames[cat.Name] = true
 
+		if cat.Priority < 1 {
+			return fmt.Errorf("category %s must have a priority of 1 or more, got %d", cat.Name, cat.Priority)
+		}
+
 		if len(cat.Keywords) == 0 {
 			return fmt.Errorf("category %s must have at least one keyword", cat.Name)
 		}
+
+		if err := validateKeywords(cat.Keywords, cat.ExcludeKeywords); err != nil {
+			return fmt.Errorf("category %s: %w", cat.Name, err)
+		}
+		if err := validateSubcategories(cat.Subcategories, ""); err != nil {
+			return fmt.Errorf("category %s, %w", cat.Name, err)
+		}
+
+		switch cat.MatchSource {
+		case "", MatchFilename, MatchPath, MatchBoth:
+		default:
+			return fmt.Errorf("category %s has unknown match_source %q (valid: filename, path, both)", cat.Name, cat.MatchSource)
+		}
+
+		if cat.Weight < 0 {
+			return fmt.Errorf("category %s has a negative weight", cat.Name)
+		}
+
+		if cat.MinDuration < 0 || cat.MaxDuration < 0 {
+			return fmt.Errorf("category %s has a negative duration rule", cat.Name)
+		}
+		if cat.MaxDuration > 0 && cat.MinDuration > cat.MaxDuration {
+			return fmt.Errorf("category %s has min_duration (%gs) greater than max_duration (%gs)",
+				cat.Name, cat.MinDuration, cat.MaxDuration)
+		}
 	}
 
+	config.Warnings = append([]string(nil), config.migrated...)
+	for _, cat := range config.Categories {
+		config.Warnings = append(config.Warnings, subcategoryWarnings(cat.Name, cat.Subcategories, "")...)
+	}
+
+	return nil
+}
+
+// validateSubcategories checks the names and keywords of subcategories at any depth;
+// prefix is the path of the parent subcategory
+func validateSubcategories(subcategories Subcategories, prefix string) error {
+	seenNames := make(map[string]bool)
+	for _, sub := range subcategories {
+		path := prefix + sub.Name
+		if err := validateName(sub.Name); err != nil {
+			return fmt.Errorf("subcategory %q: %w", path, err)
+		}
+		if seenNames[sub.Name] {
+			return fmt.Errorf("duplicate subcategory name: %s", path)
+		}
+		seenNames[sub.Name] = true
+
+		if sub.Priority < 0 {
+			return fmt.Errorf("subcategory %s has a negative priority", path)
+		}
+		if err := validateKeywords(sub.Keywords, sub.ExcludeKeywords); err != nil {
+			return fmt.Errorf("subcategory %s: %w", path, err)
+		}
+		if err := validateSubcategories(sub.Subcategories, path+"/"); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// GetDefaultConfig returns the default hardcoded configuration
-// This ensures backward compatibility when no config file is provided
+// subcategoryWarnings reports sibling subcategories at any depth with the same priority
+// whose keywords can match the same name with the same length, so that only their names
+// decide between them; prefix is the path of the parent subcategory
+func subcategoryWarnings(category string, subcategories Subcategories, prefix string) []string {
+	var warnings []string
+	for i, a := range subcategories {
+		for _, b := range subcategories[i+1:] {
+			if a.Priority != b.Priority {
+				continue
+			}
+			if kwA, kwB, ok := overlappingKeywords(a, b); ok {
+				winner := a
+				if b.Precedes(a) {
+					winner = b
+				}
+				warnings = append(warnings, fmt.Sprintf(
+					"category %s: subcategories %q (keyword %q) and %q (keyword %q) match the same names with the same length; %q wins by name (set priority to choose)",
+					category, prefix+a.Name, kwA, prefix+b.Name, kwB, prefix+winner.Name))
+			}
+		}
+		warnings = append(warnings, subcategoryWarnings(category, a.Subcategories, prefix+a.Name+"/")...)
+	}
+	return warnings
+}
+
+// overlappingKeywords returns the first pair of keywords of a and b, including those of
+// their nested subcategories, that match some name with the same length
+func overlappingKeywords(a, b SubcategoryDefinition) (string, string, bool) {
+	for _, ma := range compileAll(a) {
+		for _, mb := range compileAll(b) {
+			if ma.Len() != mb.Len() {
+				continue
+			}
+			if strings.EqualFold(ma.Keyword, mb.Keyword) || ma.Covers(mb) || mb.Covers(ma) {
+				return ma.Keyword, mb.Keyword, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// compileAll compiles the keywords of a subcategory and its nested subcategories,
+// skipping invalid ones
+func compileAll(sub SubcategoryDefinition) []*keyword.Matcher {
+	var matchers []*keyword.Matcher
+	collect := func(keywords []string) {
+		for _, kw := range keywords {
+			if m, err := keyword.Compile(kw); err == nil {
+				matchers = append(matchers, m)
+			}
+		}
+	}
+	collect(sub.Keywords)
+	sub.Subcategories.Walk(func(_ string, child SubcategoryDefinition) {
+		collect(child.Keywords)
+	})
+	return matchers
+}
+
+// validateKeywords checks that every keyword and exclusion compiles to a matcher and that
+// no exclusion rejects every name an inclusion could match
+func validateKeywords(keywords, excludes []string) error {
+	for i, kw := range append(append([]string(nil), keywords...), excludes...) {
+		if strings.TrimSpace(kw) == "" {
+			if i >= len(keywords) {
+				return fmt.Errorf("exclude_keywords: keyword %d is an empty string", i-len(keywords)+1)
+			}
+			return fmt.Errorf("keyword %d is an empty string", i+1)
+		}
+	}
+
+	var included []*keyword.Matcher
+	for _, kw := range keywords {
+		m, err := keyword.Compile(kw)
+		if err != nil {
+			return err
+		}
+		included = append(included, m)
+	}
+
+	for _, ex := range excludes {
+		exclusion, err := keyword.Compile(ex)
+		if err != nil {
+			return fmt.Errorf("exclude_keywords: %w", err)
+		}
+		for _, inclusion := range included {
+			if exclusion.Covers(inclusion) {
+				return fmt.Errorf("exclude keyword %q shadows keyword %q, which can never match", ex, inclusion.Keyword)
+			}
+		}
+	}
+	return nil
+}
+
+// defaultConfigJSON is the built-in default configuration, the only definition of the
+// default categories; config-example.json is a copy written by "config dump-default"
+//
+//go:embed default.json
+var defaultConfigJSON []byte
+
+// DefaultConfigJSON returns the built-in default configuration as written in default.json
+func DefaultConfigJSON() []byte {
+	return append([]byte(nil), defaultConfigJSON...)
+}
+
+// GetDefaultConfig returns the built-in default configuration, decoded from the embedded
+// default.json on every call so callers can modify the result
 func GetDefaultConfig() *CategoryConfig {
-	return &CategoryConfig{
-		Categories: []CategoryDefinition{
-			{
-				Name:     "oneshots",
-				Priority: 1,
-				Keywords: []string{"oneshot", "one-shot", "hit", "stab", "shot"},
-				Subcategories: map[string][]string{
-					"bass":    {"bass shot", "bass_shot", "bass stab", "bass_stab", "bass hit", "bass_hit", "bassshot"},
-					"synth":   {"synth shot", "synth_shot", "synth stab", "synth_stab", "synthshot"},
-					"vocal":   {"vocal shot", "vocal_shot"},
-					"drum":    {"drum hit", "drum_hit", "drum stab", "drum_stab"},
-					"melodic": {"melodic stab", "melodic_stab"},
-					"general": {"oneshot", "one-shot", "one_shot", "hit", "stab", "shot"},
-				},
-			},
-			{
-				Name:     "drums",
-				Priority: 2,
-				Keywords: []string{"kick", "snare", "hihat", "hi-hat", "hi_hat", "hi hat", "hats", "clap", "tom", "cymbal", "crash", "ride", "drum", "bd", "sd", "hh", "closed hat", "open hat", "hat closed", "hat open", "sidestick", "side stick", "rimshot", "rim shot", "cup", "rim", "cym", "china", "crossstick", "cross stick"},
-				Subcategories: map[string][]string{
-					"kick":   {"kick", "bd"},
-					"snare":  {"snare", "sd"},
-					"hihat":  {"hihat", "hi-hat", "hi_hat", "hi hat", "hh", "hats", "closed hat", "open hat", "hat closed", "hat open"},
-					"clap":   {"clap"},
-					"tom":    {"tom", "toms"},
-					"cymbal": {"cymbal", "crash", "ride", "cup", "cym", "china"},
-					"rimshot": {"sidestick", "side stick", "rimshot", "rim shot", "crossstick", "cross stick", "rim"},
-					"fill":   {"drum fill", "drum_fill"},
-					"loop":   {"drum loop", "drum_loop", "beat loop", "beat_loop"},
-					"ethnic": {"ethnic drum", "ethnic_drum", "indian drum", "indian_drum", "tribal drum", "tribal_drum"},
-					"acoustic": {"acoustic drum", "acoustic_drum"},
-					"cinematic": {"cinematic drum", "cinematic_drum", "cinematic"},
-				},
-			},
-			{
-				Name:     "bass",
-				Priority: 3,
-				Keywords: []string{"bass", "sub", "808", "909"},
-				Subcategories: map[string][]string{
-					"sub":   {"sub", "subbass", "sub-bass", "sub_bass"},
-					"808":   {"808"},
-					"909":   {"909"},
-					"growl": {"growl", "wobble", "whomp", "freak"},
-					"loop":  {"bass loop", "bass_loop", "bassloop"},
-					"psy":   {"psy", "psy bass", "psy_bass", "psybass"},
-					"pluck": {"bass pluck", "bass_pluck", "pluck bass", "pluck_bass", "plucked bass", "plucked_bass"},
-				},
-			},
-			{
-				Name:     "percussion",
-				Priority: 4,
-				Keywords: []string{"perc", "percussion", "shaker", "conga", "bongo", "tambourine", "tamb", "cowbell", "cabasa", "clave", "claves", "agogo", "timbale", "timpani", "maracas", "maraca", "woodblock", "wood block", "triangle", "guiro", "djembe", "udu", "brush", "chk", "cowb"},
-				Subcategories: map[string][]string{
-					"shaker":      {"shaker", "shake"},
-					"conga":       {"conga", "congas"},
-					"bongo":       {"bongo"},
-					"tambourine":  {"tambourine", "tamb"},
-					"cowbell":     {"cowbell", "cow bell", "cowb"},
-					"cabasa":      {"cabasa"},
-					"clave":       {"clave", "claves"},
-					"agogo":       {"agogo"},
-					"timbale":     {"timbale"},
-					"timpani":     {"timpani"},
-					"maracas":     {"maracas", "maraca"},
-					"woodblock":   {"woodblock", "wood block"},
-					"triangle":    {"triangle"},
-					"guiro":       {"guiro"},
-					"djembe":      {"djembe"},
-					"udu":         {"udu"},
-					"brush":       {"brush"},
-					"miscellaneous": {"chk"},
-					"high":        {"hi perc", "hi_perc", "high perc", "high_perc", "high percussion", "high_percussion", "percussion high", "percussion_high"},
-					"low":         {"low perc", "low_perc", "low percussion", "low_percussion", "percussion low", "percussion_low"},
-					"mid":         {"mid perc", "mid_perc", "mid percussion", "mid_percussion", "percussion mid", "percussion_mid"},
-					"loop":        {"percussion loop", "percussion_loop", "perc loop", "perc_loop"},
-					"rimshot":     {"rimshot", "rim shot", "rim_shot", "rim"},
-					"clank":       {"clank", "metal perc", "metal_perc", "metallic"},
-					"wood":        {"wooden", "wood perc", "wood_perc", "wooden perc", "wooden_perc"},
-					"slap":        {"slap", "percussion slap", "percussion_slap"},
-					"knock":       {"knock", "percussion knock", "percussion_knock"},
-					"beatbox":     {"beatbox", "beat box", "beat_box"},
-					"ethnic":      {"ethnic perc", "ethnic_perc", "tribal perc", "tribal_perc", "african perc", "african_perc", "indian perc", "indian_perc"},
-				},
-			},
-			{
-				Name:     "vocals",
-				Priority: 5,
-				Keywords: []string{"vocal", "vox", "voice", "acapella", "choir", "shout", "chant", "adlib"},
-				Subcategories: map[string][]string{
-					"vocal":    {"vocal"},
-					"vox":      {"vox"},
-					"voice":    {"voice"},
-					"acapella": {"acapella"},
-					"choir":    {"choir", "chorus", "ensemble"},
-					"shout":    {"shout", "yell", "scream"},
-					"chant":    {"chant", "chanting"},
-					"adlib":    {"adlib", "ad-lib", "ad lib"},
-				},
-			},
-			{
-				Name:     "synth",
-				Priority: 6,
-				Keywords: []string{"synth", "lead", "pad", "pluck", "saw", "square", "sine"},
-				Subcategories: map[string][]string{
-					"lead":    {"lead", "leads", "synth lead", "synth_lead"},
-					"pad":     {"pad", "pads", "synth pad", "synth_pad"},
-					"pluck":   {"pluck", "plucks", "plucked", "synth pluck", "synth_pluck"},
-					"saw":     {"saw", "sawtooth"},
-					"square":  {"square"},
-					"sine":    {"sine"},
-					"loop":    {"synth loop", "synth_loop", "synthloop"},
-					"reverse": {"reverse synth", "reverse_synth", "reversed"},
-					"fill":    {"synth fill", "synth_fill", "synthfill"},
-					"arp":     {"arp", "arpeggio", "arpeggiated"},
-					"blip":    {"blip", "beep", "bleep"},
-				},
-			},
-			{
-				Name:     "melodic",
-				Priority: 7,
-				Keywords: []string{"piano", "guitar", "bell", "marimba", "xylophone", "harp", "strings", "violin", "cello", "flute", "horn", "trumpet", "sax", "saxophone", "organ", "keys", "brass", "woodwind", "arpeggio", "arpeggiated", "melody", "oud", "bouzouki", "duduk", "glissentar", "joombush", "mandolin", "mandolino", "wurli", "wurlitzer", "clav", "clavinet", "accordion", "chime", "chimes"},
-				Subcategories: map[string][]string{
-					"piano":     {"piano"},
-					"guitar":    {"guitar", "gtr", "acoustic guitar", "electric guitar"},
-					"bell":      {"bell", "chime", "chimes"},
-					"marimba":   {"marimba"},
-					"xylophone": {"xylophone"},
-					"harp":      {"harp"},
-					"strings":   {"strings", "string", "violin", "cello", "viola"},
-					"woodwind":  {"flute", "clarinet", "oboe", "sax", "saxophone", "woodwind"},
-					"brass":     {"horn", "trumpet", "trombone", "brass"},
-					"keys":      {"organ", "keys", "keyboard", "wurli", "wurlitzer", "clav", "clavinet"},
-					"oud":       {"oud"},
-					"bouzouki":  {"bouzouki"},
-					"duduk":     {"duduk"},
-					"glissentar": {"glissentar"},
-					"joombush":  {"joombush"},
-					"mandolin":  {"mandolin", "mandolino"},
-					"accordion": {"accordion"},
-				},
-			},
-			{
-				Name:     "fx",
-				Priority: 8,
-				Keywords: []string{"fx", "sfx", "riser", "downsweep", "whoosh", "impact", "sweep", "noise", "white", "reverse", "rev", "glitch", "tone", "envelope", "pulse", "ufo", "bleeps", "sync", "click"},
-				Subcategories: map[string][]string{
-					"riser":       {"riser", "uplift", "risefx"},
-					"downsweep":   {"downsweep"},
-					"whoosh":      {"whoosh"},
-					"impact":      {"impact", "boom", "slam"},
-					"sweep":       {"sweep", "uplifter"},
-					"noise":       {"noise", "white", "white noise", "pink noise"},
-					"reverse":     {"reverse", "rev"},
-					"game":        {"game", "video game"},
-					"psy":         {"psy", "psychedelic"},
-					"transformer": {"transformer", "robot"},
-					"laser":       {"laser", "lazer"},
-					"water":       {"water", "splash", "ocean"},
-					"glitch":      {"glitch"},
-					"tone":        {"tone"},
-					"envelope":    {"envelope"},
-					"pulse":       {"pulse"},
-					"ufo":         {"ufo"},
-					"blip":        {"bleeps"},
-					"sync":        {"sync"},
-					"click":       {"click"},
-				},
-			},
-			{
-				Name:     "transition",
-				Priority: 9,
-				Keywords: []string{"fill", "transition", "build", "buildup", "build-up", "breakdown", "break-down", "downlifter", "stop"},
-				Subcategories: map[string][]string{
-					"fill":       {"fill"},
-					"transition": {"transition"},
-					"buildup":    {"build", "buildup", "build-up"},
-					"breakdown":  {"breakdown", "break-down"},
-					"downlifter": {"downlifter"},
-					"stop":       {"stop"},
-				},
-			},
-			{
-				Name:     "ambiance",
-				Priority: 10,
-				Keywords: []string{"ambiance", "ambient", "atmosphere", "drone", "texture", "atmospheric"},
-				Subcategories: map[string][]string{
-					"dark":       {"dark"},
-					"bright":     {"bright"},
-					"space":      {"space"},
-					"nature":     {"nature"},
-					"industrial": {"industrial"},
-				},
-			},
-			{
-				Name:     "foley",
-				Priority: 11,
-				Keywords: []string{"foley", "bird", "animal", "water", "splash", "scratch", "vinyl", "snap", "whistle", "ocean", "nature", "wind"},
-				Subcategories: map[string][]string{
-					"nature":     {"bird", "wind"},
-					"animal":     {"animal"},
-					"water":      {"water", "splash", "ocean"},
-					"vinyl":      {"scratch", "vinyl"},
-					"human":      {"snap", "whistle"},
-					"mechanical": {"mechanical"},
-				},
-			},
-			{
-				Name:     "loops",
-				Priority: 12,
-				Keywords: []string{"loop", "phrase", "bar", "beat"},
-				Subcategories: map[string][]string{
-					"loop":   {"loop"},
-					"phrase": {"phrase"},
-					"bar":    {"bar"},
-					"beat":   {"beat"},
-				},
-			},
-		},
+	var config CategoryConfig
+	if err := json.Unmarshal(defaultConfigJSON, &config); err != nil {
+		panic(fmt.Sprintf("invalid embedded default configuration: %v", err))
 	}
+	return &config
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -34,8 +35,8 @@ func TestLoadConfigFromFile(t *testing.T) {
 				Name:     "test_category",
 				Priority: 1,
 				Keywords: []string{"test", "sample"},
-				Subcategories: map[string][]string{
-					"sub1": {"keyword1", "keyword2"},
+				Subcategories: Subcategories{
+					{Name: "sub1", Keywords: []string{"keyword1", "keyword2"}},
 				},
 			},
 		},
@@ -84,6 +85,26 @@ func TestLoadConfigInvalidJSON(t *testing.T) {
 	}
 }
 
+func TestLoadConfigInvalidPattern(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+
+	data := `{"categories": [{"name": "drums", "priority": 1, "keywords": ["kick", "re:bd(\\d+"]}]}`
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	_, err := LoadConfig(configPath)
+	if err == nil {
+		t.Fatal("LoadConfig should error on an invalid regular expression")
+	}
+	for _, want := range []string{"drums", `re:bd(\\d+`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %s, got: %v", want, err)
+		}
+	}
+}
+
 func TestLoadConfigNonexistent(t *testing.T) {
 	_, err := LoadConfig("/nonexistent/path/config.json")
 	if err == nil {
@@ -158,6 +179,153 @@ func TestValidateConfigNoKeywords(t *testing.T) {
 	}
 }
 
+func TestValidateConfigDurationRules(t *testing.T) {
+	tests := []struct {
+		name        string
+		minDuration float64
+		maxDuration float64
+		expectError bool
+	}{
+		{"no rule", 0, 0, false},
+		{"max only", 0, 1.5, false},
+		{"min only", 2, 0, false},
+		{"min below max", 1, 4, false},
+		{"negative", -1, 0, true},
+		{"min above max", 4, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &CategoryConfig{
+				Categories: []CategoryDefinition{
+					{
+						Name:        "test",
+						Priority:    1,
+						Keywords:    []string{"test"},
+						MinDuration: tt.minDuration,
+						MaxDuration: tt.maxDuration,
+					},
+				},
+			}
+
+			err := validateConfig(config)
+			if tt.expectError && err == nil {
+				t.Error("Validation should fail for invalid duration rule")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Validation should pass, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigLayout(t *testing.T) {
+	tests := []struct {
+		layout      string
+		expectError bool
+	}{
+		{"", false},
+		{"{category}/{subcategory}/{bpm}/{key}/{name}{ext}", false},
+		{"{pack}/{category}/{name}", false},
+		{"{category}/{nope}/{filename}", true},
+		{"/{category}/{filename}", true},
+		{"{category}/{subcategory}", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			config := &CategoryConfig{
+				Layout: tt.layout,
+				Categories: []CategoryDefinition{
+					{Name: "test", Priority: 1, Keywords: []string{"test"}},
+				},
+			}
+
+			err := validateConfig(config)
+			if tt.expectError && err == nil {
+				t.Error("Validation should fail for invalid layout")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Validation should pass, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigKeywords(t *testing.T) {
+	tests := []struct {
+		name          string
+		keywords      []string
+		subcategories Subcategories
+		expectError   bool
+	}{
+		{"plain and substring", []string{"kick", "substr:bass"}, nil, false},
+		{"empty keyword", []string{"kick", ""}, nil, true},
+		{"separators only", []string{"-_"}, nil, true},
+		{"empty substring", []string{"substr:"}, nil, true},
+		{"regex and glob", []string{`re:^bd\d+`, "glob:*_808_*"}, nil, false},
+		{"invalid regex", []string{"re:kick("}, nil, true},
+		{"invalid glob", []string{"kick"}, Subcategories{{Name: "kick", Keywords: []string{"glob:kick["}}}, true},
+		{"empty subcategory keyword", []string{"kick"}, Subcategories{{Name: "kick", Keywords: []string{" "}}}, true},
+		{"nested subcategories", []string{"kick"}, Subcategories{{Name: "cymbal", Subcategories: Subcategories{{Name: "crash", Keywords: []string{"crash"}}}}}, false},
+		{"empty nested keyword", []string{"kick"}, Subcategories{{Name: "cymbal", Subcategories: Subcategories{{Name: "crash", Keywords: []string{""}}}}}, true},
+		{"slash in subcategory name", []string{"kick"}, Subcategories{{Name: "cymbal/crash", Keywords: []string{"crash"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &CategoryConfig{
+				Categories: []CategoryDefinition{
+					{Name: "test", Priority: 1, Keywords: tt.keywords, Subcategories: tt.subcategories},
+				},
+			}
+
+			err := validateConfig(config)
+			if tt.expectError && err == nil {
+				t.Error("Validation should fail for an empty keyword")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Validation should pass, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigExclusions(t *testing.T) {
+	tests := []struct {
+		name          string
+		excludes      []string
+		subcategories Subcategories
+		expectError   bool
+	}{
+		{"narrower exclusion", []string{"top hat"}, nil, false},
+		{"exclusion shadows keyword", []string{"hi-hat"}, nil, true},
+		{"exclusion matching one token of keyword", []string{"hat"}, nil, false},
+		{"substring exclusion shadows keyword", []string{"substr:ha"}, nil, true},
+		{"empty exclusion", []string{""}, nil, true},
+		{"subcategory exclusion", nil, Subcategories{{Name: "hihat", Keywords: []string{"hi hat"}, ExcludeKeywords: []string{"top hat"}}}, false},
+		{"subcategory exclusion shadows keyword", nil, Subcategories{{Name: "hihat", Keywords: []string{"hi hat"}, ExcludeKeywords: []string{"hihat"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &CategoryConfig{
+				Categories: []CategoryDefinition{
+					{Name: "test", Priority: 1, Keywords: []string{"hi hat"}, ExcludeKeywords: tt.excludes, Subcategories: tt.subcategories},
+				},
+			}
+
+			err := validateConfig(config)
+			if tt.expectError && err == nil {
+				t.Error("Validation should fail for an invalid exclusion")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Validation should pass, got: %v", err)
+			}
+		})
+	}
+}
+
 func TestGetDefaultConfig(t *testing.T) {
 	config := GetDefaultConfig()
 
@@ -183,3 +351,236 @@ func TestGetDefaultConfig(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateConfigMatchSource(t *testing.T) {
+	for _, source := range []MatchSource{"", MatchFilename, MatchPath, MatchBoth, "folder"} {
+		config := &CategoryConfig{
+			Categories: []CategoryDefinition{
+				{Name: "test", Priority: 1, Keywords: []string{"test"}, MatchSource: source},
+			},
+		}
+
+		err := validateConfig(config)
+		if source == "folder" && err == nil {
+			t.Error("Validation should fail for an unknown match_source")
+		}
+		if source != "folder" && err != nil {
+			t.Errorf("Validation should pass for %q, got: %v", source, err)
+		}
+	}
+}
+
+func TestCategorySource(t *testing.T) {
+	tests := []struct {
+		source       MatchSource
+		pathEvidence bool
+		expected     MatchSource
+	}{
+		{"", false, MatchFilename},
+		{"", true, MatchBoth},
+		{MatchFilename, true, MatchFilename},
+		{MatchPath, false, MatchPath},
+	}
+
+	for _, tt := range tests {
+		def := CategoryDefinition{Name: "test", MatchSource: tt.source}
+		if got := def.Source(tt.pathEvidence); got != tt.expected {
+			t.Errorf("Source(%v) with match_source %q = %q, expected %q", tt.pathEvidence, tt.source, got, tt.expected)
+		}
+	}
+}
+
+func TestValidateConfigScoring(t *testing.T) {
+	tests := []struct {
+		name        string
+		threshold   float64
+		weight      float64
+		expectError bool
+	}{
+		{"defaults", 0, 0, false},
+		{"custom", 0.8, 1.5, false},
+		{"negative weight", 0, -1, true},
+		{"threshold above one", 1.5, 0, true},
+		{"negative threshold", -0.1, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &CategoryConfig{
+				ConfidenceThreshold: tt.threshold,
+				Categories: []CategoryDefinition{
+					{Name: "test", Priority: 1, Keywords: []string{"test"}, Weight: tt.weight},
+				},
+			}
+
+			err := validateConfig(config)
+			if tt.expectError && err == nil {
+				t.Error("Validation should fail for an out-of-range weight or threshold")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Validation should pass, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigSubcategoryWarnings(t *testing.T) {
+	tests := []struct {
+		name          string
+		subcategories Subcategories
+		warnings      int
+	}{
+		{"distinct keywords", Subcategories{
+			{Name: "kick", Keywords: []string{"kick"}},
+			{Name: "snare", Keywords: []string{"snare"}},
+		}, 0},
+		{"same keyword", Subcategories{
+			{Name: "snare", Keywords: []string{"snare", "rim"}},
+			{Name: "rim", Keywords: []string{"rim"}},
+		}, 1},
+		{"separator variants", Subcategories{
+			{Name: "hihat", Keywords: []string{"hihat"}},
+			{Name: "hat", Keywords: []string{"hi-hat"}},
+		}, 1},
+		{"different lengths", Subcategories{
+			{Name: "hat", Keywords: []string{"hat"}},
+			{Name: "open", Keywords: []string{"open hat"}},
+		}, 0},
+		{"priorities choose", Subcategories{
+			{Name: "snare", Keywords: []string{"rim"}, Priority: 1},
+			{Name: "rim", Keywords: []string{"rim"}, Priority: 2},
+		}, 0},
+		{"nested keywords", Subcategories{
+			{Name: "perc", Keywords: []string{"perc"}, Subcategories: Subcategories{
+				{Name: "clap", Keywords: []string{"clap"}},
+				{Name: "snap", Keywords: []string{"clap"}},
+			}},
+			{Name: "hand", Keywords: []string{"clap"}},
+		}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &CategoryConfig{
+				Categories: []CategoryDefinition{
+					{Name: "drums", Priority: 1, Keywords: []string{"drum"}, Subcategories: tt.subcategories},
+				},
+			}
+
+			if err := validateConfig(config); err != nil {
+				t.Fatalf("Validation should pass, got: %v", err)
+			}
+			if len(config.Warnings) != tt.warnings {
+				t.Errorf("Expected %d warnings, got %v", tt.warnings, config.Warnings)
+			}
+		})
+	}
+
+	config := &CategoryConfig{
+		Categories: []CategoryDefinition{
+			{Name: "drums", Priority: 1, Keywords: []string{"drum"}, Subcategories: Subcategories{
+				{Name: "snare", Keywords: []string{"snare"}, Priority: -1},
+			}},
+		},
+	}
+	if err := validateConfig(config); err == nil {
+		t.Error("Validation should fail for a negative subcategory priority")
+	}
+}
+
+func TestDefaultConfigValid(t *testing.T) {
+	config := GetDefaultConfig()
+	if err := validateConfig(config); err != nil {
+		t.Fatalf("Default config should be valid: %v", err)
+	}
+	if len(config.Warnings) > 0 {
+		t.Errorf("Default config should load without warnings, got %v", config.Warnings)
+	}
+
+	// Every call returns an independent copy
+	config.Categories[0].Keywords[0] = "changed"
+	if GetDefaultConfig().Categories[0].Keywords[0] == "changed" {
+		t.Error("GetDefaultConfig should not share state between calls")
+	}
+}
+
+func TestExampleConfigMatchesDefault(t *testing.T) {
+	example, err := os.ReadFile(filepath.Join("..", "..", "config-example.json"))
+	if err != nil {
+		t.Fatalf("Failed to read config-example.json: %v", err)
+	}
+	if string(example) != string(DefaultConfigJSON()) {
+		t.Error("config-example.json differs from internal/config/default.json; regenerate it with 'sample-shifter config dump-default --output config-example.json'")
+	}
+}
+
+func TestValidateConfigNames(t *testing.T) {
+	tests := []struct {
+		name        string
+		category    string
+		subcategory string
+		expectError bool
+	}{
+		{"plain names", "drums", "kick", false},
+		{"spaces and dots inside", "Drum Loops", "v1.2 kicks", false},
+		{"leading dot", "drums", ".hidden", false},
+		{"backslash in category", `drums\kicks`, "kick", true},
+		{"colon in category", "drums:kicks", "kick", true},
+		{"dot category", ".", "kick", true},
+		{"dot dot subcategory", "drums", "..", true},
+		{"trailing dot", "drums.", "kick", true},
+		{"leading space", " drums", "kick", true},
+		{"trailing space in subcategory", "drums", "kick ", true},
+		{"wildcard in subcategory", "drums", "kick*", true},
+		{"control character", "drums", "kick\t1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &CategoryConfig{
+				Categories: []CategoryDefinition{{
+					Name:          tt.category,
+					Priority:      1,
+					Keywords:      []string{"kick"},
+					Subcategories: Subcategories{{Name: tt.subcategory, Keywords: []string{"kick"}}},
+				}},
+			}
+
+			err := validateConfig(config)
+			if tt.expectError && err == nil {
+				t.Error("Validation should fail for an invalid name")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Validation should pass, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigPriority(t *testing.T) {
+	for _, priority := range []int{0, -1} {
+		config := &CategoryConfig{
+			Categories: []CategoryDefinition{{Name: "drums", Priority: priority, Keywords: []string{"kick"}}},
+		}
+		if err := validateConfig(config); err == nil {
+			t.Errorf("Validation should fail for priority %d", priority)
+		}
+	}
+}
+
+func TestValidateConfigDuplicateSubcategory(t *testing.T) {
+	config := &CategoryConfig{
+		Categories: []CategoryDefinition{{
+			Name:     "drums",
+			Priority: 1,
+			Keywords: []string{"kick"},
+			Subcategories: Subcategories{
+				{Name: "kick", Keywords: []string{"kick"}},
+				{Name: "kick", Keywords: []string{"bd"}},
+			},
+		}},
+	}
+	if err := validateConfig(config); err == nil {
+		t.Error("Validation should fail for duplicate subcategory names")
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// EnvConfigPath is the environment variable naming the configuration file to use when no
+// --config flag is given
+const EnvConfigPath = "SAMPLE_SHIFTER_CONFIG"
+
+// LibraryConfigNames are the per-library configuration files looked up in the source
+// directory and its ancestors, in order of preference
+var LibraryConfigNames = []string{".sample-shifter.json", ".sample-shifter.yaml", ".sample-shifter.yml", ".sample-shifter.toml"}
+
+// UserConfigNames are the configuration files looked up in the sample-shifter folder of
+// the user configuration directory: $XDG_CONFIG_HOME if set, otherwise ~/.config on Linux,
+// ~/Library/Application Support on macOS and %AppData% on Windows
+var UserConfigNames = []string{"config.json", "config.yaml", "config.yml", "config.toml"}
+
+// Origin describes where a configuration was found
+type Origin string
+
+const (
+	OriginFlag    Origin = "--config flag"
+	OriginEnv     Origin = "$" + EnvConfigPath
+	OriginLibrary Origin = "library"
+	OriginUser    Origin = "user config"
+	OriginDefault Origin = "built-in default"
+)
+
+// Location is the configuration file chosen by Discover; Path is empty for the built-in
+// default configuration
+type Location struct {
+	Path   string
+	Origin Origin
+}
+
+// String describes the location for display, e.g. "/lib/.sample-shifter.json (library)"
+func (l Location) String() string {
+	if l.Path == "" {
+		return string(OriginDefault)
+	}
+	return fmt.Sprintf("%s (%s)", l.Path, l.Origin)
+}
+
+// Discover chooses the configuration file to use: the flag value if set, then the file
+// named by $SAMPLE_SHIFTER_CONFIG, then the first LibraryConfigNames file in startDir or
+// its closest ancestor that has one, then a UserConfigNames file in the user configuration
+// directory, and otherwise the built-in default. Files named by the flag or the
+// environment are not checked for existence; LoadConfig reports them.
+func Discover(flagPath, startDir string) (Location, error) {
+	if flagPath != "" {
+		return Location{Path: flagPath, Origin: OriginFlag}, nil
+	}
+	if path := os.Getenv(EnvConfigPath); path != "" {
+		return Location{Path: path, Origin: OriginEnv}, nil
+	}
+
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return Location{}, fmt.Errorf("failed to resolve %s: %w", startDir, err)
+	}
+	for {
+		if path, ok := findFile(dir, LibraryConfigNames); ok {
+			return Location{Path: path, Origin: OriginLibrary}, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	if userDir, err := userConfigDir(); err == nil {
+		if path, ok := findFile(filepath.Join(userDir, "sample-shifter"), UserConfigNames); ok {
+			return Location{Path: path, Origin: OriginUser}, nil
+		}
+	}
+
+	return Location{Origin: OriginDefault}, nil
+}
+
+// userConfigDir returns $XDG_CONFIG_HOME when it is set to an absolute path, on every
+// platform, and otherwise the platform's user configuration directory
+func userConfigDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	return os.UserConfigDir()
+}
+
+// findFile returns the first of the named regular files that exists in dir
+func findFile(dir string, names []string) (string, bool) {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path, true
+		}
+	}
+	return "", false
+}
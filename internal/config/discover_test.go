@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiscover(t *testing.T) {
+	root := t.TempDir()
+	library := filepath.Join(root, "library")
+	source := filepath.Join(library, "packs", "drums")
+	userDir := filepath.Join(root, "xdg")
+	if err := os.MkdirAll(source, 0755); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(userDir, "sample-shifter"), 0755); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+
+	touch := func(path string) {
+		if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+	t.Setenv(EnvConfigPath, "")
+
+	check := func(name, flag string, want Location) {
+		t.Helper()
+		got, err := Discover(flag, source)
+		if err != nil {
+			t.Fatalf("%s: Discover failed: %v", name, err)
+		}
+		if got != want {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+
+	check("nothing found", "", Location{Origin: OriginDefault})
+
+	userConfig := filepath.Join(userDir, "sample-shifter", "config.yaml")
+	touch(userConfig)
+	check("user config", "", Location{Path: userConfig, Origin: OriginUser})
+
+	libraryConfig := filepath.Join(library, ".sample-shifter.json")
+	touch(libraryConfig)
+	check("library dotfile in an ancestor", "", Location{Path: libraryConfig, Origin: OriginLibrary})
+
+	closer := filepath.Join(source, ".sample-shifter.toml")
+	touch(closer)
+	check("closest dotfile wins", "", Location{Path: closer, Origin: OriginLibrary})
+
+	t.Setenv(EnvConfigPath, "env.json")
+	check("environment", "", Location{Path: "env.json", Origin: OriginEnv})
+
+	check("flag", "flag.json", Location{Path: "flag.json", Origin: OriginFlag})
+}
@@ -0,0 +1,535 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// nodeKind is the kind of value a rawNode holds
+type nodeKind int
+
+const (
+	scalarNode nodeKind = iota
+	objectNode
+	arrayNode
+)
+
+// rawNode is a configuration document before decoding. It keeps the order of keys and
+// where each key was written, so that unknown fields can be reported with their position
+// and migrations can rewrite a file without reordering it.
+type rawNode struct {
+	kind  nodeKind
+	keys  []rawKey    // members of an object, in document order
+	items []*rawNode  // elements of an array
+	value interface{} // a scalar: string, bool, nil or a number
+}
+
+// rawKey is an object member; line and column are zero when the format has no positions
+type rawKey struct {
+	name         string
+	line, column int
+	value        *rawNode
+}
+
+// errorf returns an error prefixed with the position of the key when it is known
+func (k rawKey) errorf(format string, args ...interface{}) error {
+	if k.line > 0 {
+		format = "line %d, column %d: " + format
+		args = append([]interface{}{k.line, k.column}, args...)
+	}
+	return fmt.Errorf(format, args...)
+}
+
+// member returns the member of an object with the given name
+func (n *rawNode) member(name string) (rawKey, bool) {
+	for _, key := range n.keys {
+		if key.name == name {
+			return key, true
+		}
+	}
+	return rawKey{}, false
+}
+
+// set replaces the value of a member of an object, or inserts the member at index i
+func (n *rawNode) set(name string, value *rawNode, i int) {
+	for j := range n.keys {
+		if n.keys[j].name == name {
+			n.keys[j].value = value
+			return
+		}
+	}
+	i = min(max(i, 0), len(n.keys))
+	n.keys = append(n.keys[:i], append([]rawKey{{name: name, value: value}}, n.keys[i:]...)...)
+}
+
+// intValue returns the value of a scalar holding a whole number
+func (n *rawNode) intValue() (int, bool) {
+	if n == nil || n.kind != scalarNode {
+		return 0, false
+	}
+	switch v := n.value.(type) {
+	case json.Number:
+		i, err := strconv.Atoi(string(v))
+		return i, err == nil
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case uint64:
+		return int(v), true
+	}
+	return 0, false
+}
+
+// intNode returns a scalar holding a whole number
+func intNode(i int) *rawNode {
+	return &rawNode{kind: scalarNode, value: json.Number(strconv.Itoa(i))}
+}
+
+// readDocument parses a configuration file of the given format into a rawNode tree;
+// converted is the document as converted by toJSON
+func readDocument(data, converted []byte, format Format) (*rawNode, error) {
+	switch format {
+	case FormatYAML:
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		if len(doc.Content) == 0 {
+			return &rawNode{kind: objectNode}, nil
+		}
+		return yamlTree(doc.Content[0])
+	case FormatTOML:
+		// TOML documents are read after conversion to JSON, with the positions of the keys
+		// in the TOML source
+		root, err := jsonTree(converted)
+		if err != nil {
+			return nil, err
+		}
+		setPositions(root, nil, data, tomlKeyOffsets(data))
+		return root, nil
+	}
+
+	// Report syntax errors the way the decoder does, with the offending character
+	if err := json.Unmarshal(data, new(interface{})); err != nil {
+		return nil, jsonError(data, err)
+	}
+	return jsonTree(data)
+}
+
+// jsonTree parses a JSON document into a rawNode tree with the position of every key
+func jsonTree(data []byte) (*rawNode, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var read func() (*rawNode, error)
+	read = func() (*rawNode, error) {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch tok {
+		case json.Delim('{'):
+			node := &rawNode{kind: objectNode}
+			for dec.More() {
+				start := keyStart(data, dec.InputOffset())
+				tok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := read()
+				if err != nil {
+					return nil, err
+				}
+				line, column := position(data, start)
+				node.keys = append(node.keys, rawKey{name: tok.(string), line: line, column: column, value: value})
+			}
+			_, err = dec.Token()
+			return node, err
+		case json.Delim('['):
+			node := &rawNode{kind: arrayNode}
+			for dec.More() {
+				item, err := read()
+				if err != nil {
+					return nil, err
+				}
+				node.items = append(node.items, item)
+			}
+			_, err = dec.Token()
+			return node, err
+		}
+		return &rawNode{kind: scalarNode, value: tok}, nil
+	}
+
+	root, err := read()
+	if err == io.EOF {
+		return &rawNode{kind: objectNode}, nil
+	}
+	return root, err
+}
+
+// keyStart skips the separators between the end of the previous token and an object key
+func keyStart(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) && strings.IndexByte(" \t\r\n,", data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+// position converts a byte offset into a 1-based line and column
+func position(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// yamlTree converts a YAML node into a rawNode tree
+func yamlTree(node *yaml.Node) (*rawNode, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return yamlTree(node.Alias)
+	case yaml.MappingNode:
+		raw := &rawNode{kind: objectNode}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			value, err := yamlTree(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			raw.keys = append(raw.keys, rawKey{name: key.Value, line: key.Line, column: key.Column, value: value})
+		}
+		return raw, nil
+	case yaml.SequenceNode:
+		raw := &rawNode{kind: arrayNode}
+		for _, item := range node.Content {
+			value, err := yamlTree(item)
+			if err != nil {
+				return nil, err
+			}
+			raw.items = append(raw.items, value)
+		}
+		return raw, nil
+	}
+
+	raw := &rawNode{kind: scalarNode}
+	if err := node.Decode(&raw.value); err != nil {
+		return nil, fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	return raw, nil
+}
+
+// setPositions sets the position of every key of a tree from the byte offsets of the keys
+// in data, by path
+func setPositions(node *rawNode, path []string, data []byte, offsets map[string]int) {
+	for i := range node.keys {
+		keyPath := append(path[:len(path):len(path)], node.keys[i].name)
+		if offset, ok := offsets[pathKey(keyPath)]; ok {
+			node.keys[i].line, node.keys[i].column = position(data, int64(offset))
+		} else {
+			node.keys[i].line, node.keys[i].column = 0, 0
+		}
+		setPositions(node.keys[i].value, keyPath, data, offsets)
+	}
+	for i, item := range node.items {
+		setPositions(item, append(path[:len(path):len(path)], strconv.Itoa(i)), data, offsets)
+	}
+}
+
+// pathKey joins the path of a key, including the index of array elements
+func pathKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
+
+// encodeDocument writes a rawNode tree in the given format: JSON indented like
+// "config dump-default", YAML with keyword lists on one line, or TOML with categories as
+// arrays of tables
+func encodeDocument(root *rawNode, format Format) ([]byte, error) {
+	switch format {
+	case FormatYAML:
+		node, err := root.yamlNode()
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(node); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case FormatTOML:
+		var buf bytes.Buffer
+		if err := writeTOMLTable(&buf, root, nil, false); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	compact, err := root.json()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, compact, "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// json returns the tree as compact JSON
+func (n *rawNode) json() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := n.writeJSON(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (n *rawNode) writeJSON(buf *bytes.Buffer) error {
+	switch n.kind {
+	case objectNode:
+		buf.WriteByte('{')
+		for i, key := range n.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONScalar(buf, key.name); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := key.value.writeJSON(buf); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case arrayNode:
+		buf.WriteByte('[')
+		for i, item := range n.items {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := item.writeJSON(buf); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	}
+	return writeJSONScalar(buf, n.value)
+}
+
+// writeJSONScalar writes a scalar as JSON without escaping HTML characters, which are
+// common in keywords such as "drum & bass"
+func writeJSONScalar(buf *bytes.Buffer, value interface{}) error {
+	var scalar bytes.Buffer
+	enc := json.NewEncoder(&scalar)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return err
+	}
+	buf.Write(bytes.TrimSuffix(scalar.Bytes(), []byte("\n")))
+	return nil
+}
+
+// yamlNode converts the tree to a YAML node
+func (n *rawNode) yamlNode() (*yaml.Node, error) {
+	switch n.kind {
+	case objectNode:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, key := range n.keys {
+			value, err := key.value.yamlNode()
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.name}, value)
+		}
+		return node, nil
+	case arrayNode:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for _, item := range n.items {
+			if item.kind != scalarNode {
+				node.Style = 0
+			}
+			value, err := item.yamlNode()
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+		return node, nil
+	}
+
+	value := n.value
+	if number, ok := value.(json.Number); ok {
+		if i, err := number.Int64(); err == nil {
+			value = i
+		} else if value, err = number.Float64(); err != nil {
+			return nil, err
+		}
+	}
+	node := &yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// isTOMLTable reports whether a value is written as a [table] or an [[array of tables]]
+func (n *rawNode) isTOMLTable() bool {
+	if n.kind == objectNode {
+		return true
+	}
+	if n.kind != arrayNode || len(n.items) == 0 {
+		return false
+	}
+	for _, item := range n.items {
+		if item.kind != objectNode {
+			return false
+		}
+	}
+	return true
+}
+
+// writeTOMLTable writes the members of a TOML table, path being the keys of its header.
+// Values come before tables in TOML, so tables follow the other members; where the order
+// of members matters (subcategories), tables listed before other members are written
+// inline instead.
+func writeTOMLTable(buf *bytes.Buffer, table *rawNode, path []string, keepOrder bool) error {
+	lastValue := -1
+	for i, key := range table.keys {
+		if !key.value.isTOMLTable() {
+			lastValue = i
+		}
+	}
+
+	var tables []rawKey
+	for i, key := range table.keys {
+		if key.value.kind == scalarNode && key.value.value == nil {
+			continue
+		}
+		if key.value.isTOMLTable() && (!keepOrder || i > lastValue) {
+			tables = append(tables, key)
+			continue
+		}
+		buf.WriteString(tomlKey(key.name))
+		buf.WriteString(" = ")
+		if err := writeTOMLInline(buf, key.value); err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(append(path, key.name), "."), err)
+		}
+		buf.WriteByte('\n')
+	}
+
+	for _, key := range tables {
+		header := append(append([]string(nil), path...), key.name)
+		quoted := make([]string, len(header))
+		for i, name := range header {
+			quoted[i] = tomlKey(name)
+		}
+
+		items, open, close := []*rawNode{key.value}, "[", "]"
+		if key.value.kind == arrayNode {
+			items, open, close = key.value.items, "[[", "]]"
+		}
+		for _, item := range items {
+			// Tables holding only tables are defined by their headers
+			if open == "[" && onlyTables(item) {
+				if err := writeTOMLTable(buf, item, header, key.name == "subcategories"); err != nil {
+					return err
+				}
+				continue
+			}
+			if buf.Len() > 0 {
+				buf.WriteByte('\n')
+			}
+			buf.WriteString(open + strings.Join(quoted, ".") + close + "\n")
+			if err := writeTOMLTable(buf, item, header, key.name == "subcategories"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// onlyTables reports whether every member of a non-empty table is itself written as a table
+func onlyTables(table *rawNode) bool {
+	for _, key := range table.keys {
+		if !key.value.isTOMLTable() {
+			return false
+		}
+	}
+	return len(table.keys) > 0
+}
+
+// writeTOMLInline writes a value on one line: arrays as [...] and tables as { ... }
+func writeTOMLInline(buf *bytes.Buffer, n *rawNode) error {
+	switch n.kind {
+	case objectNode:
+		if len(n.keys) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{ ")
+		written := 0
+		for _, key := range n.keys {
+			if key.value.kind == scalarNode && key.value.value == nil {
+				continue
+			}
+			if written > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(tomlKey(key.name) + " = ")
+			if err := writeTOMLInline(buf, key.value); err != nil {
+				return err
+			}
+			written++
+		}
+		buf.WriteString(" }")
+		return nil
+	case arrayNode:
+		buf.WriteByte('[')
+		for i, item := range n.items {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			if err := writeTOMLInline(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	}
+
+	if n.value == nil {
+		return fmt.Errorf("null can't be written in TOML")
+	}
+	// JSON strings, numbers and booleans are valid TOML values
+	return writeJSONScalar(buf, n.value)
+}
+
+// bareTOMLKey matches the keys TOML allows without quotes
+var bareTOMLKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func tomlKey(name string) string {
+	if bareTOMLKey.MatchString(name) {
+		return name
+	}
+	var buf bytes.Buffer
+	writeJSONScalar(&buf, name)
+	return buf.String()
+}
@@ -0,0 +1,341 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultExtends is the value of "extends" that layers a configuration on the built-in
+// default configuration
+const DefaultExtends = "default"
+
+// resolveConfig reads a configuration file and merges it onto the configurations it
+// extends, following "extends" until a file extends nothing; chain holds the files
+// already being resolved, to detect cycles
+func resolveConfig(configPath string, chain []string) (*CategoryConfig, error) {
+	layer, err := ReadConfig(configPath)
+	if err != nil {
+		if len(chain) > 0 {
+			return nil, fmt.Errorf("%s: %w", configPath, err)
+		}
+		return nil, err
+	}
+
+	if layer.Extends == "" {
+		if layer.hasRemovals() {
+			return nil, fmt.Errorf("%s: remove_categories, remove_keywords, remove_exclude_keywords and remove_subcategories need \"extends\"", configPath)
+		}
+		return layer, nil
+	}
+
+	var base *CategoryConfig
+	if layer.Extends == DefaultExtends {
+		base = GetDefaultConfig()
+	} else {
+		basePath := layer.Extends
+		if !filepath.IsAbs(basePath) {
+			basePath = filepath.Join(filepath.Dir(configPath), basePath)
+		}
+
+		abs, err := filepath.Abs(configPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", configPath, err)
+		}
+		chain = append(chain, abs)
+		if absBase, err := filepath.Abs(basePath); err == nil {
+			for _, seen := range chain {
+				if seen == absBase {
+					return nil, fmt.Errorf("%s extends %s, which extends it again", configPath, layer.Extends)
+				}
+			}
+		}
+
+		base, err = resolveConfig(basePath, chain)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	merged, err := Merge(base, layer)
+	if err != nil {
+		return nil, fmt.Errorf("%s extends %s: %w", configPath, layer.Extends, err)
+	}
+	return merged, nil
+}
+
+// hasRemovals reports whether the configuration removes anything from an extended one
+func (c *CategoryConfig) hasRemovals() bool {
+	if len(c.RemoveCategories) > 0 {
+		return true
+	}
+	removes := false
+	for _, cat := range c.Categories {
+		removes = removes || cat.hasRemovals()
+		cat.Subcategories.Walk(func(_ string, sub SubcategoryDefinition) {
+			removes = removes || sub.hasRemovals()
+		})
+	}
+	return removes
+}
+
+// hasRemovals reports whether the category removes anything from an extended one
+func (c CategoryDefinition) hasRemovals() bool {
+	return len(c.RemoveKeywords) > 0 || len(c.RemoveExcludeKeywords) > 0 || len(c.RemoveSubcategories) > 0
+}
+
+// hasRemovals reports whether the subcategory removes anything from an extended one
+func (s SubcategoryDefinition) hasRemovals() bool {
+	return len(s.RemoveKeywords) > 0 || len(s.RemoveExcludeKeywords) > 0
+}
+
+// Merge layers a configuration on a base configuration and returns the result; neither
+// argument is modified.
+//
+// Settings the layer sets (layout, confidence_threshold, path_evidence) replace those of
+// the base; path_evidence and bar_aligned can be set to false to turn them off again.
+// Categories listed in remove_categories are dropped. A layer category with the name of a
+// base category is merged into it: set fields replace the base values, keywords,
+// exclude_keywords and subcategories are added, and remove_keywords,
+// remove_exclude_keywords and remove_subcategories drop base entries. Subcategories merge
+// the same way by name, at any depth. Other layer categories are added after the base
+// categories.
+func Merge(base, layer *CategoryConfig) (*CategoryConfig, error) {
+	merged := &CategoryConfig{
+		Version:             CurrentVersion,
+		Layout:              base.Layout,
+		PathEvidence:        base.PathEvidence,
+		ConfidenceThreshold: base.ConfidenceThreshold,
+		migrated:            append(append([]string(nil), base.migrated...), layer.migrated...),
+	}
+	if layer.Layout != "" {
+		merged.Layout = layer.Layout
+	}
+	if layer.PathEvidence != nil {
+		merged.PathEvidence = Bool(*layer.PathEvidence)
+	}
+	if layer.ConfidenceThreshold != 0 {
+		merged.ConfidenceThreshold = layer.ConfidenceThreshold
+	}
+
+	for _, name := range layer.RemoveCategories {
+		if !hasCategory(base.Categories, name) {
+			return nil, fmt.Errorf("remove_categories: %q is not a category of the extended configuration", name)
+		}
+	}
+
+	for _, cat := range base.Categories {
+		if contains(layer.RemoveCategories, cat.Name) {
+			continue
+		}
+		merged.Categories = append(merged.Categories, copyCategory(cat))
+	}
+
+	for _, cat := range layer.Categories {
+		i := categoryIndex(merged.Categories, cat.Name)
+		if i < 0 {
+			if cat.hasRemovals() {
+				return nil, fmt.Errorf("category %s is not in the extended configuration, so it has nothing to remove", cat.Name)
+			}
+			merged.Categories = append(merged.Categories, copyCategory(cat))
+			continue
+		}
+		if err := mergeCategory(&merged.Categories[i], cat); err != nil {
+			return nil, fmt.Errorf("category %s: %w", cat.Name, err)
+		}
+	}
+
+	return merged, nil
+}
+
+// mergeCategory merges a layer category into a copy of the base category
+func mergeCategory(cat *CategoryDefinition, layer CategoryDefinition) error {
+	if layer.Priority != 0 {
+		cat.Priority = layer.Priority
+	}
+	if layer.MinDuration != 0 {
+		cat.MinDuration = layer.MinDuration
+	}
+	if layer.MaxDuration != 0 {
+		cat.MaxDuration = layer.MaxDuration
+	}
+	if layer.BarAligned != nil {
+		cat.BarAligned = Bool(*layer.BarAligned)
+	}
+	if layer.Weight != 0 {
+		cat.Weight = layer.Weight
+	}
+	if layer.MatchSource != "" {
+		cat.MatchSource = layer.MatchSource
+	}
+
+	keywords, err := mergeKeywords(cat.Keywords, layer.Keywords, layer.RemoveKeywords, "remove_keywords")
+	if err != nil {
+		return err
+	}
+	cat.Keywords = keywords
+	excludes, err := mergeKeywords(cat.ExcludeKeywords, layer.ExcludeKeywords, layer.RemoveExcludeKeywords, "remove_exclude_keywords")
+	if err != nil {
+		return err
+	}
+	cat.ExcludeKeywords = excludes
+
+	for _, path := range layer.RemoveSubcategories {
+		subcategories, ok := removeSubcategory(cat.Subcategories, path)
+		if !ok {
+			return fmt.Errorf("remove_subcategories: %q is not a subcategory of the extended configuration", path)
+		}
+		cat.Subcategories = subcategories
+	}
+
+	subcategories, err := mergeSubcategories(cat.Subcategories, layer.Subcategories, "")
+	if err != nil {
+		return err
+	}
+	cat.Subcategories = subcategories
+	return nil
+}
+
+// mergeSubcategories merges layer subcategories into base subcategories by name; prefix is
+// the path of the parent subcategory
+func mergeSubcategories(base, layer Subcategories, prefix string) (Subcategories, error) {
+	merged := base
+	for _, sub := range layer {
+		i := -1
+		for j := range merged {
+			if merged[j].Name == sub.Name {
+				i = j
+				break
+			}
+		}
+
+		if i < 0 {
+			if sub.hasRemovals() {
+				return nil, fmt.Errorf("subcategory %s%s is not in the extended configuration, so it has nothing to remove", prefix, sub.Name)
+			}
+			merged = append(merged, copySubcategories(Subcategories{sub})...)
+			continue
+		}
+
+		keywords, err := mergeKeywords(merged[i].Keywords, sub.Keywords, sub.RemoveKeywords, "remove_keywords")
+		if err != nil {
+			return nil, fmt.Errorf("subcategory %s%s: %w", prefix, sub.Name, err)
+		}
+		merged[i].Keywords = keywords
+		excludes, err := mergeKeywords(merged[i].ExcludeKeywords, sub.ExcludeKeywords, sub.RemoveExcludeKeywords, "remove_exclude_keywords")
+		if err != nil {
+			return nil, fmt.Errorf("subcategory %s%s: %w", prefix, sub.Name, err)
+		}
+		merged[i].ExcludeKeywords = excludes
+		if sub.Priority != 0 {
+			merged[i].Priority = sub.Priority
+		}
+
+		children, err := mergeSubcategories(merged[i].Subcategories, sub.Subcategories, prefix+sub.Name+"/")
+		if err != nil {
+			return nil, err
+		}
+		merged[i].Subcategories = children
+	}
+	return merged, nil
+}
+
+// mergeKeywords removes keywords from a copy of base and adds the new keywords it doesn't
+// have yet. Keywords compare case-insensitively; removing a missing keyword is an error
+// naming the removal field.
+func mergeKeywords(base, add, remove []string, field string) ([]string, error) {
+	var merged []string
+	for _, kw := range base {
+		if !containsFold(remove, kw) {
+			merged = append(merged, kw)
+		}
+	}
+	for _, kw := range remove {
+		if !containsFold(base, kw) {
+			return nil, fmt.Errorf("%s: %q is not in the extended configuration", field, kw)
+		}
+	}
+	for _, kw := range add {
+		if !containsFold(merged, kw) {
+			merged = append(merged, kw)
+		}
+	}
+	return merged, nil
+}
+
+// removeSubcategory returns the subcategories without the one at path, e.g. "cymbal/crash"
+func removeSubcategory(subcategories Subcategories, path string) (Subcategories, bool) {
+	first, rest, nested := strings.Cut(path, "/")
+	for i, sub := range subcategories {
+		if sub.Name != first {
+			continue
+		}
+		if !nested {
+			return append(subcategories[:i:i], subcategories[i+1:]...), true
+		}
+		children, ok := removeSubcategory(sub.Subcategories, rest)
+		if !ok {
+			return nil, false
+		}
+		result := append(Subcategories(nil), subcategories...)
+		result[i].Subcategories = children
+		return result, true
+	}
+	return nil, false
+}
+
+// copyCategory copies a category deeply enough that merging into the copy leaves the
+// original untouched
+func copyCategory(cat CategoryDefinition) CategoryDefinition {
+	cat.Keywords = append([]string(nil), cat.Keywords...)
+	cat.ExcludeKeywords = append([]string(nil), cat.ExcludeKeywords...)
+	cat.Subcategories = copySubcategories(cat.Subcategories)
+	return cat
+}
+
+func copySubcategories(subcategories Subcategories) Subcategories {
+	if subcategories == nil {
+		return nil
+	}
+	copied := make(Subcategories, len(subcategories))
+	for i, sub := range subcategories {
+		sub.Keywords = append([]string(nil), sub.Keywords...)
+		sub.ExcludeKeywords = append([]string(nil), sub.ExcludeKeywords...)
+		sub.RemoveKeywords = nil
+		sub.RemoveExcludeKeywords = nil
+		sub.Subcategories = copySubcategories(sub.Subcategories)
+		copied[i] = sub
+	}
+	return copied
+}
+
+func hasCategory(categories []CategoryDefinition, name string) bool {
+	return categoryIndex(categories, name) >= 0
+}
+
+func categoryIndex(categories []CategoryDefinition, name string) int {
+	for i, cat := range categories {
+		if cat.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mergeBase() *CategoryConfig {
+	return &CategoryConfig{
+		Layout: "{category}/{subcategory}/{filename}",
+		Categories: []CategoryDefinition{
+			{Name: "drums", Priority: 1, Keywords: []string{"kick", "snare", "tom"}, Subcategories: Subcategories{
+				{Name: "kick", Keywords: []string{"kick"}},
+				{Name: "cymbal", Keywords: []string{"cymbal"}, Subcategories: Subcategories{
+					{Name: "crash", Keywords: []string{"crash"}},
+					{Name: "ride", Keywords: []string{"ride"}},
+				}},
+			}},
+			{Name: "bass", Priority: 2, Keywords: []string{"bass"}},
+			{Name: "fx", Priority: 3, Keywords: []string{"fx"}},
+		},
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := mergeBase()
+	layer := &CategoryConfig{
+		ConfidenceThreshold: 0.8,
+		RemoveCategories:    []string{"fx"},
+		Categories: []CategoryDefinition{
+			{
+				Name:                "drums",
+				Keywords:            []string{"rimclick", "Kick"},
+				RemoveKeywords:      []string{"tom"},
+				RemoveSubcategories: []string{"cymbal/ride"},
+				Subcategories: Subcategories{
+					{Name: "kick", Keywords: []string{"bd"}, Priority: 1},
+					{Name: "cymbal", Subcategories: Subcategories{{Name: "china", Keywords: []string{"china"}}}},
+					{Name: "rim", Keywords: []string{"rimclick"}},
+				},
+			},
+			{Name: "bass", Weight: 0.5},
+			{Name: "foley", Priority: 4, Keywords: []string{"foley"}},
+		},
+	}
+
+	merged, err := Merge(base, layer)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	if merged.Layout != base.Layout || merged.ConfidenceThreshold != 0.8 {
+		t.Errorf("Expected base layout and layer threshold, got %q and %g", merged.Layout, merged.ConfidenceThreshold)
+	}
+
+	var names []string
+	for _, cat := range merged.Categories {
+		names = append(names, cat.Name)
+	}
+	if want := []string{"drums", "bass", "foley"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected categories %v, got %v", want, names)
+	}
+
+	drums := merged.Categories[0]
+	if want := []string{"kick", "snare", "rimclick"}; !reflect.DeepEqual(drums.Keywords, want) {
+		t.Errorf("Expected keywords %v, got %v", want, drums.Keywords)
+	}
+	if drums.Priority != 1 {
+		t.Errorf("Expected the base priority to be kept, got %d", drums.Priority)
+	}
+
+	var paths []string
+	drums.Subcategories.Walk(func(path string, sub SubcategoryDefinition) {
+		paths = append(paths, path)
+	})
+	if want := []string{"kick", "cymbal", "cymbal/crash", "cymbal/china", "rim"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("Expected subcategories %v, got %v", want, paths)
+	}
+	kick, _ := drums.Subcategories.Get("kick")
+	if !reflect.DeepEqual(kick.Keywords, []string{"kick", "bd"}) || kick.Priority != 1 {
+		t.Errorf("Expected merged kick subcategory, got %+v", kick)
+	}
+
+	if merged.Categories[1].Weight != 0.5 || !reflect.DeepEqual(merged.Categories[1].Keywords, []string{"bass"}) {
+		t.Errorf("Expected bass with the layer weight and base keywords, got %+v", merged.Categories[1])
+	}
+
+	// The base is left untouched
+	if !reflect.DeepEqual(base, mergeBase()) {
+		t.Error("Merge should not modify the base configuration")
+	}
+}
+
+func TestMergeSwitchesAndExclusions(t *testing.T) {
+	base := &CategoryConfig{
+		PathEvidence: Bool(true),
+		Categories: []CategoryDefinition{
+			{Name: "loops", Priority: 1, Keywords: []string{"loop"}, BarAligned: Bool(true), ExcludeKeywords: []string{"loop fx", "fill"},
+				Subcategories: Subcategories{{Name: "drum", Keywords: []string{"drum"}, ExcludeKeywords: []string{"drum fill"}}}},
+		},
+	}
+
+	// A layer that leaves the switches unset keeps the base values
+	merged, err := Merge(base, &CategoryConfig{Categories: []CategoryDefinition{{Name: "loops", Keywords: []string{"groove"}}}})
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if !merged.UsesPathEvidence() || !merged.Categories[0].RequiresBarAligned() {
+		t.Error("Unset switches should keep the base values")
+	}
+
+	layer := &CategoryConfig{
+		PathEvidence: Bool(false),
+		Categories: []CategoryDefinition{
+			{Name: "loops", BarAligned: Bool(false), ExcludeKeywords: []string{"one shot"}, RemoveExcludeKeywords: []string{"Fill"},
+				Subcategories: Subcategories{{Name: "drum", RemoveExcludeKeywords: []string{"drum fill"}}}},
+		},
+	}
+	merged, err = Merge(base, layer)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if merged.UsesPathEvidence() {
+		t.Error("Expected the layer to turn path_evidence off")
+	}
+
+	loops := merged.Categories[0]
+	if loops.RequiresBarAligned() {
+		t.Error("Expected the layer to turn bar_aligned off")
+	}
+	if want := []string{"loop fx", "one shot"}; !reflect.DeepEqual(loops.ExcludeKeywords, want) {
+		t.Errorf("Expected exclusions %v, got %v", want, loops.ExcludeKeywords)
+	}
+	if drum, _ := loops.Subcategories.Get("drum"); len(drum.ExcludeKeywords) != 0 {
+		t.Errorf("Expected the subcategory exclusion to be removed, got %v", drum.ExcludeKeywords)
+	}
+	if !base.UsesPathEvidence() || !base.Categories[0].RequiresBarAligned() {
+		t.Error("Merge should not modify the base configuration")
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		layer *CategoryConfig
+	}{
+		{"unknown category removed", &CategoryConfig{RemoveCategories: []string{"vocals"}}},
+		{"unknown keyword removed", &CategoryConfig{Categories: []CategoryDefinition{
+			{Name: "drums", RemoveKeywords: []string{"clap"}},
+		}}},
+		{"unknown subcategory removed", &CategoryConfig{Categories: []CategoryDefinition{
+			{Name: "drums", RemoveSubcategories: []string{"cymbal/china"}},
+		}}},
+		{"removal from a new category", &CategoryConfig{Categories: []CategoryDefinition{
+			{Name: "foley", Keywords: []string{"foley"}, RemoveKeywords: []string{"fx"}},
+		}}},
+		{"unknown subcategory keyword removed", &CategoryConfig{Categories: []CategoryDefinition{
+			{Name: "drums", Subcategories: Subcategories{{Name: "kick", RemoveKeywords: []string{"bd"}}}},
+		}}},
+		{"unknown exclude keyword removed", &CategoryConfig{Categories: []CategoryDefinition{
+			{Name: "drums", RemoveExcludeKeywords: []string{"top hat"}},
+		}}},
+		{"exclusion removed from a new subcategory", &CategoryConfig{Categories: []CategoryDefinition{
+			{Name: "drums", Subcategories: Subcategories{{Name: "perc", RemoveExcludeKeywords: []string{"shaker"}}}},
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Merge(mergeBase(), tt.layer); err == nil {
+				t.Error("Merge should fail")
+			}
+		})
+	}
+}
+
+func TestLoadConfigExtends(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test config: %v", err)
+		}
+		return path
+	}
+
+	write("base.json", `{"categories": [{"name": "drums", "priority": 1, "keywords": ["kick"]}]}`)
+	write("team/team.yaml", "extends: ../base.json\ncategories:\n  - name: drums\n    keywords: [snare]\n")
+	user := write("user.toml", "extends = \"team/team.yaml\"\nlayout = \"{category}/{filename}\"\n")
+
+	config, err := LoadConfig(user)
+	if err != nil {
+		t.Fatalf("LoadConfig should not error: %v", err)
+	}
+	if config.Extends != "" || config.Layout != "{category}/{filename}" {
+		t.Errorf("Expected a resolved config with the user layout, got %+v", config)
+	}
+	if want := []string{"kick", "snare"}; !reflect.DeepEqual(config.Categories[0].Keywords, want) {
+		t.Errorf("Expected keywords %v, got %v", want, config.Categories[0].Keywords)
+	}
+
+	onDefault := write("default.json", `{"extends": "default", "remove_categories": ["loops"]}`)
+	config, err = LoadConfig(onDefault)
+	if err != nil {
+		t.Fatalf("LoadConfig should not error: %v", err)
+	}
+	if len(config.Categories) != len(GetDefaultConfig().Categories)-1 {
+		t.Errorf("Expected the default categories without loops, got %d", len(config.Categories))
+	}
+
+	for name, content := range map[string]string{
+		"cycle.json":   `{"extends": "cycle2.json", "categories": []}`,
+		"cycle2.json":  `{"extends": "cycle.json", "categories": []}`,
+		"missing.json": `{"extends": "nonexistent.json", "categories": []}`,
+		"orphan.json":  `{"remove_categories": ["drums"], "categories": [{"name": "bass", "priority": 1, "keywords": ["bass"]}]}`,
+	} {
+		write(name, content)
+	}
+	for _, name := range []string{"cycle.json", "missing.json", "orphan.json"} {
+		if _, err := LoadConfig(filepath.Join(dir, name)); err == nil {
+			t.Errorf("LoadConfig should fail for %s", name)
+		} else if name == "cycle.json" && !strings.Contains(err.Error(), "extends it again") {
+			t.Errorf("Expected a cycle error, got: %v", err)
+		}
+	}
+}
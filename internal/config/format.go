@@ -0,0 +1,451 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is a configuration file format
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatTOML Format = "toml"
+)
+
+// FormatOf returns the format of a configuration file from its extension; files with
+// other extensions are read as JSON
+func FormatOf(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	}
+	return FormatJSON
+}
+
+// toJSON converts a YAML or TOML document to JSON so that every format is decoded by the
+// same JSON codecs. The order of keys is kept, which decides the order of subcategories.
+func toJSON(data []byte, format Format) ([]byte, error) {
+	switch format {
+	case FormatYAML:
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if len(doc.Content) == 0 {
+			buf.WriteString("{}")
+		} else if err := writeYAMLNode(&buf, doc.Content[0]); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case FormatTOML:
+		var doc map[string]interface{}
+		md, err := toml.Decode(string(data), &doc)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := writeTOMLValue(&buf, doc, "", tomlKeyOrder(md)); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return data, nil
+}
+
+// writeYAMLNode writes a YAML node as JSON, keeping the order of mapping keys
+func writeYAMLNode(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return writeYAMLNode(buf, node.Alias)
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := json.Marshal(node.Content[i].Value)
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeYAMLNode(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeYAMLNode(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	}
+
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	buf.Write(data)
+	return nil
+}
+
+// tomlKeyOrder returns the keys of every table in the order they appear in the document,
+// by the path of the table. Paths include the index of each array-of-tables element, e.g.
+// "categories.0.subcategories".
+func tomlKeyOrder(md toml.MetaData) map[string][]string {
+	order := make(map[string][]string)
+	seen := make(map[string]bool)
+	elements := make(map[string]int)
+
+	for _, key := range md.Keys() {
+		var parent []string
+		for i, part := range key {
+			last := i == len(key)-1
+			if last {
+				path := strings.Join(append(parent, part), ".")
+				if !seen[path] {
+					seen[path] = true
+					parentPath := strings.Join(parent, ".")
+					order[parentPath] = append(order[parentPath], part)
+				}
+			}
+			parent = append(parent, part)
+
+			if md.Type(key[:i+1]...) == "ArrayHash" {
+				plain := strings.Join(key[:i+1], ".")
+				if last {
+					elements[plain]++
+				}
+				parent = append(parent, strconv.Itoa(elements[plain]-1))
+			}
+		}
+	}
+	return order
+}
+
+// writeTOMLValue writes a decoded TOML value as JSON; path is the path of the value for
+// looking up the order of its keys
+func writeTOMLValue(buf *bytes.Buffer, value interface{}, path string, order map[string][]string) error {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		buf.WriteByte('{')
+		for i, key := range orderedKeys(v, order[path]) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			name, err := json.Marshal(key)
+			if err != nil {
+				return err
+			}
+			buf.Write(name)
+			buf.WriteByte(':')
+			if err := writeTOMLValue(buf, v[key], join(key), order); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case []map[string]interface{}:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeTOMLValue(buf, item, join(strconv.Itoa(i)), order); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeTOMLValue(buf, item, join(strconv.Itoa(i)), order); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
+
+// orderedKeys returns the keys of a table in document order; keys the document order
+// doesn't know, such as those of inline tables inside arrays, follow sorted by name
+func orderedKeys(table map[string]interface{}, known []string) []string {
+	keys := make([]string, 0, len(table))
+	listed := make(map[string]bool)
+	for _, key := range known {
+		if _, ok := table[key]; ok && !listed[key] {
+			keys = append(keys, key)
+			listed[key] = true
+		}
+	}
+
+	var rest []string
+	for key := range table {
+		if !listed[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// tomlKeyOffsets returns the byte offset of every key of a TOML document, by the path of
+// the key as pathKey joins it, with the index of each array element. The TOML decoder keeps
+// the positions of keys to itself and without the index of array-of-tables elements, so
+// they are found by scanning the document, which the decoder has already accepted.
+func tomlKeyOffsets(data []byte) map[string]int {
+	return scanTOML(data).offsets
+}
+
+// scanTOML finds the keys and comments of a TOML document
+func scanTOML(data []byte) *tomlScanner {
+	s := &tomlScanner{data: data, offsets: make(map[string]int), tables: make(map[string]int)}
+	var table []string
+	for s.skip(true); s.i < len(data); s.skip(true) {
+		if data[s.i] != '[' {
+			s.keyValue(table)
+			continue
+		}
+		s.i++
+		array := s.peek('[')
+		if array {
+			s.i++
+		}
+		names, offsets := s.keys()
+		table = nil
+		for i, name := range names {
+			table = append(table, name)
+			s.record(table, offsets[i])
+			if array && i == len(names)-1 {
+				s.tables[pathKey(table)]++
+			}
+			// A header continues the last element of the arrays of tables it names
+			if n := s.tables[pathKey(table)]; n > 0 {
+				table = append(table, strconv.Itoa(n-1))
+			}
+		}
+		for s.i < len(s.data) && s.data[s.i] != '\n' && s.data[s.i] != '#' {
+			s.i++
+		}
+	}
+	return s
+}
+
+// tomlScanner finds the keys of a TOML document; it only tells keys from values, as the
+// decoder has already checked the syntax
+type tomlScanner struct {
+	data     []byte
+	i        int
+	offsets  map[string]int // offset of each key by path, for the first time it appears
+	tables   map[string]int // number of elements of each array of tables by path
+	comments bool           // whether the document has a comment
+}
+
+func (s *tomlScanner) peek(c byte) bool {
+	return s.i < len(s.data) && s.data[s.i] == c
+}
+
+func (s *tomlScanner) record(path []string, offset int) {
+	if _, ok := s.offsets[pathKey(path)]; !ok {
+		s.offsets[pathKey(path)] = offset
+	}
+}
+
+// skip skips spaces and, when lines is set, line breaks and comments
+func (s *tomlScanner) skip(lines bool) {
+	for s.i < len(s.data) {
+		switch s.data[s.i] {
+		case ' ', '\t':
+		case '\r', '\n':
+			if !lines {
+				return
+			}
+		case '#':
+			if !lines {
+				return
+			}
+			s.comments = true
+			s.skipLine()
+			continue
+		default:
+			return
+		}
+		s.i++
+	}
+}
+
+func (s *tomlScanner) skipLine() {
+	for s.i < len(s.data) && s.data[s.i] != '\n' {
+		s.i++
+	}
+}
+
+// keys reads a dotted key, returning its parts and where each starts
+func (s *tomlScanner) keys() ([]string, []int) {
+	var names []string
+	var offsets []int
+	for {
+		s.skip(false)
+		start := s.i
+		var name string
+		switch {
+		case s.peek('"'):
+			raw := s.str()
+			var err error
+			if name, err = strconv.Unquote(raw); err != nil {
+				name = strings.Trim(raw, `"`)
+			}
+		case s.peek('\''):
+			name = strings.Trim(s.str(), "'")
+		default:
+			for s.i < len(s.data) && bareTOMLKey.Match(s.data[s.i:s.i+1]) {
+				s.i++
+			}
+			name = string(s.data[start:s.i])
+		}
+		names = append(names, name)
+		offsets = append(offsets, start)
+
+		s.skip(false)
+		if !s.peek('.') {
+			return names, offsets
+		}
+		s.i++
+	}
+}
+
+// keyValue reads a key and its value, in the table at the given path
+func (s *tomlScanner) keyValue(table []string) {
+	start := s.i
+	defer func() {
+		// Never stall on input the decoder would have rejected
+		if s.i == start {
+			s.i++
+		}
+	}()
+
+	names, offsets := s.keys()
+	path := table[:len(table):len(table)]
+	for i, name := range names {
+		path = append(path, name)
+		s.record(path, offsets[i])
+	}
+	s.skip(false)
+	if s.peek('=') {
+		s.i++
+	}
+	s.skip(false)
+	s.value(path)
+}
+
+// value reads a value, recording the keys of inline tables
+func (s *tomlScanner) value(path []string) {
+	switch {
+	case s.peek('"'), s.peek('\''):
+		s.str()
+	case s.peek('['):
+		s.i++
+		for n := 0; ; {
+			s.skip(true)
+			if s.i >= len(s.data) || s.peek(']') {
+				s.i++
+				return
+			}
+			if s.peek(',') {
+				s.i++
+				continue
+			}
+			s.value(append(path[:len(path):len(path)], strconv.Itoa(n)))
+			n++
+		}
+	case s.peek('{'):
+		s.i++
+		for {
+			s.skip(false)
+			if s.i >= len(s.data) || s.peek('}') {
+				s.i++
+				return
+			}
+			if s.peek(',') {
+				s.i++
+				continue
+			}
+			s.keyValue(path)
+		}
+	default:
+		// Numbers, booleans and dates, which may hold a space
+		for s.i < len(s.data) && strings.IndexByte(",]}#\r\n", s.data[s.i]) < 0 {
+			s.i++
+		}
+	}
+}
+
+// str reads a basic, literal or multi-line string and returns it as written
+func (s *tomlScanner) str() string {
+	start, quote := s.i, s.data[s.i]
+	delim := []byte{quote}
+	if bytes.HasPrefix(s.data[s.i:], []byte{quote, quote, quote}) {
+		delim = []byte{quote, quote, quote}
+	}
+	s.i += len(delim)
+	for s.i < len(s.data) {
+		if quote == '"' && s.data[s.i] == '\\' {
+			s.i += 2
+			continue
+		}
+		if bytes.HasPrefix(s.data[s.i:], delim) {
+			s.i += len(delim)
+			// A multi-line string may end with up to two quotes before its delimiter
+			for n := 0; n < 2 && len(delim) == 3 && s.peek(quote); n++ {
+				s.i++
+			}
+			return string(s.data[start:s.i])
+		}
+		s.i++
+	}
+	return string(s.data[start:])
+}
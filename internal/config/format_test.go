@@ -0,0 +1,157 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestFormatOf(t *testing.T) {
+	tests := []struct {
+		path   string
+		format Format
+	}{
+		{"config.json", FormatJSON},
+		{"config.yaml", FormatYAML},
+		{"Config.YML", FormatYAML},
+		{"config.toml", FormatTOML},
+		{"config", FormatJSON},
+	}
+
+	for _, tt := range tests {
+		if got := FormatOf(tt.path); got != tt.format {
+			t.Errorf("FormatOf(%s) = %s, expected %s", tt.path, got, tt.format)
+		}
+	}
+}
+
+func TestLoadConfigFormats(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `
+layout: "{category}/{filename}"
+categories:
+  - name: drums
+    priority: 1
+    keywords: [kick, snare]
+    subcategories:
+      snare: [snare]
+      kick:
+        keywords: [kick]
+        exclude_keywords: [kickstart]
+`,
+		"config.toml": `
+layout = "{category}/{filename}"
+
+[[categories]]
+name = "drums"
+priority = 1
+keywords = ["kick", "snare"]
+
+[categories.subcategories]
+snare = ["snare"]
+kick = { keywords = ["kick"], exclude_keywords = ["kickstart"] }
+`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			config, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("LoadConfig should not error: %v", err)
+			}
+			if config.Layout != "{category}/{filename}" || len(config.Categories) != 1 {
+				t.Fatalf("Unexpected config: %+v", config)
+			}
+
+			subs := config.Categories[0].Subcategories
+			if len(subs) != 2 || subs[0].Name != "snare" || subs[1].Name != "kick" {
+				t.Errorf("Expected subcategories in document order, got %+v", subs)
+			}
+			if !reflect.DeepEqual(subs[1].ExcludeKeywords, []string{"kickstart"}) {
+				t.Errorf("Expected kick exclusions, got %v", subs[1].ExcludeKeywords)
+			}
+		})
+	}
+}
+
+func TestLoadConfigInvalidFormats(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": "categories: [name: drums",
+		"config.toml": "[[categories]\nname = ",
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+			if _, err := LoadConfig(path); err == nil {
+				t.Errorf("LoadConfig should error on invalid %s", name)
+			}
+		})
+	}
+}
+
+func TestTOMLKeyOffsets(t *testing.T) {
+	content := `title = """
+not = "a key"
+"""
+"quoted key" = 'C:\samples'
+layout.name = 1979-05-27 07:32:00
+
+[[categories]]
+name = "drums" # name = "comment"
+
+[[categories]]
+name = "bass"
+[categories.subcategories.sub]
+keywords = [
+  "sub", # ]
+  { name = "808" },
+]
+`
+	if _, err := toml.Decode(content, new(interface{})); err != nil {
+		t.Fatalf("Test document is not valid TOML: %v", err)
+	}
+	offsets := tomlKeyOffsets([]byte(content))
+
+	tests := []struct {
+		path   []string
+		line   int
+		column int
+	}{
+		{[]string{"title"}, 1, 1},
+		{[]string{"quoted key"}, 4, 1},
+		{[]string{"layout"}, 5, 1},
+		{[]string{"layout", "name"}, 5, 8},
+		{[]string{"categories"}, 7, 3},
+		{[]string{"categories", "0", "name"}, 8, 1},
+		{[]string{"categories", "1", "name"}, 11, 1},
+		{[]string{"categories", "1", "subcategories"}, 12, 13},
+		{[]string{"categories", "1", "subcategories", "sub"}, 12, 27},
+		{[]string{"categories", "1", "subcategories", "sub", "keywords"}, 13, 1},
+		{[]string{"categories", "1", "subcategories", "sub", "keywords", "1", "name"}, 15, 5},
+	}
+	for _, tt := range tests {
+		offset, ok := offsets[pathKey(tt.path)]
+		if !ok {
+			t.Errorf("No offset for %v", tt.path)
+			continue
+		}
+		if line, column := position([]byte(content), int64(offset)); line != tt.line || column != tt.column {
+			t.Errorf("%v at line %d, column %d, expected line %d, column %d", tt.path, line, column, tt.line, tt.column)
+		}
+	}
+	if len(offsets) != len(tests) {
+		t.Errorf("Expected %d keys, got %d: %q", len(tests), len(offsets), offsets)
+	}
+}
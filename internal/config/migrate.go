@@ -0,0 +1,178 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the version of the configuration format written by this build.
+// Configurations without a "version" field are version 0.
+const CurrentVersion = 1
+
+// migration upgrades a configuration document to the next version in place and describes
+// what it changed
+type migration func(doc *rawNode) ([]string, error)
+
+// migrations[v] upgrades a document of version v to version v+1. Add a migration and bump
+// CurrentVersion for every change that makes earlier configurations fail to load or
+// behave differently.
+var migrations = []migration{
+	migrateToV1,
+}
+
+// migrate upgrades a configuration document to CurrentVersion in place and returns the
+// version it had and what the migrations changed. Documents that aren't objects are left
+// for decoding to reject.
+func migrate(doc *rawNode) (int, []string, error) {
+	if doc.kind != objectNode {
+		return CurrentVersion, nil, nil
+	}
+
+	from := 0
+	if key, ok := doc.member("version"); ok {
+		version, ok := key.value.intValue()
+		if !ok || version < 1 {
+			return 0, nil, key.errorf("version must be a whole number of 1 or more")
+		}
+		if version > CurrentVersion {
+			return 0, nil, key.errorf("version %d is newer than this sample-shifter supports (%d); upgrade sample-shifter", version, CurrentVersion)
+		}
+		from = version
+	}
+
+	var changes []string
+	for v := from; v < CurrentVersion; v++ {
+		changed, err := migrations[v](doc)
+		if err != nil {
+			return 0, nil, fmt.Errorf("migrating from version %d: %w", v, err)
+		}
+		changes = append(changes, changed...)
+	}
+
+	if from < CurrentVersion {
+		// The version goes first, after a "$schema" reference
+		i := 0
+		if len(doc.keys) > 0 && doc.keys[0].name == "$schema" {
+			i = 1
+		}
+		doc.set("version", intNode(CurrentVersion), i)
+	}
+	return from, changes, nil
+}
+
+// migrateToV1 upgrades unversioned configurations. Version 1 requires category priorities
+// of 1 or more, where earlier configurations accepted any priority including a missing
+// one (0), so priorities are shifted to start at 1, keeping their order. Configurations
+// that extend another are left alone: their categories omit the priority to keep the
+// extended one, and shifting would reorder them against it.
+func migrateToV1(doc *rawNode) ([]string, error) {
+	if _, ok := doc.member("extends"); ok {
+		return nil, nil
+	}
+	categories, ok := doc.member("categories")
+	if !ok || categories.value.kind != arrayNode {
+		return nil, nil
+	}
+
+	priorities := make([]int, len(categories.value.items))
+	lowest := 1
+	for i, cat := range categories.value.items {
+		if cat.kind != objectNode {
+			return nil, nil
+		}
+		if key, ok := cat.member("priority"); ok {
+			priority, ok := key.value.intValue()
+			if !ok {
+				// Decoding reports priorities that aren't whole numbers
+				return nil, nil
+			}
+			priorities[i] = priority
+		}
+		lowest = min(lowest, priorities[i])
+	}
+	if lowest >= 1 {
+		return nil, nil
+	}
+
+	shift := 1 - lowest
+	for i, cat := range categories.value.items {
+		// A missing priority goes after the name, where the other categories have it
+		after := 0
+		if len(cat.keys) > 0 && cat.keys[0].name == "name" {
+			after = 1
+		}
+		cat.set("priority", intNode(priorities[i]+shift), after)
+	}
+	return []string{fmt.Sprintf("category priorities must be 1 or more since version 1; added %d to every category priority, keeping their order", shift)}, nil
+}
+
+// Migrate upgrades a configuration file of the given format to CurrentVersion and returns
+// it in the same format with the order of its keys kept, along with the version it had and
+// what changed. Files already at CurrentVersion are returned unchanged. Comments are not
+// kept (see HasComments). The configurations it extends are not migrated.
+func Migrate(data []byte, format Format) ([]byte, int, []string, error) {
+	converted, err := toJSON(data, format)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	doc, err := readDocument(data, converted, format)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	from, changes, err := migrate(doc)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if from == CurrentVersion {
+		return data, from, nil, nil
+	}
+
+	// Don't write a file that wouldn't load
+	if err := checkFields(doc); err != nil {
+		return nil, 0, nil, err
+	}
+	migrated, err := doc.json()
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if err := json.Unmarshal(migrated, new(CategoryConfig)); err != nil {
+		return nil, 0, nil, err
+	}
+
+	out, err := encodeDocument(doc, format)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return out, from, changes, nil
+}
+
+// HasComments reports whether a configuration file of the given format has comments,
+// which Migrate doesn't keep
+func HasComments(data []byte, format Format) bool {
+	switch format {
+	case FormatYAML:
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return false
+		}
+		return yamlHasComments(&doc)
+	case FormatTOML:
+		return scanTOML(data).comments
+	}
+	return false
+}
+
+func yamlHasComments(node *yaml.Node) bool {
+	if node.HeadComment != "" || node.LineComment != "" || node.FootComment != "" {
+		return true
+	}
+	for _, child := range node.Content {
+		if yamlHasComments(child) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,276 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrationsCoverVersions(t *testing.T) {
+	if len(migrations) != CurrentVersion {
+		t.Errorf("Expected a migration for each of the %d earlier versions, got %d", CurrentVersion, len(migrations))
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		input    string
+		expected string
+		changes  int
+	}{
+		{
+			name:   "json keeps order",
+			format: FormatJSON,
+			input: `{"$schema": "./schema.json", "layout": "{category}/{filename}", "categories": [
+  {"name": "drums", "priority": 1, "keywords": ["kick", "drum & bass"],
+   "subcategories": {"snare": {"keywords": ["snare"], "priority": 2}, "kick": ["kick"]}}]}`,
+			expected: `{
+  "$schema": "./schema.json",
+  "version": 1,
+  "layout": "{category}/{filename}",
+  "categories": [
+    {
+      "name": "drums",
+      "priority": 1,
+      "keywords": [
+        "kick",
+        "drum & bass"
+      ],
+      "subcategories": {
+        "snare": {
+          "keywords": [
+            "snare"
+          ],
+          "priority": 2
+        },
+        "kick": [
+          "kick"
+        ]
+      }
+    }
+  ]
+}
+`,
+		},
+		{
+			name:   "yaml priorities shifted",
+			format: FormatYAML,
+			input: `categories:
+  - name: drums
+    priority: 0
+    keywords: [kick, "yes"]
+    subcategories:
+      snare:
+        keywords: [snare]
+      kick: [kick]
+  - name: bass
+    keywords: [bass]
+    min_duration: 0.5
+`,
+			expected: `version: 1
+categories:
+  - name: drums
+    priority: 1
+    keywords: [kick, "yes"]
+    subcategories:
+      snare:
+        keywords: [snare]
+      kick: [kick]
+  - name: bass
+    priority: 1
+    keywords: [bass]
+    min_duration: 0.5
+`,
+			changes: 1,
+		},
+		{
+			name:   "toml keeps subcategory order",
+			format: FormatTOML,
+			input: `[[categories]]
+name = "drums"
+priority = 1
+keywords = ["kick", 're:^bd\d+']
+
+[categories.subcategories]
+snare = { keywords = ["snare"], priority = 1 }
+kick = ["kick"]
+
+[categories.subcategories.cymbal.subcategories]
+ride = ["ride"]
+crash = ["crash"]
+`,
+			expected: `version = 1
+
+[[categories]]
+name = "drums"
+priority = 1
+keywords = ["kick", "re:^bd\\d+"]
+
+[categories.subcategories]
+snare = { keywords = ["snare"], priority = 1 }
+kick = ["kick"]
+
+[categories.subcategories.cymbal.subcategories]
+ride = ["ride"]
+crash = ["crash"]
+`,
+		},
+		{
+			name:   "extending configuration keeps priorities",
+			format: FormatJSON,
+			input:  `{"extends": "default", "categories": [{"name": "drums", "keywords": ["bd"]}]}`,
+			expected: `{
+  "version": 1,
+  "extends": "default",
+  "categories": [
+    {
+      "name": "drums",
+      "keywords": [
+        "bd"
+      ]
+    }
+  ]
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrated, from, changes, err := Migrate([]byte(tt.input), tt.format)
+			if err != nil {
+				t.Fatalf("Migrate should not error: %v", err)
+			}
+			if from != 0 {
+				t.Errorf("Expected version 0, got %d", from)
+			}
+			if len(changes) != tt.changes {
+				t.Errorf("Expected %d changes, got %v", tt.changes, changes)
+			}
+			if string(migrated) != tt.expected {
+				t.Errorf("Unexpected migration:\n%s\nexpected:\n%s", migrated, tt.expected)
+			}
+
+			again, from, _, err := Migrate(migrated, tt.format)
+			if err != nil || from != CurrentVersion || string(again) != string(migrated) {
+				t.Errorf("Migrating a migrated file should change nothing, got version %d, error %v", from, err)
+			}
+		})
+	}
+}
+
+func TestMigrateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"newer version", `{"version": 2, "categories": []}`, "line 1, column 2: version 2 is newer"},
+		{"invalid version", `{"version": "1", "categories": []}`, "version must be a whole number"},
+		{"zero version", `{"version": 0, "categories": []}`, "version must be a whole number"},
+		{"unknown field", `{"categories": [{"name": "drums", "priority": 1, "keywords": ["kick"], "exclude": []}]}`, `unknown field "exclude"`},
+		{"wrong type", `{"categories": [{"name": "drums", "priority": "1"}]}`, "cannot unmarshal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := Migrate([]byte(tt.input), FormatJSON)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestHasComments(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		format   Format
+		expected bool
+	}{
+		{"json", `{"categories": []}`, FormatJSON, false},
+		{"yaml", "categories: []\n", FormatYAML, false},
+		{"yaml head comment", "# drums first\ncategories: []\n", FormatYAML, true},
+		{"yaml line comment", "categories:\n  - name: drums # kit\n", FormatYAML, true},
+		{"toml", "layout = \"#{category}\"\n[[categories]]\nname = 'drums#1'\n", FormatTOML, false},
+		{"toml comment", "[[categories]]\n# kit\nname = \"drums\"\n", FormatTOML, true},
+		{"toml header comment", "[[categories]] # kit\nname = \"drums\"\n", FormatTOML, true},
+		{"toml array comment", "keywords = [\n  \"kick\", # bd\n]\n", FormatTOML, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasComments([]byte(tt.input), tt.format); got != tt.expected {
+				t.Errorf("HasComments = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadConfigMigrates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{"categories": [
+  {"name": "drums", "priority": 0, "keywords": ["kick"]},
+  {"name": "bass", "priority": 1, "keywords": ["bass"]}
+]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig should not error: %v", err)
+	}
+	if config.Version != CurrentVersion {
+		t.Errorf("Expected version %d, got %d", CurrentVersion, config.Version)
+	}
+	if config.Categories[0].Priority != 1 || config.Categories[1].Priority != 2 {
+		t.Errorf("Expected priorities 1 and 2, got %d and %d", config.Categories[0].Priority, config.Categories[1].Priority)
+	}
+	if len(config.Warnings) != 2 || !strings.Contains(config.Warnings[0], "config migrate") {
+		t.Errorf("Expected migration warnings, got %v", config.Warnings)
+	}
+
+	// Validating again keeps the migration warnings
+	if err := config.Validate(); err != nil || len(config.Warnings) != 2 {
+		t.Errorf("Expected migration warnings after validation, got %v (error %v)", config.Warnings, err)
+	}
+
+	// A file of the current version loads without warnings and is not changed on disk
+	data, _, _, err := Migrate([]byte(content), FormatJSON)
+	if err != nil {
+		t.Fatalf("Migrate should not error: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	config, err = LoadConfig(path)
+	if err != nil || len(config.Warnings) != 0 {
+		t.Errorf("Expected no warnings for a migrated file, got %v (error %v)", config.Warnings, err)
+	}
+}
+
+func TestLoadConfigMigratesExtendedFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.json":  `{"categories": [{"name": "drums", "priority": 1, "keywords": ["kick"]}]}`,
+		"layer.json": `{"version": 1, "extends": "base.json", "categories": [{"name": "bass", "priority": 2, "keywords": ["bass"]}]}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test config: %v", err)
+		}
+	}
+
+	config, err := LoadConfig(filepath.Join(dir, "layer.json"))
+	if err != nil {
+		t.Fatalf("LoadConfig should not error: %v", err)
+	}
+	if len(config.Warnings) != 1 || !strings.Contains(config.Warnings[0], "base.json") {
+		t.Errorf("Expected a migration warning for base.json, got %v", config.Warnings)
+	}
+}
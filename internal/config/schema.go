@@ -0,0 +1,111 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Schema returns a JSON Schema (draft-07) describing configuration files, for editor
+// autocompletion and validation. It mirrors the checks of strict decoding and the name,
+// priority and keyword checks of validation; the cross-field checks, such as keywords
+// shadowed by exclusions, are only done when loading.
+func Schema() []byte {
+	keywords := map[string]interface{}{
+		"type":  "array",
+		"items": map[string]interface{}{"$ref": "#/definitions/keyword"},
+	}
+	ref := func(name string) map[string]interface{} {
+		return map[string]interface{}{"$ref": "#/definitions/" + name}
+	}
+
+	schema := map[string]interface{}{
+		"$schema":              "http://json-schema.org/draft-07/schema#",
+		"title":                "sample-shifter category configuration",
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]interface{}{
+			"$schema": map[string]interface{}{"type": "string"},
+			"version": map[string]interface{}{
+				"type":        "integer",
+				"minimum":     1,
+				"maximum":     CurrentVersion,
+				"description": "Version of the configuration format; run \"sample-shifter config migrate\" to upgrade older files",
+			},
+			"extends": map[string]interface{}{
+				"type":        "string",
+				"description": `"default" for the built-in configuration or a path relative to this file`,
+			},
+			"layout": map[string]interface{}{
+				"type":        "string",
+				"description": `Target path template, e.g. "{category}/{subcategory}/{filename}"`,
+			},
+			"path_evidence":        map[string]interface{}{"type": "boolean"},
+			"confidence_threshold": map[string]interface{}{"type": "number", "minimum": 0, "maximum": 1},
+			"categories":           map[string]interface{}{"type": "array", "items": ref("category")},
+			"remove_categories":    map[string]interface{}{"type": "array", "items": ref("name")},
+		},
+		"definitions": map[string]interface{}{
+			"category": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": false,
+				"required":             []string{"name"},
+				"properties": map[string]interface{}{
+					"name":                    ref("name"),
+					"priority":                map[string]interface{}{"type": "integer", "minimum": 1},
+					"keywords":                keywords,
+					"min_duration":            map[string]interface{}{"type": "number", "minimum": 0},
+					"max_duration":            map[string]interface{}{"type": "number", "minimum": 0},
+					"bar_aligned":             map[string]interface{}{"type": "boolean"},
+					"exclude_keywords":        keywords,
+					"weight":                  map[string]interface{}{"type": "number", "minimum": 0},
+					"match_source":            map[string]interface{}{"enum": []MatchSource{MatchFilename, MatchPath, MatchBoth}},
+					"subcategories":           ref("subcategories"),
+					"remove_keywords":         keywords,
+					"remove_exclude_keywords": keywords,
+					"remove_subcategories":    map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+				},
+			},
+			"subcategories": map[string]interface{}{
+				"type":          "object",
+				"propertyNames": ref("name"),
+				"additionalProperties": map[string]interface{}{
+					"oneOf": []interface{}{keywords, ref("subcategory")},
+				},
+			},
+			"subcategory": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": false,
+				"properties": map[string]interface{}{
+					"keywords":                keywords,
+					"exclude_keywords":        keywords,
+					"priority":                map[string]interface{}{"type": "integer", "minimum": 1},
+					"subcategories":           ref("subcategories"),
+					"remove_keywords":         keywords,
+					"remove_exclude_keywords": keywords,
+				},
+			},
+			"name": map[string]interface{}{
+				"type":    "string",
+				"pattern": namePattern(),
+				"not":     map[string]interface{}{"enum": []string{".", ".."}},
+			},
+			"keyword": map[string]interface{}{
+				"type":    "string",
+				"pattern": `\S`,
+			},
+		},
+	}
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		panic("config: marshalling the JSON Schema: " + err.Error())
+	}
+	return append(data, '\n')
+}
+
+// namePattern is the regular expression of validateName's rules except "." and "..":
+// no invalid or control characters, no leading or trailing space and no trailing dot
+func namePattern() string {
+	chars := strings.ReplaceAll(invalidNameChars, `\`, `\\`) + `\x00-\x1f`
+	return `^[^` + chars + ` ]([^` + chars + `]*[^` + chars + ` .])?$`
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+)
+
+func TestSchemaProperties(t *testing.T) {
+	var schema struct {
+		Properties  map[string]json.RawMessage `json:"properties"`
+		Definitions map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"definitions"`
+	}
+	if err := json.Unmarshal(Schema(), &schema); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v", err)
+	}
+
+	objects := []struct {
+		name       string
+		properties map[string]json.RawMessage
+		fields     []string
+	}{
+		{"configuration", schema.Properties, configFields},
+		{"category", schema.Definitions["category"].Properties, categoryFields},
+		{"subcategory", schema.Definitions["subcategory"].Properties, subcategoryFields},
+	}
+
+	for _, object := range objects {
+		if len(object.properties) != len(object.fields) {
+			t.Errorf("Schema of %s has %d properties, expected %d", object.name, len(object.properties), len(object.fields))
+		}
+		for _, field := range object.fields {
+			if _, ok := object.properties[field]; !ok {
+				t.Errorf("Schema of %s is missing %q", object.name, field)
+			}
+		}
+	}
+}
+
+func TestSchemaNamePattern(t *testing.T) {
+	pattern := regexp.MustCompile(namePattern())
+	names := []string{"drums", "Drum Loops", ".hidden", "a", "v1.2", `drums\kicks`, "drums/kicks", "drums.", " drums", "drums ", "kick?", "kick\t1", ""}
+
+	for _, name := range names {
+		valid := validateName(name) == nil
+		if matched := pattern.MatchString(name); matched != valid {
+			t.Errorf("Pattern matches %q: %v, validateName accepts it: %v", name, matched, valid)
+		}
+	}
+}
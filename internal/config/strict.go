@@ -0,0 +1,191 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Fields accepted in each kind of object, taken from the JSON tags so they can't drift
+var (
+	configFields      = jsonFields(reflect.TypeOf(CategoryConfig{}))
+	categoryFields    = jsonFields(reflect.TypeOf(CategoryDefinition{}))
+	subcategoryFields = jsonFields(reflect.TypeOf(subcategoryObject{}))
+)
+
+// jsonFields returns the JSON names of a struct's fields in declaration order
+func jsonFields(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// checkFields returns an error for the first field of a configuration document that no
+// configuration object has, e.g. a misspelled "subcatagories"
+func checkFields(root *rawNode) error {
+	return checkObject(root, configFields, "the configuration", checkConfigMember)
+}
+
+// checkConfigMember descends into the categories of the configuration
+func checkConfigMember(key rawKey, where string) error {
+	if key.name != "categories" {
+		return nil
+	}
+	for i, item := range key.value.items {
+		if err := checkObject(item, categoryFields, fmt.Sprintf("categories[%d]", i), checkCategoryMember); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkCategoryMember descends into the subcategories of a category
+func checkCategoryMember(key rawKey, where string) error {
+	if key.name != "subcategories" {
+		return nil
+	}
+	return checkSubcategories(key.value, where+".subcategories")
+}
+
+// checkSubcategories checks the object form of every subcategory, at any depth
+func checkSubcategories(node *rawNode, where string) error {
+	for _, sub := range node.keys {
+		if sub.value.kind != objectNode {
+			continue
+		}
+		path := where + "." + sub.name
+		err := checkObject(sub.value, subcategoryFields, path, func(key rawKey, where string) error {
+			if key.name != "subcategories" {
+				return nil
+			}
+			return checkSubcategories(key.value, where+".subcategories")
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkObject reports the first member of an object whose name is not in fields, and
+// calls descend for the others
+func checkObject(node *rawNode, fields []string, where string, descend func(key rawKey, where string) error) error {
+	for _, key := range node.keys {
+		if !contains(fields, key.name) {
+			message := fmt.Sprintf("unknown field %q in %s", key.name, where)
+			if suggestion := closest(key.name, fields); suggestion != "" {
+				message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			}
+			if key.line > 0 {
+				message = fmt.Sprintf("line %d, column %d: %s", key.line, key.column, message)
+			}
+			return errors.New(message)
+		}
+		if err := descend(key, where); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closest returns the field nearest to name when it looks like a typo of it
+func closest(name string, fields []string) string {
+	best, bestDistance := "", 3
+	for _, field := range fields {
+		if d := editDistance(strings.ToLower(name), field); d < bestDistance {
+			best, bestDistance = field, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// jsonError adds the line and column of the offending character to JSON syntax errors
+func jsonError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, column := position(data, max(syntaxErr.Offset-1, 0))
+		return fmt.Errorf("line %d, column %d: %w", line, column, err)
+	}
+	return err
+}
+
+// fieldError adds the position of the field to an error for a value of the wrong type,
+// looking the field up in the document by its path, e.g. "categories.1.priority"
+func fieldError(doc *rawNode, err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) || typeErr.Field == "" {
+		return err
+	}
+
+	node, key := doc, rawKey{}
+	for _, part := range strings.Split(typeErr.Field, ".") {
+		switch node.kind {
+		case objectNode:
+			member, ok := node.member(part)
+			if !ok {
+				return err
+			}
+			node, key = member.value, member
+		case arrayNode:
+			i, convErr := strconv.Atoi(part)
+			if convErr != nil || i < 0 || i >= len(node.items) {
+				return err
+			}
+			node = node.items[i]
+		default:
+			return err
+		}
+	}
+	return key.errorf("%w", err)
+}
+
+// invalidNameChars can't appear in category and subcategory names, which become folder
+// names on every platform
+const invalidNameChars = `/\:*?"<>|`
+
+// validateName checks that a category or subcategory name is usable as a folder name
+func validateName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("name cannot be empty")
+	case name == "." || name == "..":
+		return fmt.Errorf("name %q is not a valid folder name", name)
+	case strings.TrimSpace(name) != name || strings.HasSuffix(name, "."):
+		return fmt.Errorf("name %q cannot start or end with spaces or end with a dot", name)
+	}
+	for _, r := range name {
+		if strings.ContainsRune(invalidNameChars, r) || r < 0x20 {
+			return fmt.Errorf("name %q contains %s, which is not allowed in folder names (%s)", name, strconv.QuoteRune(r), invalidNameChars)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadConfigUnknownFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected string
+	}{
+		{
+			name: "top-level field",
+			file: "config.json",
+			content: `{
+  "layuot": "{category}/{filename}",
+  "categories": []
+}`,
+			expected: `line 2, column 3: unknown field "layuot" in the configuration (did you mean "layout"?)`,
+		},
+		{
+			name: "category field",
+			file: "config.json",
+			content: `{
+  "categories": [
+    {"name": "drums", "priority": 1, "keywords": ["kick"]},
+    {"name": "bass", "priority": 2, "keywords": ["bass"], "subcatagories": {}}
+  ]
+}`,
+			expected: `line 4, column 59: unknown field "subcatagories" in categories[1] (did you mean "subcategories"?)`,
+		},
+		{
+			name: "nested subcategory field",
+			file: "config.json",
+			content: `{"categories": [{"name": "drums", "priority": 1, "keywords": ["crash"],
+  "subcategories": {"cymbal": {"subcategories": {"crash": {"keyword": ["crash"]}}}}}]}`,
+			expected: `line 2, column 60: unknown field "keyword" in categories[0].subcategories.cymbal.subcategories.crash (did you mean "keywords"?)`,
+		},
+		{
+			name:     "no suggestion",
+			file:     "config.json",
+			content:  `{"colour": "red", "categories": []}`,
+			expected: `line 1, column 2: unknown field "colour" in the configuration`,
+		},
+		{
+			name: "yaml",
+			file: "config.yaml",
+			content: `categories:
+  - name: drums
+    priority: 1
+    keywords: [kick]
+    exclude: [kickstart]
+`,
+			expected: `line 5, column 5: unknown field "exclude" in categories[0]`,
+		},
+		{
+			name: "toml",
+			file: "config.toml",
+			content: `[[categories]]
+name = "drums"
+priority = 1
+keywords = ["kick"]
+match_sorce = "path"
+`,
+			expected: `line 5, column 1: unknown field "match_sorce" in categories[0] (did you mean "match_source"?)`,
+		},
+		{
+			name: "toml second array table",
+			file: "config.toml",
+			content: `# keywords = "not a key"
+[[categories]]
+name = "drums"
+priority = 1
+keywords = ["kick = 1"]
+
+[[categories]]
+name = "bass"
+priority = 2
+keywords = ["bass"]
+subcategories = { sub = { keywords = ["sub"], keyword = ["808"] } }
+`,
+			expected: `line 11, column 47: unknown field "keyword" in categories[1].subcategories.sub (did you mean "keywords"?)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			_, err := ReadConfig(path)
+			if err == nil {
+				t.Fatal("ReadConfig should error on an unknown field")
+			}
+			if !strings.HasSuffix(err.Error(), ": "+tt.expected) {
+				t.Errorf("Expected error ending in %q, got %q", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestReadConfigKnownFields(t *testing.T) {
+	content := `{
+  "$schema": "./sample-shifter.schema.json",
+  "extends": "default",
+  "remove_categories": ["fx"],
+  "categories": [
+    {
+      "name": "drums",
+      "remove_keywords": ["kick"],
+      "remove_subcategories": ["cymbal/crash"],
+      "subcategories": {
+        "snare": {"keywords": ["rim"], "remove_keywords": ["snr"], "priority": 1}
+      }
+    }
+  ]
+}`
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	if _, err := ReadConfig(path); err != nil {
+		t.Errorf("ReadConfig should accept every known field, got: %v", err)
+	}
+}
+
+func TestReadConfigErrorPosition(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected string
+	}{
+		{"syntax error", "config.json", "{\n  \"categories\": [\n    {\"name\": \"drums\",}\n  ]\n}", "line 3, column 22:"},
+		{"type error", "config.json", "{\n  \"categories\": [\n    {\"name\": \"drums\", \"priority\": \"1\"}\n  ]\n}", "line 3, column 23:"},
+		{"toml type error", "config.toml", "[[categories]]\nname = \"drums\"\n\n[[categories]]\nname = \"bass\"\n  priority = \"2\"\n", "line 6, column 3:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+
+			_, err := ReadConfig(path)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
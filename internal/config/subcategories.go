@@ -0,0 +1,186 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SubcategoryDefinition defines a subcategory folder and the keywords that select it
+type SubcategoryDefinition struct {
+	Name     string
+	Keywords []string
+	// ExcludeKeywords reject a keyword match, e.g. "top hat" for a hihat subcategory
+	ExcludeKeywords []string
+	// Priority breaks ties between sibling subcategories whose keywords match with the
+	// same length, see Precedes. Priorities start at 1; zero means unset.
+	Priority int
+	// Subcategories are nested folders, e.g. "crash" and "ride" below "cymbal"
+	Subcategories Subcategories
+	// RemoveKeywords and RemoveExcludeKeywords drop keywords and exclude keywords of the
+	// same subcategory in an extended configuration
+	RemoveKeywords        []string
+	RemoveExcludeKeywords []string
+}
+
+// subcategoryObject is the object form of a subcategory in JSON
+type subcategoryObject struct {
+	Keywords        []string      `json:"keywords,omitempty"`
+	ExcludeKeywords []string      `json:"exclude_keywords,omitempty"`
+	Priority        int           `json:"priority,omitempty"`
+	Subcategories   Subcategories `json:"subcategories,omitempty"`
+	RemoveKeywords  []string      `json:"remove_keywords,omitempty"`
+	RemoveExcludes  []string      `json:"remove_exclude_keywords,omitempty"`
+}
+
+// Precedes reports whether s wins a tie against a sibling subcategory: the lower
+// priority wins, subcategories without a priority rank after those with one, and
+// otherwise the name that sorts first wins
+func (s SubcategoryDefinition) Precedes(other SubcategoryDefinition) bool {
+	switch {
+	case s.Priority != other.Priority && other.Priority == 0:
+		return true
+	case s.Priority != other.Priority && s.Priority == 0:
+		return false
+	case s.Priority != other.Priority:
+		return s.Priority < other.Priority
+	}
+	return s.Name < other.Name
+}
+
+// Subcategories is an ordered list of subcategories. In JSON it is an object keyed by
+// subcategory name whose values are either a keyword array or an object with "keywords",
+// "exclude_keywords", "priority", nested "subcategories", "remove_keywords" and
+// "remove_exclude_keywords". The order of the keys is preserved.
+type Subcategories []SubcategoryDefinition
+
+// Get returns the subcategory with the given name, or for nested subcategories the
+// slash-separated path, e.g. "cymbal/crash"
+func (s Subcategories) Get(name string) (SubcategoryDefinition, bool) {
+	first, rest, nested := strings.Cut(name, "/")
+	for _, sub := range s {
+		if sub.Name != first {
+			continue
+		}
+		if nested {
+			return sub.Subcategories.Get(rest)
+		}
+		return sub, true
+	}
+	return SubcategoryDefinition{}, false
+}
+
+// Walk calls fn for every subcategory, parents before their children, with the
+// slash-separated path of the subcategory
+func (s Subcategories) Walk(fn func(path string, sub SubcategoryDefinition)) {
+	s.walk("", fn)
+}
+
+func (s Subcategories) walk(prefix string, fn func(path string, sub SubcategoryDefinition)) {
+	for _, sub := range s {
+		path := prefix + sub.Name
+		fn(path, sub)
+		sub.Subcategories.walk(path+"/", fn)
+	}
+}
+
+// MarshalJSON writes subcategories as an object, using the short array form for
+// subcategories with nothing but keywords
+func (s Subcategories) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, sub := range s {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(sub.Name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+
+		var value interface{} = sub.Keywords
+		if len(sub.ExcludeKeywords) > 0 || sub.Priority != 0 || len(sub.Subcategories) > 0 ||
+			len(sub.RemoveKeywords) > 0 || len(sub.RemoveExcludeKeywords) > 0 {
+			value = subcategoryObject{
+				Keywords:        sub.Keywords,
+				ExcludeKeywords: sub.ExcludeKeywords,
+				Priority:        sub.Priority,
+				Subcategories:   sub.Subcategories,
+				RemoveKeywords:  sub.RemoveKeywords,
+				RemoveExcludes:  sub.RemoveExcludeKeywords,
+			}
+		} else if sub.Keywords == nil {
+			value = []string{}
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON reads subcategories from an object, keeping the order of its keys
+func (s *Subcategories) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*s = nil
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("subcategories must be an object mapping names to keywords")
+	}
+
+	var result Subcategories
+	seen := make(map[string]bool)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name := tok.(string)
+		if seen[name] {
+			return fmt.Errorf("duplicate subcategory %q", name)
+		}
+		seen[name] = true
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+
+		sub := SubcategoryDefinition{Name: name}
+		switch trimmed := bytes.TrimSpace(raw); {
+		case len(trimmed) > 0 && trimmed[0] == '[':
+			if err := json.Unmarshal(trimmed, &sub.Keywords); err != nil {
+				return fmt.Errorf("subcategory %q: %w", name, err)
+			}
+		case len(trimmed) > 0 && trimmed[0] == '{':
+			var obj subcategoryObject
+			if err := json.Unmarshal(trimmed, &obj); err != nil {
+				return fmt.Errorf("subcategory %q: %w", name, err)
+			}
+			sub.Keywords = obj.Keywords
+			sub.ExcludeKeywords = obj.ExcludeKeywords
+			sub.Priority = obj.Priority
+			sub.Subcategories = obj.Subcategories
+			sub.RemoveKeywords = obj.RemoveKeywords
+			sub.RemoveExcludeKeywords = obj.RemoveExcludes
+		default:
+			return fmt.Errorf("subcategory %q must be a keyword array or an object with keywords", name)
+		}
+		result = append(result, sub)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	*s = result
+	return nil
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSubcategoriesJSON(t *testing.T) {
+	input := `{"snare": ["snare", "sd"], "hihat": {"keywords": ["hat"], "exclude_keywords": ["top hat"]}, "kick": ["kick"]}`
+
+	var subs Subcategories
+	if err := json.Unmarshal([]byte(input), &subs); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	expected := Subcategories{
+		{Name: "snare", Keywords: []string{"snare", "sd"}},
+		{Name: "hihat", Keywords: []string{"hat"}, ExcludeKeywords: []string{"top hat"}},
+		{Name: "kick", Keywords: []string{"kick"}},
+	}
+	if !reflect.DeepEqual(subs, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, subs)
+	}
+
+	data, err := json.Marshal(subs)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `{"snare":["snare","sd"],"hihat":{"keywords":["hat"],"exclude_keywords":["top hat"]},"kick":["kick"]}`
+	if string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+}
+
+func TestSubcategoriesJSONErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"not an object", `["kick"]`},
+		{"duplicate name", `{"kick": ["kick"], "kick": ["bd"]}`},
+		{"string value", `{"kick": "kick"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subs Subcategories
+			if err := json.Unmarshal([]byte(tt.input), &subs); err == nil {
+				t.Errorf("Unmarshal should fail for %s", tt.input)
+			}
+		})
+	}
+}
+
+func TestSubcategoriesNestedJSON(t *testing.T) {
+	input := `{"kick": ["kick"], "cymbal": {"keywords": ["cymbal"], "subcategories": {"crash": ["crash"], "ride": {"keywords": ["ride"], "exclude_keywords": ["ride on"]}}}}`
+
+	var subs Subcategories
+	if err := json.Unmarshal([]byte(input), &subs); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	ride, ok := subs.Get("cymbal/ride")
+	if !ok || !reflect.DeepEqual(ride.ExcludeKeywords, []string{"ride on"}) {
+		t.Fatalf("Expected nested subcategory cymbal/ride, got %+v", ride)
+	}
+	if _, ok := subs.Get("cymbal/china"); ok {
+		t.Error("Get should not find a missing nested subcategory")
+	}
+
+	var paths []string
+	subs.Walk(func(path string, sub SubcategoryDefinition) {
+		paths = append(paths, path)
+	})
+	if want := []string{"kick", "cymbal", "cymbal/crash", "cymbal/ride"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("Expected walk order %v, got %v", want, paths)
+	}
+
+	data, err := json.Marshal(subs)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `{"kick":["kick"],"cymbal":{"keywords":["cymbal"],"subcategories":{"crash":["crash"],"ride":{"keywords":["ride"],"exclude_keywords":["ride on"]}}}}`
+	if string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+}
+
+func TestSubcategoriesPriorityJSON(t *testing.T) {
+	input := `{"snare":{"keywords":["snr"],"priority":1},"rim":["rim"]}`
+
+	var subs Subcategories
+	if err := json.Unmarshal([]byte(input), &subs); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if snare, _ := subs.Get("snare"); snare.Priority != 1 {
+		t.Errorf("Expected priority 1, got %d", snare.Priority)
+	}
+
+	data, err := json.Marshal(subs)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(data) != input {
+		t.Errorf("Expected %s, got %s", input, data)
+	}
+}
+
+func TestSubcategoryPrecedes(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b SubcategoryDefinition
+		want bool
+	}{
+		{"lower priority wins", SubcategoryDefinition{Name: "b", Priority: 1}, SubcategoryDefinition{Name: "a", Priority: 2}, true},
+		{"higher priority loses", SubcategoryDefinition{Name: "a", Priority: 2}, SubcategoryDefinition{Name: "b", Priority: 1}, false},
+		{"set priority beats unset", SubcategoryDefinition{Name: "b", Priority: 5}, SubcategoryDefinition{Name: "a"}, true},
+		{"unset priority loses", SubcategoryDefinition{Name: "a"}, SubcategoryDefinition{Name: "b", Priority: 5}, false},
+		{"equal priority by name", SubcategoryDefinition{Name: "a", Priority: 1}, SubcategoryDefinition{Name: "b", Priority: 1}, true},
+		{"unset priority by name", SubcategoryDefinition{Name: "b"}, SubcategoryDefinition{Name: "a"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Precedes(tt.b); got != tt.want {
+				t.Errorf("Expected %s.Precedes(%s) = %v, got %v", tt.a.Name, tt.b.Name, tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package dupes
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/theclifmeister/sample-shifter/internal/hashing"
+	"github.com/theclifmeister/sample-shifter/internal/scanner"
+)
+
+// Policy decides which file of a duplicate group is kept as the canonical copy
+type Policy string
+
+const (
+	// PolicyShortestPath keeps the file with the shortest path below the scanned directory
+	PolicyShortestPath Policy = "shortest-path"
+	// PolicyFirstPack keeps the file from the alphabetically first pack
+	PolicyFirstPack Policy = "first-pack"
+	// PolicyCategoryPriority keeps the file whose category has the highest priority
+	PolicyCategoryPriority Policy = "category-priority"
+)
+
+// DefaultPolicy is used when no policy is given
+const DefaultPolicy = PolicyShortestPath
+
+// Policies lists all valid canonical selection policies
+var Policies = []Policy{PolicyShortestPath, PolicyFirstPack, PolicyCategoryPriority}
+
+// ParsePolicy validates a canonical selection policy name
+func ParsePolicy(name string) (Policy, error) {
+	for _, policy := range Policies {
+		if string(policy) == name {
+			return policy, nil
+		}
+	}
+
+	names := make([]string, len(Policies))
+	for i, policy := range Policies {
+		names[i] = string(policy)
+	}
+	return "", fmt.Errorf("unknown keep policy %q (valid: %s)", name, strings.Join(names, ", "))
+}
+
+// Group is a set of files with identical content
+type Group struct {
+	Hash string
+	Size int64
+	// Files holds the duplicates; after Canonicalize the canonical copy comes first
+	Files []scanner.SampleFile
+}
+
+// Wasted returns the bytes that would be reclaimed by keeping a single copy
+func (g Group) Wasted() int64 {
+	return g.Size * int64(len(g.Files)-1)
+}
+
+// Find groups samples with identical content. Only files sharing their size with another
+// file are hashed, using the given number of parallel workers. Files that cannot be read
+// are left out of the groups and reported as errors. Groups are ordered by wasted bytes.
+func Find(samples []scanner.SampleFile, workers int) ([]Group, []error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	var errs []error
+
+	// Size prefilter: a file with a unique size cannot have a duplicate
+	bySize := make(map[int64][]int)
+	for i, sample := range samples {
+		info, err := os.Stat(sample.OriginalPath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sample.OriginalPath, err))
+			continue
+		}
+		bySize[info.Size()] = append(bySize[info.Size()], i)
+	}
+
+	var candidates []int
+	sizes := make(map[int]int64)
+	for size, indices := range bySize {
+		if len(indices) < 2 {
+			continue
+		}
+		for _, i := range indices {
+			candidates = append(candidates, i)
+			sizes[i] = size
+		}
+	}
+	sort.Ints(candidates)
+
+	hashes := make([]string, len(candidates))
+	hashErrs := make([]error, len(candidates))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range work {
+				hashes[c], hashErrs[c] = hashing.HashFile(samples[candidates[c]].OriginalPath)
+			}
+		}()
+	}
+	for c := range candidates {
+		work <- c
+	}
+	close(work)
+	wg.Wait()
+
+	type groupKey struct {
+		size int64
+		hash string
+	}
+	byContent := make(map[groupKey]*Group)
+	var keys []groupKey
+	for c, i := range candidates {
+		if hashErrs[c] != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", samples[i].OriginalPath, hashErrs[c]))
+			continue
+		}
+		key := groupKey{sizes[i], hashes[c]}
+		group, ok := byContent[key]
+		if !ok {
+			group = &Group{Hash: hashes[c], Size: sizes[i]}
+			byContent[key] = group
+			keys = append(keys, key)
+		}
+		group.Files = append(group.Files, samples[i])
+	}
+
+	var groups []Group
+	for _, key := range keys {
+		if group := byContent[key]; len(group.Files) > 1 {
+			groups = append(groups, *group)
+		}
+	}
+
+	sort.SliceStable(groups, func(a, b int) bool {
+		return groups[a].Wasted() > groups[b].Wasted()
+	})
+	return groups, errs
+}
+
+// Ranker ranks a file for PolicyCategoryPriority; lower ranks are preferred
+type Ranker func(sample scanner.SampleFile) int
+
+// Canonicalize orders the files of each group so the canonical copy comes first.
+// rank is only used by PolicyCategoryPriority and is called once per file. Ties are broken
+// by shortest path, then alphabetically, so the choice is deterministic.
+func Canonicalize(groups []Group, policy Policy, rank Ranker) {
+	for g := range groups {
+		files := groups[g].Files
+
+		var ranks map[string]int
+		if policy == PolicyCategoryPriority && rank != nil {
+			ranks = make(map[string]int, len(files))
+			for _, file := range files {
+				ranks[file.OriginalPath] = rank(file)
+			}
+		}
+
+		sort.SliceStable(files, func(a, b int) bool {
+			switch policy {
+			case PolicyFirstPack:
+				packA, packB := strings.ToLower(files[a].Pack()), strings.ToLower(files[b].Pack())
+				if packA != packB {
+					return packA < packB
+				}
+			case PolicyCategoryPriority:
+				if rankA, rankB := ranks[files[a].OriginalPath], ranks[files[b].OriginalPath]; rankA != rankB {
+					return rankA < rankB
+				}
+			}
+
+			pathA, pathB := displayPath(files[a]), displayPath(files[b])
+			if len(pathA) != len(pathB) {
+				return len(pathA) < len(pathB)
+			}
+			return pathA < pathB
+		})
+	}
+}
+
+// Redundant maps the original path of every non-canonical file in the groups to the
+// original path of the canonical copy it duplicates
+func Redundant(groups []Group) map[string]string {
+	redundant := make(map[string]string)
+	for _, group := range groups {
+		for _, file := range group.Files[1:] {
+			redundant[file.OriginalPath] = group.Files[0].OriginalPath
+		}
+	}
+	return redundant
+}
+
+// displayPath returns the path below the scanned directory, or the full path if unknown
+func displayPath(sample scanner.SampleFile) string {
+	if sample.RelativePath != "" {
+		return sample.RelativePath
+	}
+	return sample.OriginalPath
+}
@@ -0,0 +1,136 @@
+package dupes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/theclifmeister/sample-shifter/internal/scanner"
+)
+
+// writeSamples creates source files under root and returns them as scanned samples
+func writeSamples(t *testing.T, root string, contents map[string]string, order []string) []scanner.SampleFile {
+	t.Helper()
+	var samples []scanner.SampleFile
+	for _, rel := range order {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(contents[rel]), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		samples = append(samples, scanner.SampleFile{
+			OriginalPath: path,
+			FileName:     filepath.Base(path),
+			Extension:    filepath.Ext(path),
+			RelativePath: filepath.FromSlash(rel),
+		})
+	}
+	return samples
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	contents := map[string]string{
+		"packB/drums/Kick Hard.wav": "kick",
+		"packA/Kick.wav":            "kick",
+		"packC/808 Kick.wav":        "kick",
+		"packA/Snare.wav":           "snar", // same size as the kicks, different content
+		"packB/Snare.wav":           "snar",
+		"packA/Hat.wav":             "unique hat",
+	}
+	order := []string{"packA/Hat.wav", "packA/Kick.wav", "packA/Snare.wav", "packB/Snare.wav", "packB/drums/Kick Hard.wav", "packC/808 Kick.wav"}
+	samples := writeSamples(t, root, contents, order)
+
+	for _, workers := range []int{1, 4} {
+		groups, errs := Find(samples, workers)
+		if len(errs) != 0 {
+			t.Fatalf("Unexpected errors: %v", errs)
+		}
+		if len(groups) != 2 {
+			t.Fatalf("Expected 2 groups, got %d", len(groups))
+		}
+
+		kicks := groups[0]
+		if len(kicks.Files) != 3 || kicks.Size != 4 || kicks.Wasted() != 8 {
+			t.Errorf("Unexpected kick group: %d files, size %d, wasted %d", len(kicks.Files), kicks.Size, kicks.Wasted())
+		}
+		if len(groups[1].Files) != 2 || groups[1].Files[0].FileName != "Snare.wav" {
+			t.Errorf("Unexpected snare group: %+v", groups[1].Files)
+		}
+	}
+}
+
+func TestFindReportsUnreadableFiles(t *testing.T) {
+	root := t.TempDir()
+	samples := writeSamples(t, root, map[string]string{"a.wav": "x", "b.wav": "x"}, []string{"a.wav", "b.wav"})
+	samples = append(samples, scanner.SampleFile{OriginalPath: filepath.Join(root, "gone.wav")})
+
+	groups, errs := Find(samples, 2)
+	if len(errs) != 1 {
+		t.Errorf("Expected 1 error for the missing file, got %v", errs)
+	}
+	if len(groups) != 1 || len(groups[0].Files) != 2 {
+		t.Errorf("Readable duplicates should still be grouped, got %+v", groups)
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	files := []scanner.SampleFile{
+		{OriginalPath: "/lib/packB/Kick.wav", RelativePath: filepath.FromSlash("packB/Kick.wav")},
+		{OriginalPath: "/lib/packA/drums/Kick.wav", RelativePath: filepath.FromSlash("packA/drums/Kick.wav")},
+		{OriginalPath: "/lib/packC/Perc Kick.wav", RelativePath: filepath.FromSlash("packC/Perc Kick.wav")},
+	}
+	ranks := map[string]int{"/lib/packB/Kick.wav": 2, "/lib/packA/drums/Kick.wav": 2, "/lib/packC/Perc Kick.wav": 1}
+
+	tests := []struct {
+		policy   Policy
+		expected string
+	}{
+		{PolicyShortestPath, "/lib/packB/Kick.wav"},
+		{PolicyFirstPack, "/lib/packA/drums/Kick.wav"},
+		{PolicyCategoryPriority, "/lib/packC/Perc Kick.wav"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			group := Group{Files: append([]scanner.SampleFile(nil), files...)}
+			groups := []Group{group}
+			calls := 0
+			Canonicalize(groups, tt.policy, func(s scanner.SampleFile) int {
+				calls++
+				return ranks[s.OriginalPath]
+			})
+
+			if groups[0].Files[0].OriginalPath != tt.expected {
+				t.Errorf("Expected canonical %s, got %s", tt.expected, groups[0].Files[0].OriginalPath)
+			}
+			if tt.policy == PolicyCategoryPriority && calls != len(files) {
+				t.Errorf("Expected one rank call per file, got %d", calls)
+			}
+
+			redundant := Redundant(groups)
+			if len(redundant) != 2 || redundant[tt.expected] != "" {
+				t.Errorf("Expected the two other files to be redundant, got %v", redundant)
+			}
+			for path, canonical := range redundant {
+				if canonical != tt.expected {
+					t.Errorf("Expected %s to duplicate %s, got %s", path, tt.expected, canonical)
+				}
+			}
+		})
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	for _, policy := range Policies {
+		if parsed, err := ParsePolicy(string(policy)); err != nil || parsed != policy {
+			t.Errorf("ParsePolicy(%q) = %q, %v", policy, parsed, err)
+		}
+	}
+
+	if _, err := ParsePolicy("longest-path"); err == nil {
+		t.Error("ParsePolicy should reject unknown policies")
+	}
+}
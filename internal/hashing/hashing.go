@@ -0,0 +1,50 @@
+package hashing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// HashFile returns the hex-encoded SHA-256 digest of a file's contents
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", fmt.Errorf("failed to hash file: %w", err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// SameContent reports whether two files have identical contents.
+// Sizes are compared first so differing files are usually rejected without reading them.
+func SameContent(pathA, pathB string) (bool, error) {
+	infoA, err := os.Stat(pathA)
+	if err != nil {
+		return false, err
+	}
+	infoB, err := os.Stat(pathB)
+	if err != nil {
+		return false, err
+	}
+	if infoA.Size() != infoB.Size() {
+		return false, nil
+	}
+
+	hashA, err := HashFile(pathA)
+	if err != nil {
+		return false, err
+	}
+	hashB, err := HashFile(pathB)
+	if err != nil {
+		return false, err
+	}
+	return hashA == hashB, nil
+}
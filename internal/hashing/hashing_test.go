@@ -0,0 +1,62 @@
+package hashing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHashFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kick.wav")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	hash, err := HashFile(path)
+	if err != nil {
+		t.Fatalf("HashFile failed: %v", err)
+	}
+
+	expected := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if hash != expected {
+		t.Errorf("Expected hash %s, got %s", expected, hash)
+	}
+
+	if _, err := HashFile(filepath.Join(t.TempDir(), "missing.wav")); err == nil {
+		t.Error("HashFile should error on missing file")
+	}
+}
+
+func TestSameContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"a.wav": "same content",
+		"b.wav": "same content",
+		"c.wav": "diff content",
+		"d.wav": "longer content here",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"a.wav", "b.wav", true},
+		{"a.wav", "c.wav", false},
+		{"a.wav", "d.wav", false},
+	}
+
+	for _, tt := range tests {
+		same, err := SameContent(filepath.Join(tmpDir, tt.a), filepath.Join(tmpDir, tt.b))
+		if err != nil {
+			t.Fatalf("SameContent failed: %v", err)
+		}
+		if same != tt.expected {
+			t.Errorf("SameContent(%s, %s) = %v, expected %v", tt.a, tt.b, same, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package incremental
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/theclifmeister/sample-shifter/internal/categorizer"
+	"github.com/theclifmeister/sample-shifter/internal/hashing"
+	"github.com/theclifmeister/sample-shifter/internal/journal"
+	"github.com/theclifmeister/sample-shifter/internal/scanner"
+)
+
+// Status describes how a source file relates to what is already in the target
+type Status string
+
+const (
+	// StatusNew means nothing exists at the target path yet
+	StatusNew Status = "new"
+	// StatusUpdated means the target exists but differs from the source
+	StatusUpdated Status = "updated"
+	// StatusUnchanged means the target already matches the source
+	StatusUnchanged Status = "unchanged"
+)
+
+// modTimeTolerance absorbs timestamp rounding on filesystems such as FAT and exFAT,
+// which store modification times with two-second resolution
+const modTimeTolerance = 2 * time.Second
+
+// Compare decides whether source needs to be transferred to target. Targets that are the
+// same file as the source (hard links, symlinks) are unchanged. Otherwise the sizes and
+// modification times must match; with checksum, matching sizes are confirmed by comparing
+// SHA-256 hashes instead of modification times.
+func Compare(source, target string, checksum bool) (Status, error) {
+	targetInfo, err := os.Stat(target)
+	if os.IsNotExist(err) {
+		if _, lerr := os.Lstat(target); lerr == nil {
+			// A dangling symlink is in the way
+			return StatusUpdated, nil
+		}
+		return StatusNew, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to stat target: %w", err)
+	}
+
+	sourceInfo, err := os.Stat(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat source: %w", err)
+	}
+
+	if os.SameFile(sourceInfo, targetInfo) {
+		return StatusUnchanged, nil
+	}
+	if sourceInfo.Size() != targetInfo.Size() {
+		return StatusUpdated, nil
+	}
+
+	if checksum {
+		same, err := hashing.SameContent(source, target)
+		if err != nil {
+			return "", err
+		}
+		if same {
+			return StatusUnchanged, nil
+		}
+		return StatusUpdated, nil
+	}
+
+	delta := sourceInfo.ModTime().Sub(targetInfo.ModTime())
+	if delta < 0 {
+		delta = -delta
+	}
+	if delta <= modTimeTolerance {
+		return StatusUnchanged, nil
+	}
+	return StatusUpdated, nil
+}
+
+// PlacedFrom reads the journals in targetDir and returns a check reporting whether a target
+// file was placed from a given source by an earlier run. A target that the latest run
+// touching it pruned counts as placed by nobody.
+func PlacedFrom(targetDir string) (categorizer.PlacedFromFunc, error) {
+	journals, err := journal.List(targetDir)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make(map[string]string)
+	for _, j := range journals {
+		for _, entry := range j.Entries {
+			if entry.Pruned {
+				delete(sources, pathKey(entry.Target))
+			} else {
+				sources[pathKey(entry.Target)] = pathKey(entry.Source)
+			}
+		}
+	}
+
+	return func(target, source string) bool {
+		absTarget, err := filepath.Abs(target)
+		if err != nil {
+			return false
+		}
+		absSource, err := filepath.Abs(source)
+		if err != nil {
+			return false
+		}
+		placed, ok := sources[pathKey(absTarget)]
+		return ok && placed == pathKey(absSource)
+	}, nil
+}
+
+// Orphans returns the audio files under targetDir that no file in the batch maps to,
+// i.e. target files whose source no longer exists. Paths are compared case-insensitively.
+func Orphans(targetDir string, files []categorizer.CategorizedFile) ([]string, error) {
+	expected := make(map[string]bool)
+	for _, file := range files {
+		expected[pathKey(file.TargetPath)] = true
+		if file.Collision != nil {
+			expected[pathKey(file.Collision.OriginalTarget)] = true
+		}
+	}
+
+	var orphans []string
+	err := filepath.Walk(targetDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == targetDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == journal.DirName && path != targetDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isAudioFile(path) && !expected[pathKey(path)] {
+			orphans = append(orphans, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk target directory: %w", err)
+	}
+
+	sort.Strings(orphans)
+	return orphans, nil
+}
+
+// RemoveEmptyParents removes the now-empty folders between path and targetDir
+func RemoveEmptyParents(path, targetDir string) {
+	stop := filepath.Clean(targetDir)
+	for dir := filepath.Dir(path); dir != stop && strings.HasPrefix(dir, stop); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+	}
+}
+
+// isAudioFile reports whether path has one of the scanned audio extensions
+func isAudioFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, audioExt := range scanner.AudioExtensions {
+		if ext == audioExt {
+			return true
+		}
+	}
+	return false
+}
+
+// pathKey normalizes a path for comparison
+func pathKey(path string) string {
+	return strings.ToLower(filepath.Clean(path))
+}
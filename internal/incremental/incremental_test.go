@@ -0,0 +1,195 @@
+package incremental
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/theclifmeister/sample-shifter/internal/categorizer"
+	"github.com/theclifmeister/sample-shifter/internal/journal"
+	"github.com/theclifmeister/sample-shifter/internal/scanner"
+	"github.com/theclifmeister/sample-shifter/internal/transfer"
+)
+
+// writeFile creates a file with the given content and modification time
+func writeFile(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set time on %s: %v", path, err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		targetContent string
+		targetTime    time.Time
+		noTarget      bool
+		checksum      bool
+		expected      Status
+	}{
+		{name: "missing target", noTarget: true, expected: StatusNew},
+		{name: "same size and time", targetContent: "kick", targetTime: base, expected: StatusUnchanged},
+		{name: "coarse timestamp", targetContent: "kick", targetTime: base.Add(time.Second), expected: StatusUnchanged},
+		{name: "different size", targetContent: "kick!", targetTime: base, expected: StatusUpdated},
+		{name: "newer source", targetContent: "kick", targetTime: base.Add(-time.Hour), expected: StatusUpdated},
+		{name: "same time different content", targetContent: "KICK", targetTime: base, expected: StatusUnchanged},
+		{name: "checksum catches content", targetContent: "KICK", targetTime: base, checksum: true, expected: StatusUpdated},
+		{name: "checksum ignores time", targetContent: "kick", targetTime: base.Add(-time.Hour), checksum: true, expected: StatusUnchanged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "src", "Kick.wav")
+			dst := filepath.Join(dir, "target", "Kick.wav")
+			writeFile(t, src, "kick", base)
+			if !tt.noTarget {
+				writeFile(t, dst, tt.targetContent, tt.targetTime)
+			}
+
+			status, err := Compare(src, dst, tt.checksum)
+			if err != nil {
+				t.Fatalf("Compare failed: %v", err)
+			}
+			if status != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, status)
+			}
+		})
+	}
+}
+
+func TestCompareLinkedTarget(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "Kick.wav")
+	writeFile(t, src, "kick", time.Now())
+
+	hardlink := filepath.Join(dir, "hard.wav")
+	if err := os.Link(src, hardlink); err != nil {
+		t.Skipf("Hard links not supported: %v", err)
+	}
+	symlink := filepath.Join(dir, "sym.wav")
+	if err := os.Symlink(src, symlink); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	for _, target := range []string{hardlink, symlink} {
+		if status, err := Compare(src, target, false); err != nil || status != StatusUnchanged {
+			t.Errorf("Expected %s to be unchanged, got %s (%v)", target, status, err)
+		}
+	}
+}
+
+func TestOrphans(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	kick := filepath.Join(dir, "drums", "kick", "Kick.wav")
+	renamed := filepath.Join(dir, "drums", "kick", "Kick_2.wav")
+	old := filepath.Join(dir, "drums", "snare", "OldSnare.wav")
+	for _, path := range []string{kick, renamed, old} {
+		writeFile(t, path, "x", now)
+	}
+	// Non-audio files and the journal folder are never orphans
+	writeFile(t, filepath.Join(dir, "notes.txt"), "x", now)
+	writeFile(t, filepath.Join(dir, journal.DirName, "backups", "run", "Gone.wav"), "x", now)
+
+	files := []categorizer.CategorizedFile{
+		{Sample: scanner.SampleFile{OriginalPath: "/src/a/Kick.wav"}, TargetPath: filepath.Join(dir, "drums", "kick", "KICK.wav")},
+		{
+			Sample:     scanner.SampleFile{OriginalPath: "/src/b/Kick.wav"},
+			TargetPath: renamed,
+			Collision:  &categorizer.CollisionInfo{OriginalTarget: kick, Action: categorizer.CollisionActionRename},
+		},
+	}
+
+	orphans, err := Orphans(dir, files)
+	if err != nil {
+		t.Fatalf("Orphans failed: %v", err)
+	}
+	if !reflect.DeepEqual(orphans, []string{old}) {
+		t.Errorf("Expected only %s to be orphaned, got %v", old, orphans)
+	}
+
+	if orphans, err := Orphans(filepath.Join(dir, "missing"), files); err != nil || len(orphans) != 0 {
+		t.Errorf("Expected no orphans for a missing target, got %v (%v)", orphans, err)
+	}
+}
+
+func TestPlacedFromKeepsUnrelatedFilesAsCollisions(t *testing.T) {
+	root := t.TempDir()
+	targetDir := filepath.Join(root, "target")
+	now := time.Now()
+
+	kickSrc := filepath.Join(root, "src", "Kick.wav")
+	snareSrc := filepath.Join(root, "src", "Snare.wav")
+	writeFile(t, kickSrc, "kick", now)
+	writeFile(t, snareSrc, "snare", now)
+	kickDst := filepath.Join(targetDir, "drums", "kick", "Kick.wav")
+	snareDst := filepath.Join(targetDir, "drums", "snare", "Snare.wav")
+
+	// An earlier run placed the kick; the snare target holds a file nobody placed
+	run, err := journal.New(targetDir, transfer.ModeCopy)
+	if err != nil {
+		t.Fatalf("journal.New failed: %v", err)
+	}
+	if _, err := run.Transfer(kickSrc, kickDst, transfer.ModeCopy, false); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+	if err := run.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	writeFile(t, snareDst, "someone else's snare", now)
+
+	placedFrom, err := PlacedFrom(targetDir)
+	if err != nil {
+		t.Fatalf("PlacedFrom failed: %v", err)
+	}
+	if !placedFrom(kickDst, kickSrc) {
+		t.Error("Expected the journaled kick to count as placed from its source")
+	}
+	if placedFrom(kickDst, snareSrc) {
+		t.Error("A target placed from another source must not count as placed from this one")
+	}
+
+	files := []categorizer.CategorizedFile{
+		{Sample: scanner.SampleFile{OriginalPath: kickSrc}, TargetPath: kickDst},
+		{Sample: scanner.SampleFile{OriginalPath: snareSrc}, TargetPath: snareDst},
+	}
+	resolved := categorizer.ResolveCollisions(files, categorizer.CollisionRename, true, placedFrom)
+
+	if resolved[0].Collision != nil || resolved[0].TargetPath != kickDst {
+		t.Errorf("Expected the kick to keep its own target, got %s (%+v)", resolved[0].TargetPath, resolved[0].Collision)
+	}
+	if resolved[1].Collision == nil || filepath.Base(resolved[1].TargetPath) != "Snare_2.wav" {
+		t.Errorf("Expected the unrelated file to be renamed around, got %s", resolved[1].TargetPath)
+	}
+}
+
+func TestRemoveEmptyParents(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "drums", "snare", "rim", "Rim.wav")
+	keep := filepath.Join(dir, "drums", "Keep.wav")
+	writeFile(t, file, "x", time.Now())
+	writeFile(t, keep, "x", time.Now())
+
+	os.Remove(file)
+	RemoveEmptyParents(file, dir)
+
+	if _, err := os.Stat(filepath.Join(dir, "drums", "snare")); !os.IsNotExist(err) {
+		t.Error("Expected empty folders to be removed")
+	}
+	if _, err := os.Stat(keep); err != nil {
+		t.Errorf("Non-empty folders must be kept: %v", err)
+	}
+}
@@ -0,0 +1,452 @@
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/theclifmeister/sample-shifter/internal/hashing"
+	"github.com/theclifmeister/sample-shifter/internal/transfer"
+)
+
+// DirName is the folder inside a target directory that holds journals and backups
+const DirName = ".sample-shifter"
+
+// ErrNoJournal is returned when a target directory has no recorded runs
+var ErrNoJournal = errors.New("no apply runs recorded in target directory")
+
+// runIDPattern matches the run IDs New generates: the start time and a random suffix
+var runIDPattern = regexp.MustCompile(`^[0-9]{8}-[0-9]{6}-[0-9a-f]{6}$`)
+
+// Entry records a single file placed in the target directory by an apply run
+type Entry struct {
+	// Source is the file that was transferred
+	Source string `json:"source"`
+	// Target is where the file was placed
+	Target string `json:"target"`
+	// Mode is the transfer mode actually used (a reflink may have fallen back to copy)
+	Mode transfer.Mode `json:"mode"`
+	// Size and ModTime describe the target right after the transfer
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	// SHA256 is the content hash of the target; empty for symlinks
+	SHA256 string `json:"sha256,omitempty"`
+	// LinkTarget is the link text of a symlink target
+	LinkTarget string `json:"link_target,omitempty"`
+	// Backup is where an overwritten or pruned file was moved before it was replaced
+	Backup string `json:"backup,omitempty"`
+	// Pruned marks a target file that was removed because its source no longer exists
+	Pruned bool `json:"pruned,omitempty"`
+}
+
+// Journal records everything an apply run changed so it can be undone. Every change is
+// appended to the journal file and synced before the run moves on, so an interrupted run
+// can still be undone. Transfer and Prune may be called from concurrent workers.
+type Journal struct {
+	mu sync.Mutex
+	// created indexes CreatedDirs
+	created map[string]bool
+	// file is the journal being appended to; opened by the first change
+	file *os.File
+
+	RunID     string
+	Timestamp time.Time
+	Mode      transfer.Mode
+	TargetDir string
+	// CreatedDirs lists directories created by the run, parents before children
+	CreatedDirs []string
+	Entries     []Entry
+}
+
+// header is the first line of a journal file
+type header struct {
+	RunID     string        `json:"run_id"`
+	Timestamp time.Time     `json:"timestamp"`
+	Mode      transfer.Mode `json:"mode"`
+	TargetDir string        `json:"target_dir"`
+}
+
+// change is one line after the header of a journal file
+type change struct {
+	CreatedDir string `json:"created_dir,omitempty"`
+	Entry      *Entry `json:"entry,omitempty"`
+}
+
+// New starts a journal for a run placing files into targetDir with the given mode
+func New(targetDir string, mode transfer.Mode) (*Journal, error) {
+	absTarget, err := filepath.Abs(targetDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve target directory: %w", err)
+	}
+
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, fmt.Errorf("failed to generate run ID: %w", err)
+	}
+
+	now := time.Now()
+	return &Journal{
+		RunID:     now.Format("20060102-150405") + "-" + hex.EncodeToString(suffix),
+		Timestamp: now,
+		Mode:      mode,
+		TargetDir: absTarget,
+		created:   make(map[string]bool),
+	}, nil
+}
+
+// Path returns where the journal is stored
+func (j *Journal) Path() string {
+	return filepath.Join(journalDir(j.TargetDir), j.RunID+".jsonl")
+}
+
+// backupDir returns where files overwritten by this run are kept
+func (j *Journal) backupDir() string {
+	return filepath.Join(j.TargetDir, DirName, "backups", j.RunID)
+}
+
+// Transfer places source at target like transfer.Transfer and records the result. With
+// overwrite, an existing file at target is moved into the run's backup folder first and
+// is put back if the transfer fails.
+func (j *Journal) Transfer(source, target string, mode transfer.Mode, overwrite bool) (transfer.Mode, error) {
+	backup, err := j.prepare(target, overwrite)
+	if err != nil {
+		return mode, err
+	}
+
+	used, err := transfer.Transfer(source, target, mode, overwrite)
+	if err != nil {
+		if backup != "" {
+			os.Rename(backup, target)
+		}
+		return used, err
+	}
+
+	return used, j.record(source, target, used, backup)
+}
+
+// prepare records the directories a transfer to target is about to create and, when
+// overwrite is set, moves an existing file into the backup folder. It returns the backup path.
+func (j *Journal) prepare(target string, overwrite bool) (string, error) {
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve target path: %w", err)
+	}
+
+	j.mu.Lock()
+	if j.created == nil {
+		j.created = make(map[string]bool)
+	}
+	var missing []string
+	for dir := filepath.Dir(absTarget); ; dir = filepath.Dir(dir) {
+		if _, err := os.Lstat(dir); err == nil {
+			break
+		}
+		missing = append(missing, dir)
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		if j.created[missing[i]] {
+			continue
+		}
+		j.created[missing[i]] = true
+		j.CreatedDirs = append(j.CreatedDirs, missing[i])
+		if err := j.appendLocked(change{CreatedDir: missing[i]}); err != nil {
+			j.mu.Unlock()
+			return "", err
+		}
+	}
+	j.mu.Unlock()
+
+	if !overwrite {
+		return "", nil
+	}
+	if _, err := os.Lstat(absTarget); err != nil {
+		return "", nil
+	}
+
+	return j.backup(absTarget)
+}
+
+// backup moves an existing target file into the run's backup folder and returns its new path
+func (j *Journal) backup(absTarget string) (string, error) {
+	rel, err := filepath.Rel(j.TargetDir, absTarget)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(absTarget)
+	}
+	backup := filepath.Join(j.backupDir(), rel)
+	if err := os.MkdirAll(filepath.Dir(backup), 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if err := os.Rename(absTarget, backup); err != nil {
+		return "", fmt.Errorf("failed to back up existing file: %w", err)
+	}
+	return backup, nil
+}
+
+// Prune removes a target file by moving it into the run's backup folder, so that an undo
+// can bring it back
+func (j *Journal) Prune(target string) error {
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return fmt.Errorf("failed to resolve target path: %w", err)
+	}
+
+	backup, err := j.backup(absTarget)
+	if err != nil {
+		return err
+	}
+
+	return j.add(Entry{Target: absTarget, Backup: backup, Pruned: true})
+}
+
+// record adds a completed transfer to the journal
+func (j *Journal) record(source, target string, mode transfer.Mode, backup string) error {
+	absSource, err := filepath.Abs(source)
+	if err != nil {
+		return fmt.Errorf("failed to resolve source path: %w", err)
+	}
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return fmt.Errorf("failed to resolve target path: %w", err)
+	}
+
+	info, err := os.Lstat(absTarget)
+	if err != nil {
+		return fmt.Errorf("failed to stat target: %w", err)
+	}
+
+	entry := Entry{
+		Source:  absSource,
+		Target:  absTarget,
+		Mode:    mode,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Backup:  backup,
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		if entry.LinkTarget, err = os.Readlink(absTarget); err != nil {
+			return fmt.Errorf("failed to read symlink: %w", err)
+		}
+	} else if entry.SHA256, err = hashing.HashFile(absTarget); err != nil {
+		return err
+	}
+
+	return j.add(entry)
+}
+
+// add records a completed entry and appends it to the journal file
+func (j *Journal) add(entry Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.Entries = append(j.Entries, entry)
+	return j.appendLocked(change{Entry: &entry})
+}
+
+// appendLocked writes a change to the journal file, creating the file with its header on
+// the first change, and syncs it to disk. The caller must hold j.mu.
+func (j *Journal) appendLocked(c change) error {
+	if j.file == nil {
+		if err := os.MkdirAll(journalDir(j.TargetDir), 0755); err != nil {
+			return fmt.Errorf("failed to create journal directory: %w", err)
+		}
+		file, err := os.OpenFile(j.Path(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to create journal: %w", err)
+		}
+		j.file = file
+		if err := j.writeLine(j.header()); err != nil {
+			return err
+		}
+	}
+	if err := j.writeLine(c); err != nil {
+		return err
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// writeLine appends a JSON-encoded line to the open journal file
+func (j *Journal) writeLine(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal: %w", err)
+	}
+	if _, err := j.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// header returns the run details written at the top of the journal file
+func (j *Journal) header() header {
+	return header{RunID: j.RunID, Timestamp: j.Timestamp, Mode: j.Mode, TargetDir: j.TargetDir}
+}
+
+// Close closes the journal file of a recording run. It is a no-op when nothing was recorded.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to close journal: %w", err)
+	}
+	return nil
+}
+
+// Save replaces the journal file with the journal's current contents. The new file is
+// written next to the old one and renamed into place, so a failed save leaves the old
+// journal intact.
+func (j *Journal) Save() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := os.MkdirAll(journalDir(j.TargetDir), 0755); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	if err := encoder.Encode(j.header()); err != nil {
+		return fmt.Errorf("failed to encode journal: %w", err)
+	}
+	for _, dir := range j.CreatedDirs {
+		if err := encoder.Encode(change{CreatedDir: dir}); err != nil {
+			return fmt.Errorf("failed to encode journal: %w", err)
+		}
+	}
+	for i := range j.Entries {
+		if err := encoder.Encode(change{Entry: &j.Entries[i]}); err != nil {
+			return fmt.Errorf("failed to encode journal: %w", err)
+		}
+	}
+
+	tmp := j.Path() + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := os.Rename(tmp, j.Path()); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// Load reads the journal of a specific run from a target directory. A last line cut short
+// by an interrupted run is ignored.
+func Load(targetDir, runID string) (*Journal, error) {
+	// The run ID names a file inside the journal folder; don't let it point elsewhere
+	if !runIDPattern.MatchString(runID) {
+		return nil, fmt.Errorf("invalid run ID %q (expected e.g. 20060102-150405-a1b2c3)", runID)
+	}
+	file, err := os.Open(filepath.Join(journalDir(targetDir), runID+".jsonl"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no journal for run %q", runID)
+		}
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var j *Journal
+	for lineNum := 1; ; lineNum++ {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// Without a trailing newline the line was never completely written
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read journal: %w", err)
+		}
+
+		if j == nil {
+			var h header
+			if err := json.Unmarshal(line, &h); err != nil {
+				return nil, fmt.Errorf("failed to parse journal %s: %w", runID, err)
+			}
+			j = &Journal{RunID: h.RunID, Timestamp: h.Timestamp, Mode: h.Mode, TargetDir: h.TargetDir}
+			continue
+		}
+
+		var c change
+		if err := json.Unmarshal(line, &c); err != nil {
+			return nil, fmt.Errorf("failed to parse journal %s line %d: %w", runID, lineNum, err)
+		}
+		switch {
+		case c.Entry != nil:
+			j.Entries = append(j.Entries, *c.Entry)
+		case c.CreatedDir != "":
+			j.CreatedDirs = append(j.CreatedDirs, c.CreatedDir)
+		}
+	}
+
+	if j == nil {
+		return nil, fmt.Errorf("failed to parse journal %s: missing header", runID)
+	}
+	return j, nil
+}
+
+// List returns all journals in a target directory, oldest first
+func List(targetDir string) ([]*Journal, error) {
+	files, err := filepath.Glob(filepath.Join(journalDir(targetDir), "*.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list journals: %w", err)
+	}
+
+	var journals []*Journal
+	for _, file := range files {
+		runID := strings.TrimSuffix(filepath.Base(file), ".jsonl")
+		if !runIDPattern.MatchString(runID) {
+			continue
+		}
+		j, err := Load(targetDir, runID)
+		if err != nil {
+			return nil, err
+		}
+		journals = append(journals, j)
+	}
+
+	sort.Slice(journals, func(a, b int) bool {
+		return journals[a].Timestamp.Before(journals[b].Timestamp)
+	})
+	return journals, nil
+}
+
+// Latest returns the most recent journal in a target directory
+func Latest(targetDir string) (*Journal, error) {
+	journals, err := List(targetDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(journals) == 0 {
+		return nil, ErrNoJournal
+	}
+	return journals[len(journals)-1], nil
+}
+
+// journalDir returns the folder holding journals for a target directory
+func journalDir(targetDir string) string {
+	return filepath.Join(targetDir, DirName, "journal")
+}
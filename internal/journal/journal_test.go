@@ -0,0 +1,299 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/theclifmeister/sample-shifter/internal/transfer"
+)
+
+// writeFile creates a file with the given content, creating parent directories
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestUndoRemovesRunAndRestoresBackups(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "target")
+	kickSrc := filepath.Join(root, "src", "Kick.wav")
+	snareSrc := filepath.Join(root, "src", "Snare.wav")
+	writeFile(t, kickSrc, "new kick")
+	writeFile(t, snareSrc, "snare")
+
+	// An existing file that the run overwrites
+	kickDst := filepath.Join(target, "drums", "kick", "Kick.wav")
+	writeFile(t, kickDst, "old kick")
+	snareDst := filepath.Join(target, "drums", "snare", "Snare.wav")
+
+	j, err := New(target, transfer.ModeCopy)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := j.Transfer(kickSrc, kickDst, transfer.ModeCopy, true); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+	if _, err := j.Transfer(snareSrc, snareDst, transfer.ModeCopy, false); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	loaded, err := Latest(target)
+	if err != nil {
+		t.Fatalf("Latest failed: %v", err)
+	}
+	if loaded.RunID != j.RunID || len(loaded.Entries) != 2 {
+		t.Fatalf("Unexpected journal: %+v", loaded)
+	}
+
+	results, err := Undo(loaded, false)
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	for _, result := range results {
+		if !result.Done() {
+			t.Errorf("Expected %s to be undone, got %s (%s)", result.Entry.Target, result.Action, result.Reason)
+		}
+	}
+
+	if data, _ := os.ReadFile(kickDst); string(data) != "old kick" {
+		t.Errorf("Expected overwritten file to be restored, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(target, "drums", "snare")); !os.IsNotExist(err) {
+		t.Error("Expected directory created by the run to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(target, DirName)); !os.IsNotExist(err) {
+		t.Error("Expected journal and backups to be removed after a complete undo")
+	}
+	if _, err := os.Stat(snareSrc); err != nil {
+		t.Errorf("Source files must survive undo: %v", err)
+	}
+}
+
+func TestUndoMoveRun(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "target")
+	src := filepath.Join(root, "src", "Kick.wav")
+	dst := filepath.Join(target, "drums", "Kick.wav")
+	writeFile(t, src, "kick")
+
+	j, err := New(target, transfer.ModeMove)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := j.Transfer(src, dst, transfer.ModeMove, false); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+
+	if _, err := Undo(j, false); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if data, _ := os.ReadFile(src); string(data) != "kick" {
+		t.Errorf("Expected moved file to be back at its source, got %q", data)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Error("Expected target directory created by the run to be removed")
+	}
+}
+
+func TestUndoRefusesModifiedFiles(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "target")
+	src := filepath.Join(root, "src", "Kick.wav")
+	writeFile(t, src, "kick")
+
+	tests := []struct {
+		name    string
+		modify  func(path string)
+		refused bool
+	}{
+		{"untouched", func(string) {}, false},
+		{"touched but identical", func(path string) {
+			later := time.Now().Add(time.Hour)
+			os.Chtimes(path, later, later)
+		}, false},
+		{"same size new content", func(path string) {
+			os.WriteFile(path, []byte("KICK"), 0644)
+		}, true},
+		{"different size", func(path string) {
+			os.WriteFile(path, []byte("kick, edited"), 0644)
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := filepath.Join(target, tt.name, "Kick.wav")
+			j, err := New(target, transfer.ModeCopy)
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			if _, err := j.Transfer(src, dst, transfer.ModeCopy, false); err != nil {
+				t.Fatalf("Transfer failed: %v", err)
+			}
+
+			tt.modify(dst)
+
+			results, err := Undo(j, false)
+			if err != nil {
+				t.Fatalf("Undo failed: %v", err)
+			}
+			refused := results[0].Action == UndoRefused
+			if refused != tt.refused {
+				t.Errorf("Expected refused=%v, got %s", tt.refused, results[0].Action)
+			}
+
+			_, statErr := os.Stat(dst)
+			if tt.refused && statErr != nil {
+				t.Error("A modified file must not be deleted")
+			}
+			if !tt.refused && !os.IsNotExist(statErr) {
+				t.Error("An unmodified file should be deleted")
+			}
+			if tt.refused && len(j.Entries) != 1 {
+				t.Errorf("Refused entries should stay in the journal, got %d", len(j.Entries))
+			}
+		})
+	}
+}
+
+func TestUndoDryRunChangesNothing(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "target")
+	src := filepath.Join(root, "src", "Kick.wav")
+	dst := filepath.Join(target, "Kick.wav")
+	writeFile(t, src, "kick")
+
+	j, err := New(target, transfer.ModeSymlink)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := j.Transfer(src, dst, transfer.ModeSymlink, false); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+
+	results, err := Undo(j, true)
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if results[0].Action != UndoRemoved {
+		t.Errorf("Expected symlink to be reported as removed, got %s", results[0].Action)
+	}
+	if _, err := os.Lstat(dst); err != nil {
+		t.Errorf("Dry run must not remove files: %v", err)
+	}
+}
+
+func TestInterruptedRunCanBeUndone(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "target")
+	src := filepath.Join(root, "src", "Kick.wav")
+	writeFile(t, src, "kick")
+	dst := filepath.Join(target, "drums", "Kick.wav")
+
+	j, err := New(target, transfer.ModeCopy)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := j.Transfer(src, dst, transfer.ModeCopy, false); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+
+	// The run stops before closing the journal, partway through writing another change
+	file, err := os.OpenFile(j.Path(), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open journal: %v", err)
+	}
+	file.WriteString(`{"entry":{"source":"/src/Sna`)
+	file.Close()
+
+	loaded, err := Latest(target)
+	if err != nil {
+		t.Fatalf("Latest failed: %v", err)
+	}
+	if len(loaded.Entries) != 1 || loaded.Entries[0].Target != dst {
+		t.Fatalf("Expected the completed transfer to be journaled, got %+v", loaded.Entries)
+	}
+	if len(loaded.CreatedDirs) == 0 {
+		t.Error("Expected created directories to be journaled")
+	}
+
+	if _, err := Undo(loaded, false); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Error("Expected the interrupted run to be undone completely")
+	}
+}
+
+func TestLoadRejectsInvalidRunIDs(t *testing.T) {
+	root := t.TempDir()
+	targetDir := filepath.Join(root, "target")
+	outside := filepath.Join(root, "elsewhere", "x.jsonl")
+	if err := os.MkdirAll(filepath.Dir(outside), 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := `{"run_id":"x","timestamp":"2024-01-01T00:00:00Z","mode":"copy","target_dir":"` + targetDir + `"}` + "\n"
+	if err := os.WriteFile(outside, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []string{
+		"../../../elsewhere/x",
+		filepath.Join("..", "..", "..", "elsewhere", "x"),
+		"20240101-000000-abcdef/../x",
+		"..",
+		"x",
+		"",
+	}
+	for _, runID := range tests {
+		if _, err := Load(targetDir, runID); err == nil || !strings.Contains(err.Error(), "invalid run ID") {
+			t.Errorf("Load(%q) should reject the run ID, got %v", runID, err)
+		}
+	}
+}
+
+func TestLatestWithoutJournal(t *testing.T) {
+	if _, err := Latest(t.TempDir()); err != ErrNoJournal {
+		t.Errorf("Expected ErrNoJournal, got %v", err)
+	}
+}
+
+func TestUndoRestoresPrunedFiles(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "target")
+	orphan := filepath.Join(target, "drums", "Old.wav")
+	writeFile(t, orphan, "old")
+
+	j, err := New(target, transfer.ModeCopy)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := j.Prune(orphan); err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Fatal("Prune should remove the file from the target")
+	}
+	os.Remove(filepath.Dir(orphan))
+
+	results, err := Undo(j, false)
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if results[0].Action != UndoRestored {
+		t.Errorf("Expected pruned file to be restored, got %s (%s)", results[0].Action, results[0].Reason)
+	}
+	if data, _ := os.ReadFile(orphan); string(data) != "old" {
+		t.Errorf("Expected pruned content back, got %q", data)
+	}
+}
@@ -0,0 +1,186 @@
+package journal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/theclifmeister/sample-shifter/internal/hashing"
+	"github.com/theclifmeister/sample-shifter/internal/transfer"
+)
+
+// Undo actions reported for each journal entry
+const (
+	UndoRemoved  = "removed"
+	UndoRestored = "restored"
+	UndoMissing  = "missing"
+	UndoRefused  = "refused"
+	UndoFailed   = "failed"
+)
+
+// UndoResult describes what happened to one journal entry
+type UndoResult struct {
+	Entry  Entry
+	Action string
+	// Reason explains refused and failed entries
+	Reason string
+}
+
+// Done reports whether the entry no longer needs undoing
+func (r UndoResult) Done() bool {
+	return r.Action == UndoRemoved || r.Action == UndoRestored || r.Action == UndoMissing
+}
+
+// Undo reverses a run: files are removed (or moved back to their source for move runs),
+// overwritten and pruned files are restored from backup and directories created by the
+// run are removed when empty. Files modified since the run are never touched. Entries that
+// could not be undone stay in the journal so the undo can be retried; once everything is
+// undone the journal is deleted. With dryRun nothing is changed.
+func Undo(j *Journal, dryRun bool) ([]UndoResult, error) {
+	var results []UndoResult
+	var remaining []Entry
+
+	for i := len(j.Entries) - 1; i >= 0; i-- {
+		result := undoEntry(j.Entries[i], dryRun)
+		results = append(results, result)
+		if !result.Done() {
+			remaining = append([]Entry{j.Entries[i]}, remaining...)
+		}
+	}
+
+	if dryRun {
+		return results, nil
+	}
+
+	j.Entries = remaining
+	var err error
+	if len(remaining) > 0 {
+		err = j.Save()
+	} else {
+		err = j.remove()
+	}
+
+	for i := len(j.CreatedDirs) - 1; i >= 0; i-- {
+		// Only succeeds for empty directories, which is exactly what we want
+		os.Remove(j.CreatedDirs[i])
+	}
+	return results, err
+}
+
+// undoEntry reverses a single transfer
+func undoEntry(entry Entry, dryRun bool) UndoResult {
+	result := UndoResult{Entry: entry}
+
+	if entry.Pruned {
+		if _, err := os.Lstat(entry.Target); err == nil {
+			result.Action, result.Reason = UndoRefused, "target path is occupied again"
+			return result
+		}
+		result.Action = UndoRestored
+		restoreBackup(entry, dryRun, &result)
+		return result
+	}
+
+	modified, err := changedSinceRun(entry)
+	if os.IsNotExist(err) {
+		result.Action = UndoMissing
+		restoreBackup(entry, dryRun, &result)
+		return result
+	}
+	if err != nil {
+		result.Action, result.Reason = UndoFailed, err.Error()
+		return result
+	}
+	if modified {
+		result.Action, result.Reason = UndoRefused, "modified since the run"
+		return result
+	}
+
+	if entry.Mode == transfer.ModeMove {
+		if _, err := os.Lstat(entry.Source); err == nil {
+			result.Action, result.Reason = UndoRefused, "source path is occupied again"
+			return result
+		}
+		result.Action = UndoRestored
+		if !dryRun {
+			if _, err := transfer.Transfer(entry.Target, entry.Source, transfer.ModeMove, false); err != nil {
+				result.Action, result.Reason = UndoFailed, err.Error()
+				return result
+			}
+		}
+	} else {
+		result.Action = UndoRemoved
+		if !dryRun {
+			if err := os.Remove(entry.Target); err != nil {
+				result.Action, result.Reason = UndoFailed, fmt.Sprintf("failed to remove: %v", err)
+				return result
+			}
+		}
+	}
+
+	restoreBackup(entry, dryRun, &result)
+	return result
+}
+
+// restoreBackup puts a file overwritten or pruned by the run back in place
+func restoreBackup(entry Entry, dryRun bool, result *UndoResult) {
+	if entry.Backup == "" || dryRun {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(entry.Target), 0755); err != nil {
+		result.Action, result.Reason = UndoFailed, fmt.Sprintf("failed to restore backup: %v", err)
+		return
+	}
+	if err := os.Rename(entry.Backup, entry.Target); err != nil {
+		result.Action, result.Reason = UndoFailed, fmt.Sprintf("failed to restore backup: %v", err)
+	}
+}
+
+// changedSinceRun reports whether a target differs from what the run left behind. Size and
+// modification time are checked first; the content hash settles a changed modification time.
+func changedSinceRun(entry Entry) (bool, error) {
+	info, err := os.Lstat(entry.Target)
+	if err != nil {
+		return false, err
+	}
+
+	if entry.LinkTarget != "" {
+		if info.Mode()&os.ModeSymlink == 0 {
+			return true, nil
+		}
+		link, err := os.Readlink(entry.Target)
+		if err != nil {
+			return false, fmt.Errorf("failed to read symlink: %w", err)
+		}
+		return link != entry.LinkTarget, nil
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 || info.Size() != entry.Size {
+		return true, nil
+	}
+	if info.ModTime().Equal(entry.ModTime) {
+		return false, nil
+	}
+
+	hash, err := hashing.HashFile(entry.Target)
+	if err != nil {
+		return false, err
+	}
+	return hash != entry.SHA256, nil
+}
+
+// remove deletes the journal and the run's backups, then any state folders left empty
+func (j *Journal) remove() error {
+	if err := os.RemoveAll(j.backupDir()); err != nil {
+		return fmt.Errorf("failed to remove backups: %w", err)
+	}
+	if err := os.Remove(j.Path()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove journal: %w", err)
+	}
+
+	stateDir := filepath.Join(j.TargetDir, DirName)
+	for _, dir := range []string{filepath.Join(stateDir, "backups"), journalDir(j.TargetDir), stateDir} {
+		os.Remove(dir)
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package keyword
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+const (
+	// SubstringPrefix marks a keyword that matches anywhere in a name, e.g. "substr:bass"
+	// also matches "Bassline". Without a prefix keywords only match whole tokens.
+	SubstringPrefix = "substr:"
+	// RegexPrefix marks a case-insensitive regular expression searched for in the name,
+	// e.g. `re:^bd\d+`
+	RegexPrefix = "re:"
+	// GlobPrefix marks a case-insensitive glob that must match the whole name, e.g.
+	// "glob:*_808_*"
+	GlobPrefix = "glob:"
+)
+
+// kind is the way a keyword is matched
+type kind int
+
+const (
+	kindToken kind = iota
+	kindSubstring
+	kindRegex
+	kindGlob
+)
+
+// Tokenize splits a name into lowercase tokens. Tokens are separated by any character that
+// is not a letter or digit, by letter/digit boundaries ("Kick01" -> kick, 01) and by
+// camelCase boundaries ("DeepKick" -> deep, kick; "HHOpen" -> hh, open).
+func Tokenize(s string) []string {
+	var tokens []string
+	runes := []rune(s)
+	start := -1
+
+	flush := func(end int) {
+		if start >= 0 && end > start {
+			tokens = append(tokens, strings.ToLower(string(runes[start:end])))
+		}
+		start = -1
+	}
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush(i)
+			continue
+		}
+		if start >= 0 && isBoundary(runes, i) {
+			flush(i)
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	flush(len(runes))
+
+	return tokens
+}
+
+// isBoundary reports whether a new token starts at runes[i], given that runes[i-1] is part
+// of the current token
+func isBoundary(runes []rune, i int) bool {
+	prev, cur := runes[i-1], runes[i]
+	switch {
+	case unicode.IsDigit(prev) != unicode.IsDigit(cur):
+		return true
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return true
+	case unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+		// The last capital of an acronym starts the next word: "HHOpen" -> HH, Open
+		return true
+	}
+	return false
+}
+
+// Name is a filename prepared for matching
+type Name struct {
+	// Lower is the lowercase name, used for substring, regex and glob keywords
+	Lower string
+	// Tokens are the lowercase tokens of the name
+	Tokens []string
+}
+
+// NewName prepares a name for matching against many keywords
+func NewName(name string) Name {
+	return Name{Lower: strings.ToLower(name), Tokens: Tokenize(name)}
+}
+
+// Matcher matches one configured keyword against names
+type Matcher struct {
+	// Keyword is the keyword as written in the configuration
+	Keyword string
+	kind    kind
+	// pattern is the keyword without prefix, lowercased (except for regular expressions)
+	pattern string
+	// joined is the concatenation of the keyword's tokens
+	joined string
+	re     *regexp.Regexp
+	// literal is the number of literal characters every match of a regular expression has
+	literal int
+}
+
+// Compile prepares a keyword for matching. Plain keywords match a whole token or run of
+// tokens: "hi hat", "hi-hat" and "hihat" all match "HiHat_01" but "sub" does not match
+// "subtle". Keywords with the "substr:" prefix match anywhere in the name, "re:" keywords
+// are regular expressions and "glob:" keywords are shell patterns. Names are matched
+// without their file extension.
+func Compile(kw string) (*Matcher, error) {
+	m := &Matcher{Keyword: kw}
+
+	text := kw
+	lower := strings.ToLower(kw)
+	switch {
+	case strings.HasPrefix(lower, SubstringPrefix):
+		m.kind = kindSubstring
+		text = kw[len(SubstringPrefix):]
+	case strings.HasPrefix(lower, RegexPrefix):
+		m.kind = kindRegex
+		text = kw[len(RegexPrefix):]
+	case strings.HasPrefix(lower, GlobPrefix):
+		m.kind = kindGlob
+		text = kw[len(GlobPrefix):]
+	}
+	m.pattern = strings.ToLower(strings.TrimSpace(text))
+	m.joined = strings.Join(Tokenize(text), "")
+
+	if m.pattern == "" || (m.kind == kindToken && m.joined == "") {
+		return nil, fmt.Errorf("keyword %q has nothing to match", kw)
+	}
+
+	switch m.kind {
+	case kindRegex:
+		m.pattern = strings.TrimSpace(text)
+		re, err := regexp.Compile("(?i)" + m.pattern)
+		if err != nil {
+			return nil, fmt.Errorf("keyword %q is not a valid regular expression: %w", kw, err)
+		}
+		m.re = re
+		if parsed, err := syntax.Parse(m.pattern, syntax.Perl); err == nil {
+			m.literal = literalLen(parsed)
+		}
+	case kindGlob:
+		if _, err := path.Match(m.pattern, ""); err != nil {
+			return nil, fmt.Errorf("keyword %q is not a valid glob: %w", kw, err)
+		}
+	}
+	return m, nil
+}
+
+// Len returns the length of the matched text, used to prefer more specific keywords.
+// For regular expressions this is the number of literal characters every match contains,
+// so `re:^bd\d+` counts as 2. For globs it is the length of the pattern itself.
+func (m *Matcher) Len() int {
+	switch m.kind {
+	case kindToken:
+		return len(m.joined)
+	case kindRegex:
+		return m.literal
+	}
+	return len(m.pattern)
+}
+
+// literalLen returns the number of literal characters in every match of a parsed regular
+// expression. Character classes, anchors and optional parts don't count; of alternatives
+// the shortest counts.
+func literalLen(re *syntax.Regexp) int {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return len(re.Rune)
+	case syntax.OpCapture, syntax.OpPlus:
+		return literalLen(re.Sub[0])
+	case syntax.OpRepeat:
+		return re.Min * literalLen(re.Sub[0])
+	case syntax.OpConcat:
+		total := 0
+		for _, sub := range re.Sub {
+			total += literalLen(sub)
+		}
+		return total
+	case syntax.OpAlternate:
+		shortest := literalLen(re.Sub[0])
+		for _, sub := range re.Sub[1:] {
+			shortest = min(shortest, literalLen(sub))
+		}
+		return shortest
+	}
+	return 0
+}
+
+// Match reports whether the keyword occurs in the name
+func (m *Matcher) Match(name Name) bool {
+	_, ok := m.Find(name)
+	return ok
+}
+
+// Find reports whether the keyword occurs in the name and where the first match starts,
+// as a fraction of the name from 0 (the start) towards 1 (the end). Globs always match
+// from the start.
+func (m *Matcher) Find(name Name) (float64, bool) {
+	switch m.kind {
+	case kindSubstring:
+		return relative(strings.Index(name.Lower, m.pattern), len(name.Lower))
+	case kindRegex:
+		if loc := m.re.FindStringIndex(name.Lower); loc != nil {
+			return relative(loc[0], len(name.Lower))
+		}
+		return 0, false
+	case kindGlob:
+		matched, _ := path.Match(m.pattern, name.Lower)
+		return 0, matched
+	}
+
+	// The keyword's tokens must line up with a run of whole name tokens. Comparing the
+	// concatenations lets "hihat" match "Hi-Hat" and "hi hat" match "HiHat".
+	for i := range name.Tokens {
+		joined := ""
+		for j := i; j < len(name.Tokens); j++ {
+			joined += name.Tokens[j]
+			if isWordForm(joined, m.joined) {
+				return relative(i, len(name.Tokens))
+			}
+			if len(joined) >= len(m.joined) {
+				break
+			}
+		}
+	}
+	return 0, false
+}
+
+// relative converts an index into a fraction of length; negative indexes mean no match
+func relative(index, length int) (float64, bool) {
+	if index < 0 {
+		return 0, false
+	}
+	return float64(index) / float64(length), true
+}
+
+// Covers reports whether every name matched by other is also matched by m, i.e. whether
+// m used as an exclusion would reject all of other's matches. Regular expressions and
+// globs are never reported as covering or covered.
+func (m *Matcher) Covers(other *Matcher) bool {
+	switch {
+	case m.kind == kindRegex || m.kind == kindGlob || other.kind == kindRegex || other.kind == kindGlob:
+		return false
+	case m.kind == kindSubstring && other.kind == kindSubstring:
+		return strings.Contains(other.pattern, m.pattern)
+	case m.kind == kindSubstring:
+		// The tokens of a plain keyword appear verbatim in every name it matches
+		for _, token := range Tokenize(other.pattern) {
+			if strings.Contains(token, m.pattern) {
+				return true
+			}
+		}
+		return false
+	case other.kind == kindSubstring:
+		// A substring keyword also matches inside words, where whole tokens never match
+		return false
+	default:
+		// A name may spell other's tokens as one word ("HiHat"), so only a keyword matching
+		// that whole word covers it: "hat" covers "hats" but not "hi hat"
+		return isWordForm(other.joined, m.joined)
+	}
+}
+
+// isWordForm reports whether token is word or a simple plural of it ("kicks", "boxes").
+// Only words ending in a hissing sound take "es", so "hat" doesn't match "hates".
+func isWordForm(token, word string) bool {
+	if !strings.HasPrefix(token, word) {
+		return false
+	}
+	switch token[len(word):] {
+	case "":
+		return true
+	case "es":
+		return takesES(word)
+	case "s":
+		return !takesES(word)
+	}
+	return false
+}
+
+// takesES reports whether the plural of word ends in "es"
+func takesES(word string) bool {
+	for _, suffix := range []string{"s", "x", "z", "ch", "sh"} {
+		if strings.HasSuffix(word, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+package keyword
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"Kick_01", []string{"kick", "01"}},
+		{"DeepHouseKick", []string{"deep", "house", "kick"}},
+		{"TR909Clap", []string{"tr", "909", "clap"}},
+		{"HHOpen-Long", []string{"hh", "open", "long"}},
+		{"hi-hat  closed", []string{"hi", "hat", "closed"}},
+		{"KICK", []string{"kick"}},
+		{"Shaker16th", []string{"shaker", "16", "th"}},
+		{"(Bass) [C#m]", []string{"bass", "c", "m"}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		if got := Tokenize(tt.input); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Tokenize(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		keyword  string
+		name     string
+		expected bool
+	}{
+		// Whole tokens only
+		{"sub", "Sub Drop", true},
+		{"sub", "Subtle Pad", false},
+		{"tom", "Custom Lead", false},
+		{"rim", "Prime Lead", false},
+		{"cup", "Hiccup FX", false},
+		{"bd", "BD_Heavy_01", true},
+		{"hh", "HHOpen", true},
+		// Token sequences, regardless of separators
+		{"hi hat", "HiHat_Open", true},
+		{"hi-hat", "Hi Hat 2", true},
+		{"hihat", "Hi-Hat 2", true},
+		{"closed hat", "Closed_Hat", true},
+		{"closed hat", "Hat Closed", false},
+		{"drum loop", "DrumLoop120", true},
+		// Plurals
+		{"kick", "Kicks Layered", true},
+		{"tom", "Toms Fill", true},
+		{"kick", "Kickstart", false},
+		{"crash", "Crashes Long", true},
+		{"box", "Boxes", true},
+		{"hat", "Hates", false},
+		{"tom", "Tomes", false},
+		{"rim", "Rimes", false},
+		{"box", "Boxs", false},
+		// Digits split from letters
+		{"808", "Kick808Long", true},
+		{"909", "TR909", true},
+		// Substring opt-in
+		{"substr:bass", "Bassline", true},
+		{"SUBSTR:Bass", "SlapBassGrowl", true},
+		{"bass", "Bassline", false},
+		// Regular expressions, case-insensitive
+		{`re:^bd\d+`, "BD01_Heavy", true},
+		{`re:^bd\d+`, "Heavy_BD01", false},
+		{`re:kick[_ -]?\d{2}`, "Kick 07 Punchy", true},
+		{`re:kick[_ -]?\d{2}`, "Kick_Punchy", false},
+		// Globs match the whole name
+		{"glob:*_808_*", "Long_808_Tail", true},
+		{"glob:*_808_*", "808_Tail", false},
+		{"glob:kick??", "KICK01", true},
+	}
+
+	for _, tt := range tests {
+		m, err := Compile(tt.keyword)
+		if err != nil {
+			t.Fatalf("Compile(%q) failed: %v", tt.keyword, err)
+		}
+		if got := m.Match(NewName(tt.name)); got != tt.expected {
+			t.Errorf("%q matching %q = %v, expected %v", tt.keyword, tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestCompileRejectsEmptyKeywords(t *testing.T) {
+	for _, kw := range []string{"", "  ", "-_", "substr:", "re:", "glob: "} {
+		if _, err := Compile(kw); err == nil {
+			t.Errorf("Compile(%q) should fail", kw)
+		}
+	}
+}
+
+func TestCompileRejectsInvalidPatterns(t *testing.T) {
+	for _, kw := range []string{"re:kick(", "re:[a-", "glob:kick[", "glob:[]a]"} {
+		_, err := Compile(kw)
+		if err == nil {
+			t.Errorf("Compile(%q) should fail", kw)
+			continue
+		}
+		if !strings.Contains(err.Error(), kw) {
+			t.Errorf("Error for %q should name the keyword, got: %v", kw, err)
+		}
+	}
+}
+
+func TestMatcherLen(t *testing.T) {
+	short, _ := Compile("hat")
+	long, _ := Compile("hi-hat")
+	substr, _ := Compile("substr:hat")
+
+	if short.Len() >= long.Len() {
+		t.Errorf("Expected %q to be more specific than %q", long.Keyword, short.Keyword)
+	}
+	if substr.Len() != short.Len() {
+		t.Errorf("The substr: prefix should not count towards length, got %d", substr.Len())
+	}
+}
+
+func TestMatcherLenRegex(t *testing.T) {
+	tests := []struct {
+		keyword  string
+		expected int
+	}{
+		{`re:hat`, 3},
+		{`re:^bd\d+$`, 2},
+		{`re:(open|closed)[ _-]?hat`, 7},
+		{`re:(hi)?hat`, 3},
+		{`re:(kick){2}`, 8},
+		{`re:.*`, 0},
+	}
+
+	for _, tt := range tests {
+		m, err := Compile(tt.keyword)
+		if err != nil {
+			t.Fatalf("Compile(%q) failed: %v", tt.keyword, err)
+		}
+		if got := m.Len(); got != tt.expected {
+			t.Errorf("Len of %q = %d, expected %d", tt.keyword, got, tt.expected)
+		}
+	}
+}
+
+func TestFindPosition(t *testing.T) {
+	tests := []struct {
+		keyword  string
+		name     string
+		expected float64
+	}{
+		{"kick", "Kick_01", 0},
+		{"hi hat", "Open HiHat 01", 0.25},
+		{"riser", "Vocal Chop FX Riser", 0.75},
+		{"substr:bass", "SlapBass", 0.5},
+		{`re:\d+`, "Kick01", 4.0 / 6.0},
+		{"glob:*kick*", "Deep Kick", 0},
+	}
+
+	for _, tt := range tests {
+		m, _ := Compile(tt.keyword)
+		pos, ok := m.Find(NewName(tt.name))
+		if !ok {
+			t.Errorf("%q should match %q", tt.keyword, tt.name)
+			continue
+		}
+		if pos != tt.expected {
+			t.Errorf("%q in %q found at %v, expected %v", tt.keyword, tt.name, pos, tt.expected)
+		}
+	}
+
+	m, _ := Compile("snare")
+	if _, ok := m.Find(NewName("Kick_01")); ok {
+		t.Error("Find should report no match")
+	}
+}
+
+func TestCovers(t *testing.T) {
+	tests := []struct {
+		exclude  string
+		include  string
+		expected bool
+	}{
+		{"hat", "hi hat", false},
+		{"hat", "hihat", false},
+		{"hi", "hi hat", false},
+		{"hat", "hat", true},
+		{"hat", "hats", true},
+		{"box", "boxes", true},
+		{"bo", "boxes", false},
+		{"hat", "hates", false},
+		{"tom", "tomes", false},
+		{"808", "808 kick", false},
+		{"hi hat", "hihats", true},
+		{"hats", "hat", false},
+		{"top hat", "hat", false},
+		{"hi-hat", "hihat", true},
+		{"sub", "subtle", false},
+		{"substr:sub", "sub", true},
+		{"substr:sub", "subbass", true},
+		{"substr:sub", "substr:subbass", true},
+		{"substr:subbass", "substr:sub", false},
+		{"sub", "substr:sub", false},
+		{"re:hat", "hi hat", false},
+		{"hat", "glob:*hat*", false},
+	}
+
+	for _, tt := range tests {
+		exclude, _ := Compile(tt.exclude)
+		include, _ := Compile(tt.include)
+		if got := exclude.Covers(include); got != tt.expected {
+			t.Errorf("%q covers %q = %v, expected %v", tt.exclude, tt.include, got, tt.expected)
+		}
+	}
+}
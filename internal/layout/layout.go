@@ -0,0 +1,180 @@
+package layout
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultTemplate reproduces the classic target/category/subcategory/filename layout
+const DefaultTemplate = "{category}/{subcategory}/{filename}"
+
+// Placeholders lists every placeholder a template may reference, with a short description
+var Placeholders = map[string]string{
+	"category":    "category folder name",
+	"subcategory": "subcategory folder name",
+	"filename":    "target file name including extension",
+	"name":        "target file name without extension",
+	"ext":         "file extension including the leading dot",
+	"bpm":         "tempo extracted from the filename",
+	"key":         "musical key extracted from the filename",
+	"camelot":     "Camelot code of the musical key",
+	"pack":        "first folder below the scanned source directory",
+	"samplerate":  "sample rate from the audio header",
+	"bitdepth":    "bit depth from the audio header",
+	"channels":    "channel count from the audio header",
+}
+
+// trimmedSeparators are removed from the edges of a segment after empty placeholders collapse
+const trimmedSeparators = " -_."
+
+// Template is a parsed target path layout such as "{category}/{bpm}/{name}{ext}"
+type Template struct {
+	raw      string
+	segments []segment
+}
+
+// segment is one path component of a template made of literal text and placeholders
+type segment []part
+
+// part is either literal text or a placeholder name
+type part struct {
+	literal     string
+	placeholder string
+}
+
+// Parse validates and parses a layout template.
+// Templates use "/" as the path separator, must be relative, may only reference known
+// placeholders, and must end with a segment containing {name} or {filename}.
+func Parse(template string) (*Template, error) {
+	if strings.TrimSpace(template) == "" {
+		return nil, fmt.Errorf("layout template cannot be empty")
+	}
+	if strings.HasPrefix(template, "/") || strings.Contains(template, `\`) {
+		return nil, fmt.Errorf("layout template %q must be a relative path using '/' separators", template)
+	}
+
+	t := &Template{raw: template}
+	for _, rawSegment := range strings.Split(template, "/") {
+		if rawSegment == "" {
+			return nil, fmt.Errorf("layout template %q contains an empty path segment", template)
+		}
+		if rawSegment == "." || rawSegment == ".." {
+			return nil, fmt.Errorf("layout template %q must not contain %q segments", template, rawSegment)
+		}
+
+		seg, err := parseSegment(rawSegment)
+		if err != nil {
+			return nil, fmt.Errorf("layout template %q: %w", template, err)
+		}
+		t.segments = append(t.segments, seg)
+	}
+
+	last := t.segments[len(t.segments)-1]
+	if !last.references("name") && !last.references("filename") {
+		return nil, fmt.Errorf("layout template %q must end with a segment containing {name} or {filename}", template)
+	}
+
+	return t, nil
+}
+
+// parseSegment splits a path segment into literal and placeholder parts
+func parseSegment(raw string) (segment, error) {
+	var seg segment
+	rest := raw
+	for rest != "" {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			seg = append(seg, part{literal: rest})
+			break
+		}
+		if rest[open] == '}' {
+			return nil, fmt.Errorf("unbalanced '}' in segment %q", raw)
+		}
+		if open > 0 {
+			seg = append(seg, part{literal: rest[:open]})
+		}
+
+		closing := strings.IndexAny(rest[open+1:], "{}")
+		if closing < 0 || rest[open+1+closing] == '{' {
+			return nil, fmt.Errorf("unbalanced '{' in segment %q", raw)
+		}
+		name := rest[open+1 : open+1+closing]
+		if _, ok := Placeholders[name]; !ok {
+			return nil, fmt.Errorf("unknown placeholder {%s} (available: %s)", name, strings.Join(placeholderNames(), ", "))
+		}
+		seg = append(seg, part{placeholder: name})
+		rest = rest[open+1+closing+1:]
+	}
+	return seg, nil
+}
+
+// references reports whether the segment uses the given placeholder
+func (s segment) references(name string) bool {
+	for _, p := range s {
+		if p.placeholder == name {
+			return true
+		}
+	}
+	return false
+}
+
+// render fills the segment with values. A segment whose placeholders are all empty is
+// dropped entirely; otherwise empty placeholders are removed and dangling separators trimmed.
+func (s segment) render(values map[string]string) string {
+	var builder strings.Builder
+	hasPlaceholder, hasValue, collapsed := false, false, false
+	for _, p := range s {
+		if p.placeholder == "" {
+			builder.WriteString(p.literal)
+			continue
+		}
+		hasPlaceholder = true
+		if value := values[p.placeholder]; value != "" {
+			hasValue = true
+			builder.WriteString(value)
+		} else {
+			// Drop the separator that joined the empty placeholder to what came before
+			collapsed = true
+			trimmed := strings.TrimRight(builder.String(), trimmedSeparators)
+			builder.Reset()
+			builder.WriteString(trimmed)
+		}
+	}
+	if hasPlaceholder && !hasValue {
+		return ""
+	}
+	if collapsed {
+		return strings.Trim(builder.String(), trimmedSeparators)
+	}
+	return builder.String()
+}
+
+// Render fills the template with values and returns a slash-separated relative path.
+// Values may themselves contain "/" to produce nested folders.
+func (t *Template) Render(values map[string]string) string {
+	var components []string
+	for _, seg := range t.segments {
+		for _, component := range strings.Split(seg.render(values), "/") {
+			if component != "" {
+				components = append(components, component)
+			}
+		}
+	}
+	return strings.Join(components, "/")
+}
+
+// String returns the original template text
+func (t *Template) String() string {
+	return t.raw
+}
+
+// placeholderNames returns the known placeholder names in sorted order
+func placeholderNames() []string {
+	names := make([]string, 0, len(Placeholders))
+	for name := range Placeholders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
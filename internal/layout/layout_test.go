@@ -0,0 +1,100 @@
+package layout
+
+import "testing"
+
+func TestParseValid(t *testing.T) {
+	templates := []string{
+		DefaultTemplate,
+		"{category}/{subcategory}/{bpm}/{key}/{name}{ext}",
+		"{pack}/{category}/{name}",
+		"{category}/{bpm}bpm/{name}_{key}{ext}",
+		"Samples/{category}/{filename}",
+	}
+
+	for _, template := range templates {
+		t.Run(template, func(t *testing.T) {
+			parsed, err := Parse(template)
+			if err != nil {
+				t.Fatalf("Parse should accept %q: %v", template, err)
+			}
+			if parsed.String() != template {
+				t.Errorf("Expected String() %q, got %q", template, parsed.String())
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	templates := []string{
+		"",
+		"/{category}/{filename}",
+		`{category}\{filename}`,
+		"{category}//{filename}",
+		"{category}/../{filename}",
+		"{category}/{unknown}/{filename}",
+		"{category}/{filename",
+		"{category}/filename}",
+		"{category}/{{name}}",
+		"{category}/{}/{filename}",
+		"{filename}/{category}",
+		"{category}/{bpm}",
+	}
+
+	for _, template := range templates {
+		t.Run(template, func(t *testing.T) {
+			if _, err := Parse(template); err == nil {
+				t.Errorf("Parse should reject %q", template)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	values := map[string]string{
+		"category":    "bass",
+		"subcategory": "",
+		"filename":    "Bass_Am_128bpm.wav",
+		"name":        "Bass_Am_128bpm",
+		"ext":         ".wav",
+		"bpm":         "128",
+		"key":         "",
+		"pack":        "PackA",
+	}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{DefaultTemplate, "bass/Bass_Am_128bpm.wav"},
+		{"{category}/{subcategory}/{bpm}/{key}/{name}{ext}", "bass/128/Bass_Am_128bpm.wav"},
+		{"{pack}/{category}/{name}", "PackA/bass/Bass_Am_128bpm"},
+		{"{category}/{key}-{bpm}/{filename}", "bass/128/Bass_Am_128bpm.wav"},
+		{"{category}/{key}mode/{filename}", "bass/Bass_Am_128bpm.wav"},
+		{"{category}/{name}_{key}{ext}", "bass/Bass_Am_128bpm.wav"},
+		{"_{category}/{filename}", "_bass/Bass_Am_128bpm.wav"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			parsed, err := Parse(tt.template)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if got := parsed.Render(values); got != tt.expected {
+				t.Errorf("Render() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRenderNestedValue(t *testing.T) {
+	parsed, err := Parse(DefaultTemplate)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	got := parsed.Render(map[string]string{"category": "drums", "subcategory": "cymbal/crash", "filename": "crash.wav"})
+	if got != "drums/cymbal/crash/crash.wav" {
+		t.Errorf("Expected nested path, got %q", got)
+	}
+}
@@ -0,0 +1,282 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/theclifmeister/sample-shifter/internal/categorizer"
+	"github.com/theclifmeister/sample-shifter/internal/config"
+	"github.com/theclifmeister/sample-shifter/internal/keyword"
+	"github.com/theclifmeister/sample-shifter/internal/scanner"
+)
+
+// Rules reported by Lint
+const (
+	// RuleDuplicateKeyword: the same keyword is listed in two categories, or twice in one
+	RuleDuplicateKeyword = "duplicate-keyword"
+	// RuleShadowedKeyword: a file named after the keyword goes to another category
+	RuleShadowedKeyword = "shadowed-keyword"
+	// RuleUncoveredSubcategoryKeyword: no keyword of the category matches a subcategory
+	// keyword, so it only fires together with another keyword of the category
+	RuleUncoveredSubcategoryKeyword = "uncovered-subcategory-keyword"
+	// RuleDuplicatePriority: two categories share a priority
+	RuleDuplicatePriority = "duplicate-priority"
+	// RuleEmptySubcategory: a subcategory without keywords can never be chosen
+	RuleEmptySubcategory = "empty-subcategory"
+	// RuleConfigWarning: a warning found while validating the configuration
+	RuleConfigWarning = "config-warning"
+)
+
+// Severity tells whether an issue should fail a lint run
+type Severity string
+
+const (
+	// SeverityWarning marks a rule that can't fire or fires unpredictably
+	SeverityWarning Severity = "warning"
+	// SeverityInfo marks a note on how rules interact that is often intended, such as
+	// subcategory keywords that only refine their category
+	SeverityInfo Severity = "info"
+)
+
+// Issue is a rule of the configuration that can't fire or fires unpredictably
+type Issue struct {
+	Rule        string   `json:"rule"`
+	Severity    Severity `json:"severity"`
+	Category    string   `json:"category,omitempty"`
+	Subcategory string   `json:"subcategory,omitempty"`
+	Keyword     string   `json:"keyword,omitempty"`
+	Message     string   `json:"message"`
+}
+
+// String formats the issue for display
+func (i Issue) String() string {
+	return fmt.Sprintf("[%s] %s", i.Rule, i.Message)
+}
+
+// categoryKeyword is one keyword of a category, compiled
+type categoryKeyword struct {
+	category string
+	matcher  *keyword.Matcher
+}
+
+// keywordKey identifies a keyword of a category as configured
+type keywordKey struct {
+	category string
+	keyword  string
+}
+
+// Lint checks a validated configuration for rules that can never fire or whose outcome
+// depends on priorities and listing order. Issues are grouped by check, with categories in
+// priority order.
+func Lint(cfg *config.CategoryConfig) []Issue {
+	categories := make([]config.CategoryDefinition, len(cfg.Categories))
+	copy(categories, cfg.Categories)
+	sort.SliceStable(categories, func(i, j int) bool {
+		return categories[i].Priority < categories[j].Priority
+	})
+
+	var issues []Issue
+	issues = append(issues, duplicatePriorities(categories)...)
+
+	duplicates, duplicated := duplicateKeywords(categories)
+	issues = append(issues, duplicates...)
+	issues = append(issues, shadowedKeywords(cfg, categories, duplicated)...)
+
+	for _, cat := range categories {
+		issues = append(issues, subcategoryIssues(cat)...)
+	}
+
+	for _, warning := range cfg.Warnings {
+		issues = append(issues, Issue{Rule: RuleConfigWarning, Message: warning})
+	}
+
+	for i := range issues {
+		issues[i].Severity = SeverityWarning
+		if issues[i].Rule == RuleUncoveredSubcategoryKeyword {
+			issues[i].Severity = SeverityInfo
+		}
+	}
+	return issues
+}
+
+// Warnings counts the issues that should fail a lint run
+func Warnings(issues []Issue) int {
+	count := 0
+	for _, issue := range issues {
+		if issue.Severity == SeverityWarning {
+			count++
+		}
+	}
+	return count
+}
+
+// duplicatePriorities reports categories that share a priority with an earlier one;
+// categories are sorted by priority
+func duplicatePriorities(categories []config.CategoryDefinition) []Issue {
+	var issues []Issue
+	for i := 1; i < len(categories); i++ {
+		prev, cat := categories[i-1], categories[i]
+		if cat.Priority == prev.Priority {
+			issues = append(issues, Issue{
+				Rule:     RuleDuplicatePriority,
+				Category: cat.Name,
+				Message: fmt.Sprintf("categories %s and %s both have priority %d; ties between them depend on the order they are listed in",
+					prev.Name, cat.Name, cat.Priority),
+			})
+		}
+	}
+	return issues
+}
+
+// duplicateKeywords reports keywords that match exactly the same names as a keyword of a
+// higher-priority category, or that are listed twice in a category, and returns the
+// duplicates
+func duplicateKeywords(categories []config.CategoryDefinition) ([]Issue, map[keywordKey]bool) {
+	var issues []Issue
+	duplicated := make(map[keywordKey]bool)
+
+	var seen []categoryKeyword
+	for _, cat := range categories {
+		for _, m := range compile(cat.Keywords) {
+			current := categoryKeyword{category: cat.Name, matcher: m}
+			for _, earlier := range seen {
+				// Separator variants ("hi-hat", "hihat") within a category are harmless
+				sameCategory := earlier.category == cat.Name
+				if !equivalent(earlier.matcher, m) || (sameCategory && !strings.EqualFold(earlier.matcher.Keyword, m.Keyword)) {
+					continue
+				}
+				message := fmt.Sprintf("keyword %q of %s duplicates %q of %s", m.Keyword, cat.Name, earlier.matcher.Keyword, earlier.category)
+				if sameCategory {
+					message = fmt.Sprintf("keyword %q of %s duplicates %q of the same category", m.Keyword, cat.Name, earlier.matcher.Keyword)
+				}
+				issues = append(issues, Issue{Rule: RuleDuplicateKeyword, Category: cat.Name, Keyword: m.Keyword, Message: message})
+				duplicated[keywordKey{category: cat.Name, keyword: m.Keyword}] = true
+				break
+			}
+			seen = append(seen, current)
+		}
+	}
+	return issues, duplicated
+}
+
+// shadowedKeywords categorizes a file named after each keyword and reports the keywords
+// whose file goes to another category. Duplicates are already reported, and regular
+// expressions, globs and categories that only match folder names can't be checked this way.
+func shadowedKeywords(cfg *config.CategoryConfig, categories []config.CategoryDefinition, duplicated map[keywordKey]bool) []Issue {
+	c, err := categorizer.NewCategorizer(cfg)
+	if err != nil {
+		// Validation reports invalid layouts
+		return nil
+	}
+
+	var issues []Issue
+	for _, cat := range categories {
+		if !cat.Source(cfg.UsesPathEvidence()).UsesFilename() {
+			continue
+		}
+		for _, m := range compile(cat.Keywords) {
+			text, ok := sampleText(m)
+			if !ok || duplicated[keywordKey{category: cat.Name, keyword: m.Keyword}] {
+				continue
+			}
+
+			fileName := text + ".wav"
+			result := c.Categorize(scanner.SampleFile{
+				OriginalPath: fileName,
+				FileName:     fileName,
+				Extension:    ".wav",
+				RelativePath: fileName,
+			}, "", false)
+			if result.Category == categorizer.Category(cat.Name) || result.Category == categorizer.CategoryUncategorized {
+				continue
+			}
+
+			message := fmt.Sprintf("keyword %q of %s is shadowed: a file named %q goes to %s", m.Keyword, cat.Name, fileName, result.Category)
+			if len(result.Matches) > 0 {
+				message += fmt.Sprintf(" (keyword %q)", result.Matches[0].Keyword)
+			}
+			issues = append(issues, Issue{Rule: RuleShadowedKeyword, Category: cat.Name, Keyword: m.Keyword, Message: message})
+		}
+	}
+	return issues
+}
+
+// subcategoryIssues reports empty subcategories and subcategory keywords that no keyword
+// of the category matches. Subcategories are only resolved once the category has been
+// chosen, so such keywords need another keyword of the category in the same name.
+func subcategoryIssues(cat config.CategoryDefinition) []Issue {
+	categoryKeywords := compile(cat.Keywords)
+
+	var issues []Issue
+	cat.Subcategories.Walk(func(path string, sub config.SubcategoryDefinition) {
+		if len(sub.Keywords) == 0 && len(sub.Subcategories) == 0 {
+			issues = append(issues, Issue{
+				Rule:        RuleEmptySubcategory,
+				Category:    cat.Name,
+				Subcategory: path,
+				Message:     fmt.Sprintf("subcategory %s/%s has no keywords and can never be chosen", cat.Name, path),
+			})
+			return
+		}
+
+		for _, m := range compile(sub.Keywords) {
+			if covered(categoryKeywords, m) {
+				continue
+			}
+			issues = append(issues, Issue{
+				Rule:        RuleUncoveredSubcategoryKeyword,
+				Category:    cat.Name,
+				Subcategory: path,
+				Keyword:     m.Keyword,
+				Message: fmt.Sprintf("keyword %q of subcategory %s/%s matches no keyword of %s, so it only applies to files that also match one of them",
+					m.Keyword, cat.Name, path, cat.Name),
+			})
+		}
+	})
+	return issues
+}
+
+// covered reports whether some category keyword matches every name the subcategory keyword
+// matches. Regular expressions and globs in subcategories are assumed to be covered.
+func covered(categoryKeywords []*keyword.Matcher, m *keyword.Matcher) bool {
+	text, ok := sampleText(m)
+	if !ok {
+		return true
+	}
+	for _, ck := range categoryKeywords {
+		if equivalent(ck, m) || ck.Covers(m) || ck.Match(keyword.NewName(text)) {
+			return true
+		}
+	}
+	return false
+}
+
+// equivalent reports whether two keywords match exactly the same names
+func equivalent(a, b *keyword.Matcher) bool {
+	return strings.EqualFold(a.Keyword, b.Keyword) || (a.Covers(b) && b.Covers(a))
+}
+
+// sampleText returns a name matched by a plain or substring keyword: the keyword itself
+// without prefix. Regular expressions and globs have no such name.
+func sampleText(m *keyword.Matcher) (string, bool) {
+	lower := strings.ToLower(m.Keyword)
+	switch {
+	case strings.HasPrefix(lower, keyword.RegexPrefix), strings.HasPrefix(lower, keyword.GlobPrefix):
+		return "", false
+	case strings.HasPrefix(lower, keyword.SubstringPrefix):
+		return strings.TrimSpace(m.Keyword[len(keyword.SubstringPrefix):]), true
+	}
+	return strings.TrimSpace(m.Keyword), true
+}
+
+// compile compiles a keyword list, dropping invalid keywords (validation reports them)
+func compile(keywords []string) []*keyword.Matcher {
+	matchers := make([]*keyword.Matcher, 0, len(keywords))
+	for _, kw := range keywords {
+		if m, err := keyword.Compile(kw); err == nil {
+			matchers = append(matchers, m)
+		}
+	}
+	return matchers
+}
@@ -0,0 +1,142 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/theclifmeister/sample-shifter/internal/config"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name       string
+		categories []config.CategoryDefinition
+		rules      []string
+	}{
+		{
+			name: "clean",
+			categories: []config.CategoryDefinition{
+				{Name: "drums", Priority: 1, Keywords: []string{"kick", "hihat", "hi-hat"}, Subcategories: config.Subcategories{
+					{Name: "kick", Keywords: []string{"kick", "808 kick"}},
+				}},
+				{Name: "bass", Priority: 2, Keywords: []string{"bass"}},
+			},
+		},
+		{
+			name: "duplicate priority",
+			categories: []config.CategoryDefinition{
+				{Name: "drums", Priority: 1, Keywords: []string{"kick"}},
+				{Name: "bass", Priority: 1, Keywords: []string{"bass"}},
+			},
+			rules: []string{RuleDuplicatePriority},
+		},
+		{
+			name: "duplicate keyword across categories",
+			categories: []config.CategoryDefinition{
+				{Name: "drums", Priority: 1, Keywords: []string{"kick"}},
+				{Name: "oneshots", Priority: 2, Keywords: []string{"Kick", "shot"}},
+			},
+			rules: []string{RuleDuplicateKeyword},
+		},
+		{
+			name: "duplicate keyword in one category",
+			categories: []config.CategoryDefinition{
+				{Name: "drums", Priority: 1, Keywords: []string{"kick", "kick"}},
+			},
+			rules: []string{RuleDuplicateKeyword},
+		},
+		{
+			name: "shadowed keyword",
+			categories: []config.CategoryDefinition{
+				{Name: "oneshots", Priority: 1, Keywords: []string{"substr:kick"}, Weight: 2},
+				{Name: "drums", Priority: 2, Keywords: []string{"kick", "snare"}},
+			},
+			rules: []string{RuleShadowedKeyword},
+		},
+		{
+			name: "path-only categories are not checked for shadowing",
+			categories: []config.CategoryDefinition{
+				{Name: "oneshots", Priority: 1, Keywords: []string{"substr:kick"}, Weight: 2},
+				{Name: "drums", Priority: 2, Keywords: []string{"kick"}, MatchSource: config.MatchPath},
+			},
+		},
+		{
+			name: "uncovered and empty subcategories",
+			categories: []config.CategoryDefinition{
+				{Name: "drums", Priority: 1, Keywords: []string{"kick"}, Subcategories: config.Subcategories{
+					{Name: "808", Keywords: []string{"808"}},
+					{Name: "empty"},
+					{Name: "pattern", Keywords: []string{"re:^bd\\d+"}},
+				}},
+			},
+			rules: []string{RuleUncoveredSubcategoryKeyword, RuleEmptySubcategory},
+		},
+		{
+			name: "config warnings",
+			categories: []config.CategoryDefinition{
+				{Name: "drums", Priority: 1, Keywords: []string{"rim"}, Subcategories: config.Subcategories{
+					{Name: "snare", Keywords: []string{"rim"}},
+					{Name: "rim", Keywords: []string{"rim"}},
+				}},
+			},
+			rules: []string{RuleConfigWarning},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.CategoryConfig{Categories: tt.categories}
+			if err := cfg.Validate(); err != nil {
+				t.Fatalf("Config should be valid: %v", err)
+			}
+
+			issues := Lint(cfg)
+			var rules []string
+			for _, issue := range issues {
+				rules = append(rules, issue.Rule)
+			}
+			if len(rules) != len(tt.rules) {
+				t.Fatalf("Expected rules %v, got %v", tt.rules, issues)
+			}
+			for i := range rules {
+				if rules[i] != tt.rules[i] {
+					t.Errorf("Expected rules %v, got %v", tt.rules, rules)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestLintSeverity(t *testing.T) {
+	cfg := &config.CategoryConfig{Categories: []config.CategoryDefinition{
+		{Name: "drums", Priority: 1, Keywords: []string{"kick"}, Subcategories: config.Subcategories{
+			{Name: "snare", Keywords: []string{"snare"}},
+			{Name: "empty"},
+		}},
+	}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Config should be valid: %v", err)
+	}
+
+	issues := Lint(cfg)
+	for _, issue := range issues {
+		expected := SeverityWarning
+		if issue.Rule == RuleUncoveredSubcategoryKeyword {
+			expected = SeverityInfo
+		}
+		if issue.Severity != expected {
+			t.Errorf("Expected %s to be a %s, got %s", issue.Rule, expected, issue.Severity)
+		}
+	}
+	if count := Warnings(issues); count != 1 {
+		t.Errorf("Expected only the empty subcategory to count as a warning, got %d", count)
+	}
+}
+
+func TestDefaultConfigLintsClean(t *testing.T) {
+	for _, issue := range Lint(config.GetDefaultConfig()) {
+		if issue.Severity != SeverityInfo {
+			t.Errorf("Default configuration should lint clean, got %s", issue)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redrawInterval limits how often the interactive status line is repainted
+const redrawInterval = 100 * time.Millisecond
+
+// Reporter tracks file and byte progress across concurrent workers. On a terminal it keeps
+// a single status line (files, bytes, throughput, ETA) at the bottom of the output; otherwise
+// every completed file is written as a plain log line. All methods are safe for concurrent use.
+type Reporter struct {
+	mu          sync.Mutex
+	out         io.Writer
+	interactive bool
+	totalFiles  int
+	totalBytes  int64
+	doneFiles   int
+	doneBytes   int64
+	start       time.Time
+	lastDraw    time.Time
+	now         func() time.Time
+}
+
+// New creates a reporter for totalFiles files of totalBytes bytes in total
+func New(out io.Writer, totalFiles int, totalBytes int64, interactive bool) *Reporter {
+	r := &Reporter{
+		out:         out,
+		interactive: interactive,
+		totalFiles:  totalFiles,
+		totalBytes:  totalBytes,
+		now:         time.Now,
+	}
+	r.start = r.now()
+	return r
+}
+
+// IsTerminal reports whether f is attached to a terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Done marks a file of the given size as finished. In plain mode the message is logged
+// with a [done/total] prefix; in interactive mode only the status line is updated.
+func (r *Reporter) Done(bytes int64, format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.doneFiles++
+	r.doneBytes += bytes
+
+	if !r.interactive {
+		fmt.Fprintf(r.out, "[%d/%d] %s\n", r.doneFiles, r.totalFiles, fmt.Sprintf(format, args...))
+		return
+	}
+	if r.doneFiles == r.totalFiles || r.now().Sub(r.lastDraw) >= redrawInterval {
+		r.draw()
+	}
+}
+
+// Fail marks a file of the given size as finished unsuccessfully. The message is always
+// written, above the status line in interactive mode.
+func (r *Reporter) Fail(bytes int64, format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.doneFiles++
+	r.doneBytes += bytes
+
+	if !r.interactive {
+		fmt.Fprintf(r.out, "[%d/%d] %s\n", r.doneFiles, r.totalFiles, fmt.Sprintf(format, args...))
+		return
+	}
+	fmt.Fprintf(r.out, "\r\033[K%s\n", fmt.Sprintf(format, args...))
+	r.draw()
+}
+
+// Finish ends the status line and returns the elapsed time
+func (r *Reporter) Finish() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.interactive {
+		r.draw()
+		fmt.Fprintln(r.out)
+	}
+	return r.now().Sub(r.start)
+}
+
+// Status returns the current one-line progress summary
+func (r *Reporter) Status() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status()
+}
+
+// draw repaints the status line; the caller must hold the lock
+func (r *Reporter) draw() {
+	r.lastDraw = r.now()
+	fmt.Fprintf(r.out, "\r\033[K%s", r.status())
+}
+
+// status formats the progress summary; the caller must hold the lock
+func (r *Reporter) status() string {
+	elapsed := r.now().Sub(r.start)
+
+	parts := []string{
+		fmt.Sprintf("%d/%d files", r.doneFiles, r.totalFiles),
+		fmt.Sprintf("%s/%s", FormatBytes(r.doneBytes), FormatBytes(r.totalBytes)),
+	}
+
+	if elapsed > 0 && r.doneBytes > 0 {
+		rate := float64(r.doneBytes) / elapsed.Seconds()
+		parts = append(parts, FormatBytes(int64(rate))+"/s")
+		if remaining := r.totalBytes - r.doneBytes; remaining > 0 {
+			eta := time.Duration(float64(remaining) / rate * float64(time.Second))
+			parts = append(parts, "ETA "+FormatDuration(eta))
+		}
+	}
+
+	return strings.Join(parts, "  ")
+}
+
+// FormatBytes renders a byte count with a binary unit, e.g. "1.5 GB"
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	value := float64(n)
+	units := []string{"KB", "MB", "GB", "TB", "PB"}
+	i := -1
+	for value >= unit && i < len(units)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", value, units[i])
+}
+
+// FormatDuration renders a duration rounded to whole seconds, e.g. "1h2m3s"
+func FormatDuration(d time.Duration) string {
+	if d < time.Second {
+		return "0s"
+	}
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,115 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReporterPlainOutput(t *testing.T) {
+	var buf bytes.Buffer
+	r := New(&buf, 2, 3000, false)
+
+	r.Done(1000, "Copying: %s", "a.wav")
+	r.Fail(2000, "ERROR: %s", "b.wav")
+	r.Finish()
+
+	expected := "[1/2] Copying: a.wav\n[2/2] ERROR: b.wav\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%q\nexpected:\n%q", buf.String(), expected)
+	}
+}
+
+func TestReporterStatus(t *testing.T) {
+	var buf bytes.Buffer
+	r := New(&buf, 4, 4*1024*1024, true)
+
+	clock := r.start
+	r.now = func() time.Time { return clock }
+
+	if status := r.Status(); status != "0/4 files  0 B/4.0 MB" {
+		t.Errorf("Unexpected initial status %q", status)
+	}
+
+	clock = clock.Add(2 * time.Second)
+	r.Done(1024*1024, "ignored")
+	status := r.Status()
+	for _, want := range []string{"1/4 files", "1.0 MB/4.0 MB", "512.0 KB/s", "ETA 6s"} {
+		if !strings.Contains(status, want) {
+			t.Errorf("Expected status %q to contain %q", status, want)
+		}
+	}
+
+	if strings.Contains(buf.String(), "ignored") {
+		t.Error("Interactive mode should not log completed files")
+	}
+
+	r.Fail(0, "ERROR: failed.wav")
+	if !strings.Contains(buf.String(), "ERROR: failed.wav\n") {
+		t.Error("Interactive mode should always show failures")
+	}
+	if !strings.Contains(buf.String(), "\r") {
+		t.Error("Interactive mode should redraw the status line")
+	}
+}
+
+func TestReporterConcurrentCounts(t *testing.T) {
+	var buf bytes.Buffer
+	r := New(&buf, 100, 100, false)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Done(1, "file")
+		}()
+	}
+	wg.Wait()
+
+	if status := r.Status(); !strings.HasPrefix(status, "100/100 files  100 B/100 B") {
+		t.Errorf("Unexpected status after concurrent updates: %q", status)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 100 {
+		t.Errorf("Expected 100 log lines, got %d", lines)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n        int64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KB"},
+		{1536, "1.5 KB"},
+		{5 * 1024 * 1024 * 1024, "5.0 GB"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatBytes(tt.n); got != tt.expected {
+			t.Errorf("FormatBytes(%d) = %q, expected %q", tt.n, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d        time.Duration
+		expected string
+	}{
+		{300 * time.Millisecond, "0s"},
+		{1500 * time.Millisecond, "2s"},
+		{62 * time.Second, "1m2s"},
+		{time.Hour + 2*time.Minute, "1h2m0s"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatDuration(tt.d); got != tt.expected {
+			t.Errorf("FormatDuration(%v) = %q, expected %q", tt.d, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package scanner
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedFormat is returned by ReadAudioInfo for formats whose headers are not parsed
+var ErrUnsupportedFormat = errors.New("unsupported audio format")
+
+// AudioInfo holds technical metadata read from an audio file header
+type AudioInfo struct {
+	Format     string
+	SampleRate int
+	BitDepth   int
+	Channels   int
+	Frames     int64
+	Duration   time.Duration
+}
+
+// ReadAudioInfo reads the header of a WAV or AIFF file and returns its metadata.
+// Other formats return ErrUnsupportedFormat; unreadable or corrupt headers return a descriptive error.
+func ReadAudioInfo(path string) (*AudioInfo, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".wav" && ext != ".aif" && ext != ".aiff" {
+		return nil, ErrUnsupportedFormat
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	return readAudioInfo(file, stat.Size())
+}
+
+// readAudioInfo detects the container from its magic bytes and dispatches to the matching parser
+func readAudioInfo(r io.ReadSeeker, size int64) (*AudioInfo, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("file too short for an audio header")
+	}
+
+	switch {
+	case string(header[0:4]) == "RIFF" && string(header[8:12]) == "WAVE":
+		return readWAV(r, size)
+	case string(header[0:4]) == "FORM" && (string(header[8:12]) == "AIFF" || string(header[8:12]) == "AIFC"):
+		return readAIFF(r, size)
+	case string(header[0:4]) == "RF64":
+		return nil, fmt.Errorf("RF64 wave files are not supported")
+	default:
+		return nil, fmt.Errorf("unrecognized header %q", header[0:4])
+	}
+}
+
+// chunk is a single RIFF/IFF chunk header
+type chunk struct {
+	id     string
+	size   int64
+	offset int64
+}
+
+// nextChunk reads the next chunk header using the given byte order
+func nextChunk(r io.ReadSeeker, order binary.ByteOrder) (chunk, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return chunk{}, err
+	}
+	offset, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return chunk{}, err
+	}
+	return chunk{
+		id:     string(header[0:4]),
+		size:   int64(order.Uint32(header[4:8])),
+		offset: offset,
+	}, nil
+}
+
+// skipChunk seeks past the chunk body including the pad byte for odd-sized chunks
+func skipChunk(r io.ReadSeeker, c chunk) error {
+	_, err := r.Seek(c.offset+c.size+c.size%2, io.SeekStart)
+	return err
+}
+
+// readWAV walks the RIFF chunks looking for "fmt " and "data"
+func readWAV(r io.ReadSeeker, size int64) (*AudioInfo, error) {
+	var info *AudioInfo
+	var blockAlign int
+	dataSize := int64(-1)
+
+	for {
+		c, err := nextChunk(r, binary.LittleEndian)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chunk: %w", err)
+		}
+
+		switch c.id {
+		case "fmt ":
+			if c.size < 16 {
+				return nil, fmt.Errorf("fmt chunk too short (%d bytes)", c.size)
+			}
+			var fmtChunk [16]byte
+			if _, err := io.ReadFull(r, fmtChunk[:]); err != nil {
+				return nil, fmt.Errorf("truncated fmt chunk")
+			}
+			info = &AudioInfo{
+				Format:     "wav",
+				Channels:   int(binary.LittleEndian.Uint16(fmtChunk[2:4])),
+				SampleRate: int(binary.LittleEndian.Uint32(fmtChunk[4:8])),
+				BitDepth:   int(binary.LittleEndian.Uint16(fmtChunk[14:16])),
+			}
+			blockAlign = int(binary.LittleEndian.Uint16(fmtChunk[12:14]))
+		case "data":
+			dataSize = c.size
+			// Some writers leave the size unset or wrong; never trust it past the end of the file
+			if remaining := size - c.offset; dataSize > remaining {
+				dataSize = remaining
+			}
+		}
+
+		if info != nil && dataSize >= 0 {
+			break
+		}
+		if err := skipChunk(r, c); err != nil {
+			return nil, fmt.Errorf("failed to skip chunk %q: %w", c.id, err)
+		}
+	}
+
+	if info == nil {
+		return nil, fmt.Errorf("missing fmt chunk")
+	}
+	if dataSize < 0 {
+		return nil, fmt.Errorf("missing data chunk")
+	}
+	if info.Channels == 0 || info.SampleRate == 0 || blockAlign == 0 {
+		return nil, fmt.Errorf("invalid fmt chunk (channels=%d, sample rate=%d, block align=%d)",
+			info.Channels, info.SampleRate, blockAlign)
+	}
+
+	info.Frames = dataSize / int64(blockAlign)
+	info.Duration = framesToDuration(info.Frames, info.SampleRate)
+	return info, nil
+}
+
+// readAIFF walks the IFF chunks looking for "COMM" and "SSND"
+func readAIFF(r io.ReadSeeker, size int64) (*AudioInfo, error) {
+	var info *AudioInfo
+	foundSound := false
+
+	for {
+		c, err := nextChunk(r, binary.BigEndian)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chunk: %w", err)
+		}
+
+		switch c.id {
+		case "COMM":
+			if c.size < 18 {
+				return nil, fmt.Errorf("COMM chunk too short (%d bytes)", c.size)
+			}
+			var comm [18]byte
+			if _, err := io.ReadFull(r, comm[:]); err != nil {
+				return nil, fmt.Errorf("truncated COMM chunk")
+			}
+			info = &AudioInfo{
+				Format:     "aiff",
+				Channels:   int(binary.BigEndian.Uint16(comm[0:2])),
+				Frames:     int64(binary.BigEndian.Uint32(comm[2:6])),
+				BitDepth:   int(binary.BigEndian.Uint16(comm[6:8])),
+				SampleRate: int(math.Round(parseExtended(comm[8:18]))),
+			}
+		case "SSND":
+			foundSound = true
+		}
+
+		if info != nil && foundSound {
+			break
+		}
+		if err := skipChunk(r, c); err != nil {
+			return nil, fmt.Errorf("failed to skip chunk %q: %w", c.id, err)
+		}
+	}
+
+	if info == nil {
+		return nil, fmt.Errorf("missing COMM chunk")
+	}
+	if !foundSound && info.Frames > 0 {
+		return nil, fmt.Errorf("missing SSND chunk")
+	}
+	if info.Channels == 0 || info.SampleRate <= 0 {
+		return nil, fmt.Errorf("invalid COMM chunk (channels=%d, sample rate=%d)", info.Channels, info.SampleRate)
+	}
+
+	info.Duration = framesToDuration(info.Frames, info.SampleRate)
+	return info, nil
+}
+
+// parseExtended decodes an 80-bit IEEE 754 extended precision float as used by AIFF sample rates
+func parseExtended(b []byte) float64 {
+	exponent := int(binary.BigEndian.Uint16(b[0:2]) & 0x7FFF)
+	mantissa := binary.BigEndian.Uint64(b[2:10])
+	if exponent == 0 && mantissa == 0 {
+		return 0
+	}
+	value := math.Ldexp(float64(mantissa), exponent-16383-63)
+	if b[0]&0x80 != 0 {
+		value = -value
+	}
+	return value
+}
+
+// framesToDuration converts a frame count at the given sample rate into a duration
+func framesToDuration(frames int64, sampleRate int) time.Duration {
+	return time.Duration(float64(frames) / float64(sampleRate) * float64(time.Second))
+}
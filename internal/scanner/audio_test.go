@@ -0,0 +1,205 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// buildWAV creates a minimal PCM WAV file with silent frames
+func buildWAV(sampleRate, bitDepth, channels, frames int) []byte {
+	blockAlign := channels * bitDepth / 8
+	dataSize := frames * blockAlign
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+dataSize))
+	buf.WriteString("WAVE")
+	buf.WriteString("fmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, uint16(1))
+	binary.Write(&buf, binary.LittleEndian, uint16(channels))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate*blockAlign))
+	binary.Write(&buf, binary.LittleEndian, uint16(blockAlign))
+	binary.Write(&buf, binary.LittleEndian, uint16(bitDepth))
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(dataSize))
+	buf.Write(make([]byte, dataSize))
+	return buf.Bytes()
+}
+
+// buildAIFF creates a minimal AIFF file with silent frames
+func buildAIFF(sampleRate, bitDepth, channels, frames int) []byte {
+	dataSize := frames * channels * bitDepth / 8
+
+	var buf bytes.Buffer
+	buf.WriteString("FORM")
+	binary.Write(&buf, binary.BigEndian, uint32(4+26+16+dataSize))
+	buf.WriteString("AIFF")
+	buf.WriteString("COMM")
+	binary.Write(&buf, binary.BigEndian, uint32(18))
+	binary.Write(&buf, binary.BigEndian, uint16(channels))
+	binary.Write(&buf, binary.BigEndian, uint32(frames))
+	binary.Write(&buf, binary.BigEndian, uint16(bitDepth))
+	buf.Write(encodeExtended(sampleRate))
+	buf.WriteString("SSND")
+	binary.Write(&buf, binary.BigEndian, uint32(8+dataSize))
+	binary.Write(&buf, binary.BigEndian, uint32(0))
+	binary.Write(&buf, binary.BigEndian, uint32(0))
+	buf.Write(make([]byte, dataSize))
+	return buf.Bytes()
+}
+
+// encodeExtended encodes a positive integer as an 80-bit extended float
+func encodeExtended(value int) []byte {
+	exponent := 16383 + 63
+	mantissa := uint64(value)
+	for mantissa&(1<<63) == 0 {
+		mantissa <<= 1
+		exponent--
+	}
+	out := make([]byte, 10)
+	binary.BigEndian.PutUint16(out[0:2], uint16(exponent))
+	binary.BigEndian.PutUint64(out[2:10], mantissa)
+	return out
+}
+
+func TestReadAudioInfo(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name       string
+		data       []byte
+		format     string
+		sampleRate int
+		bitDepth   int
+		channels   int
+		duration   time.Duration
+	}{
+		{"mono.wav", buildWAV(44100, 16, 1, 44100), "wav", 44100, 16, 1, time.Second},
+		{"stereo.wav", buildWAV(48000, 24, 2, 24000), "wav", 48000, 24, 2, 500 * time.Millisecond},
+		{"stereo.aif", buildAIFF(44100, 16, 2, 88200), "aiff", 44100, 16, 2, 2 * time.Second},
+		{"mono.aiff", buildAIFF(96000, 24, 1, 9600), "aiff", 96000, 24, 1, 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, tt.name)
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatalf("Failed to write test file: %v", err)
+			}
+
+			info, err := ReadAudioInfo(path)
+			if err != nil {
+				t.Fatalf("ReadAudioInfo failed: %v", err)
+			}
+
+			if info.Format != tt.format {
+				t.Errorf("Expected format %s, got %s", tt.format, info.Format)
+			}
+			if info.SampleRate != tt.sampleRate {
+				t.Errorf("Expected sample rate %d, got %d", tt.sampleRate, info.SampleRate)
+			}
+			if info.BitDepth != tt.bitDepth {
+				t.Errorf("Expected bit depth %d, got %d", tt.bitDepth, info.BitDepth)
+			}
+			if info.Channels != tt.channels {
+				t.Errorf("Expected %d channels, got %d", tt.channels, info.Channels)
+			}
+			if info.Duration != tt.duration {
+				t.Errorf("Expected duration %v, got %v", tt.duration, info.Duration)
+			}
+		})
+	}
+}
+
+func TestReadAudioInfoCorrupt(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	truncated := buildWAV(44100, 16, 2, 10)[:20]
+	noData := buildWAV(44100, 16, 2, 0)[:36]
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty.wav", []byte{}},
+		{"garbage.wav", []byte("this is not a wave file")},
+		{"truncated.wav", truncated},
+		{"nodata.wav", noData},
+		{"mislabeled.aif", buildWAV(44100, 16, 1, 10)[:12]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, tt.name)
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatalf("Failed to write test file: %v", err)
+			}
+
+			_, err := ReadAudioInfo(path)
+			if err == nil {
+				t.Fatal("Expected error for corrupt header, got nil")
+			}
+			if errors.Is(err, ErrUnsupportedFormat) {
+				t.Errorf("Corrupt header should not be reported as unsupported: %v", err)
+			}
+		})
+	}
+}
+
+func TestReadAudioInfoUnsupported(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sample.mp3")
+	if err := os.WriteFile(path, []byte("ID3"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	if _, err := ReadAudioInfo(path); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Expected ErrUnsupportedFormat, got %v", err)
+	}
+}
+
+func TestScanDirectoryAudioMetadata(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string][]byte{
+		"kick.wav":   buildWAV(44100, 16, 1, 22050),
+		"broken.wav": []byte("RIFF"),
+		"snare.mp3":  []byte("ID3"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), data, 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+
+	samples, err := ScanDirectory(tmpDir)
+	if err != nil {
+		t.Fatalf("ScanDirectory failed: %v", err)
+	}
+
+	for _, sample := range samples {
+		switch sample.FileName {
+		case "kick.wav":
+			if sample.Audio == nil {
+				t.Fatalf("Expected audio metadata for kick.wav, got error %q", sample.AudioError)
+			}
+			if sample.Audio.Duration != 500*time.Millisecond {
+				t.Errorf("Expected 500ms duration, got %v", sample.Audio.Duration)
+			}
+		case "broken.wav":
+			if sample.Audio != nil || sample.AudioError == "" {
+				t.Error("Expected broken.wav to report an audio error")
+			}
+		case "snare.mp3":
+			if sample.Audio != nil || sample.AudioError != "" {
+				t.Error("Expected no metadata and no error for unsupported format")
+			}
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,11 +12,20 @@ var AudioExtensions = []string{
 	".wav", ".mp3", ".flac", ".aif", ".aiff", ".ogg", ".m4a", ".wma", ".aac",
 }
 
+// stateDirName is the folder apply writes undo journals and backups into; it is never scanned
+const stateDirName = ".sample-shifter"
+
 // SampleFile represents a discovered audio sample file
 type SampleFile struct {
 	OriginalPath string
 	FileName     string
 	Extension    string
+	// RelativePath is the path of the file relative to the scanned directory
+	RelativePath string `json:",omitempty"`
+	// Audio holds header metadata for formats that can be parsed (WAV, AIFF); nil otherwise
+	Audio *AudioInfo `json:",omitempty"`
+	// AudioError describes why a supported header could not be read
+	AudioError string `json:",omitempty"`
 }
 
 // ScanDirectory recursively scans a directory for audio files
@@ -28,17 +38,30 @@ func ScanDirectory(dir string) ([]SampleFile, error) {
 		}
 
 		if info.IsDir() {
+			if info.Name() == stateDirName && path != dir {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
 		ext := strings.ToLower(filepath.Ext(path))
 		for _, audioExt := range AudioExtensions {
 			if ext == audioExt {
-				samples = append(samples, SampleFile{
+				sample := SampleFile{
 					OriginalPath: path,
 					FileName:     filepath.Base(path),
 					Extension:    ext,
-				})
+				}
+				if rel, err := filepath.Rel(dir, path); err == nil {
+					sample.RelativePath = rel
+				}
+				audioInfo, err := ReadAudioInfo(path)
+				if err == nil {
+					sample.Audio = audioInfo
+				} else if !errors.Is(err, ErrUnsupportedFormat) {
+					sample.AudioError = err.Error()
+				}
+				samples = append(samples, sample)
 				break
 			}
 		}
@@ -48,3 +71,12 @@ func ScanDirectory(dir string) ([]SampleFile, error) {
 
 	return samples, err
 }
+
+// Pack returns the first folder below the scanned directory, or "" for files at its root
+func (s SampleFile) Pack() string {
+	dir := filepath.Dir(s.RelativePath)
+	if s.RelativePath == "" || dir == "." {
+		return ""
+	}
+	return strings.Split(filepath.ToSlash(dir), "/")[0]
+}
@@ -104,3 +104,62 @@ func TestAudioExtensions(t *testing.T) {
 		}
 	}
 }
+
+func TestScanDirectoryRelativePath(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	packDir := filepath.Join(tmpDir, "PackA", "Drums")
+	if err := os.MkdirAll(packDir, 0755); err != nil {
+		t.Fatalf("Failed to create subdirectory: %v", err)
+	}
+	for _, path := range []string{filepath.Join(packDir, "kick.wav"), filepath.Join(tmpDir, "snare.wav")} {
+		if err := os.WriteFile(path, []byte{}, 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	samples, err := ScanDirectory(tmpDir)
+	if err != nil {
+		t.Fatalf("ScanDirectory failed: %v", err)
+	}
+
+	expected := map[string]struct {
+		relativePath string
+		pack         string
+	}{
+		"kick.wav":  {filepath.Join("PackA", "Drums", "kick.wav"), "PackA"},
+		"snare.wav": {"snare.wav", ""},
+	}
+
+	for _, sample := range samples {
+		want := expected[sample.FileName]
+		if sample.RelativePath != want.relativePath {
+			t.Errorf("Expected relative path %q for %s, got %q", want.relativePath, sample.FileName, sample.RelativePath)
+		}
+		if sample.Pack() != want.pack {
+			t.Errorf("Expected pack %q for %s, got %q", want.pack, sample.FileName, sample.Pack())
+		}
+	}
+}
+
+func TestScanDirectorySkipsStateDir(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	backupDir := filepath.Join(tmpDir, stateDirName, "backups", "run")
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		t.Fatalf("Failed to create state directory: %v", err)
+	}
+	for _, path := range []string{filepath.Join(backupDir, "kick.wav"), filepath.Join(tmpDir, "snare.wav")} {
+		if err := os.WriteFile(path, []byte{}, 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	samples, err := ScanDirectory(tmpDir)
+	if err != nil {
+		t.Fatalf("ScanDirectory failed: %v", err)
+	}
+	if len(samples) != 1 || samples[0].FileName != "snare.wav" {
+		t.Errorf("Expected only snare.wav outside the state directory, got %+v", samples)
+	}
+}
@@ -3,6 +3,7 @@ package stats
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/theclifmeister/sample-shifter/internal/categorizer"
 )
@@ -19,21 +20,21 @@ func DisplayStats(categorized []categorizer.CategorizedFile) {
 		return
 	}
 
-	// Group by category and subcategory
+	// Group by category and build the subcategory tree of each category
 	categoryGroups := make(map[categorizer.Category][]categorizer.CategorizedFile)
-	subcategoryGroups := make(map[categorizer.Category]map[string][]categorizer.CategorizedFile)
+	subcategoryTrees := make(map[categorizer.Category]*subcategoryNode)
 
 	for _, cat := range categorized {
 		categoryGroups[cat.Category] = append(categoryGroups[cat.Category], cat)
 
-		if subcategoryGroups[cat.Category] == nil {
-			subcategoryGroups[cat.Category] = make(map[string][]categorizer.CategorizedFile)
+		if subcategoryTrees[cat.Category] == nil {
+			subcategoryTrees[cat.Category] = &subcategoryNode{}
 		}
 		subcatKey := cat.Subcategory
 		if subcatKey == "" {
 			subcatKey = "(no subcategory)"
 		}
-		subcategoryGroups[cat.Category][subcatKey] = append(subcategoryGroups[cat.Category][subcatKey], cat)
+		subcategoryTrees[cat.Category].add(strings.Split(subcatKey, "/"))
 	}
 
 	totalFiles := len(categorized)
@@ -69,36 +70,94 @@ func DisplayStats(categorized []categorizer.CategorizedFile) {
 	for _, cc := range categoryCounts {
 		category := cc.category
 		categoryTotal := cc.count
-		subcats := subcategoryGroups[category]
 
 		fmt.Printf("%s (%d files)\n", category, categoryTotal)
 		fmt.Printf("  %-30s %10s %10s\n", "Subcategory", "Count", "% of Cat")
 		fmt.Println("  --------------------------------------------------")
+		subcategoryTrees[category].display(1, categoryTotal)
+		fmt.Println()
+	}
 
-		// Sort subcategories by count
-		type subcatCount struct {
-			name  string
-			count int
+	displayTags(categorized)
+}
+
+// subcategoryNode counts the files below one subcategory path, including nested ones
+type subcategoryNode struct {
+	name     string
+	count    int
+	children []*subcategoryNode
+}
+
+// add counts a file in the subcategory with the given path segments
+func (n *subcategoryNode) add(path []string) {
+	if len(path) == 0 {
+		return
+	}
+	var child *subcategoryNode
+	for _, c := range n.children {
+		if c.name == path[0] {
+			child = c
+			break
 		}
-		var subcatCounts []subcatCount
-		for subcat, files := range subcats {
-			subcatCounts = append(subcatCounts, subcatCount{subcat, len(files)})
+	}
+	if child == nil {
+		child = &subcategoryNode{name: path[0]}
+		n.children = append(n.children, child)
+	}
+	child.count++
+	child.add(path[1:])
+}
+
+// display prints the children of the node as an indented tree, largest first
+func (n *subcategoryNode) display(depth, categoryTotal int) {
+	sort.SliceStable(n.children, func(i, j int) bool {
+		return n.children[i].count > n.children[j].count
+	})
+	for _, child := range n.children {
+		percentage := float64(child.count) * 100.0 / float64(categoryTotal)
+		label := strings.Repeat("  ", depth-1) + child.name
+		fmt.Printf("  %-30s %10d %9.1f%%\n", label, child.count, percentage)
+		child.display(depth+1, categoryTotal)
+	}
+}
+
+// displayTags shows how many files carry each tag, most common first
+func displayTags(categorized []categorizer.CategorizedFile) {
+	counts := make(map[string]int)
+	for _, file := range categorized {
+		for _, tag := range file.Tags {
+			counts[tag]++
 		}
-		// Sort by count (descending)
-		sort.Slice(subcatCounts, func(i, j int) bool {
-			return subcatCounts[i].count > subcatCounts[j].count
-		})
-
-		for _, sc := range subcatCounts {
-			percentage := float64(sc.count) * 100.0 / float64(categoryTotal)
-			fmt.Printf("  %-30s %10d %9.1f%%\n", sc.name, sc.count, percentage)
+	}
+	if len(counts) == 0 {
+		return
+	}
+
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if counts[tags[i]] != counts[tags[j]] {
+			return counts[tags[i]] > counts[tags[j]]
 		}
-		fmt.Println()
+		return tags[i] < tags[j]
+	})
+
+	fmt.Println("=== TAGS ===")
+	fmt.Println()
+	fmt.Printf("%-30s %10s %10s\n", "Tag", "Count", "Percentage")
+	fmt.Println("--------------------------------------------------")
+	for _, tag := range tags {
+		percentage := float64(counts[tag]) * 100.0 / float64(len(categorized))
+		fmt.Printf("%-30s %10d %9.1f%%\n", tag, counts[tag], percentage)
 	}
+	fmt.Println()
 }
 
-// DisplayDetailedFileList shows a detailed list of all categorized files
-func DisplayDetailedFileList(categorized []categorizer.CategorizedFile) {
+// DisplayDetailedFileList shows a detailed list of all categorized files. With explain set,
+// the rules that decided each file are listed below it.
+func DisplayDetailedFileList(categorized []categorizer.CategorizedFile, explain bool) {
 	if len(categorized) == 0 {
 		return
 	}
@@ -128,8 +187,127 @@ func DisplayDetailedFileList(categorized []categorizer.CategorizedFile) {
 		files := categoryGroups[category]
 		fmt.Printf("Category: %s (%d files)\n", category, len(files))
 		for _, file := range files {
-			fmt.Printf("  %s\n    -> %s\n", file.Sample.OriginalPath, file.TargetPath)
+			fmt.Printf("  %s%s\n    -> %s%s\n", file.Sample.OriginalPath, formatAttributes(file), file.TargetPath, formatSource(file))
+			if explain && len(file.Matches) > 0 {
+				fmt.Printf("       because %s\n", FormatMatches(file.Matches))
+			}
 		}
 		fmt.Println()
 	}
 }
+
+// formatAttributes renders the tempo and key of a file as a bracketed suffix, or "" if none were found
+func formatAttributes(file categorizer.CategorizedFile) string {
+	var parts []string
+	if file.BPM > 0 {
+		parts = append(parts, fmt.Sprintf("%d BPM", file.BPM))
+	}
+	if file.Key != "" {
+		parts = append(parts, fmt.Sprintf("%s (%s)", file.Key, file.Camelot))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(parts, ", ") + "]"
+}
+
+// formatSource notes when a file was categorized by something other than its filename
+func formatSource(file categorizer.CategorizedFile) string {
+	switch file.MatchSource {
+	case categorizer.SourcePath:
+		return " (matched folder name)"
+	case categorizer.SourceDuration:
+		return " (matched duration)"
+	}
+	return ""
+}
+
+// FormatMatch renders a rule that fired and where, e.g. `"kick" in folder "Kicks"`
+func FormatMatch(match categorizer.KeywordMatch) string {
+	switch match.Source {
+	case categorizer.SourcePath:
+		return fmt.Sprintf("%q in folder %q", match.Keyword, match.In)
+	case categorizer.SourceDuration:
+		return match.Keyword
+	default:
+		return fmt.Sprintf("%q in filename", match.Keyword)
+	}
+}
+
+// FormatMatches renders the rules that decided a file, subcategory rules last, e.g.
+// `"kick" in filename; subcategory kick: "kick" in filename`
+func FormatMatches(matches []categorizer.KeywordMatch) string {
+	parts := make([]string, len(matches))
+	for i, match := range matches {
+		parts[i] = FormatMatch(match)
+		if match.Subcategory != "" {
+			parts[i] = fmt.Sprintf("subcategory %s: %s", match.Subcategory, parts[i])
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// DisplayLowConfidence lists files whose category was chosen with a confidence below the
+// threshold, with the competing candidates, so they can be reviewed
+func DisplayLowConfidence(categorized []categorizer.CategorizedFile, threshold float64) {
+	var low []categorizer.CategorizedFile
+	for _, file := range categorized {
+		if file.LowConfidence(threshold) {
+			low = append(low, file)
+		}
+	}
+	if len(low) == 0 {
+		return
+	}
+
+	sort.SliceStable(low, func(i, j int) bool {
+		return low[i].Confidence < low[j].Confidence
+	})
+
+	fmt.Println("=== LOW CONFIDENCE ===")
+	fmt.Println()
+	fmt.Printf("%d file(s) categorized with confidence below %.0f%%\n\n", len(low), threshold*100)
+	for _, file := range low {
+		fmt.Printf("  %s\n", file.Sample.OriginalPath)
+		fmt.Printf("    category:   %s, confidence %.0f%%%s\n", file.Category, file.Confidence*100, formatSource(file))
+		if len(file.Candidates) > 1 {
+			var others []string
+			for _, candidate := range file.Candidates[1:] {
+				others = append(others, fmt.Sprintf("%s %.2f", candidate.Category, candidate.Score))
+			}
+			fmt.Printf("    candidates: %s %.2f, %s\n", file.Category, file.Candidates[0].Score, strings.Join(others, ", "))
+		}
+	}
+	fmt.Println()
+}
+
+// DisplayCollisions lists files whose target path clashed with another file and how each clash was resolved
+func DisplayCollisions(categorized []categorizer.CategorizedFile) {
+	var collided []categorizer.CategorizedFile
+	for _, file := range categorized {
+		if file.Collision != nil {
+			collided = append(collided, file)
+		}
+	}
+	if len(collided) == 0 {
+		return
+	}
+
+	skipped := 0
+	for _, file := range collided {
+		if file.Skipped() {
+			skipped++
+		}
+	}
+
+	fmt.Println("=== TARGET PATH COLLISIONS ===")
+	fmt.Println()
+	fmt.Printf("%d collision(s), %d file(s) will be skipped\n\n", len(collided), skipped)
+	for _, file := range collided {
+		fmt.Printf("  %s\n", file.Sample.OriginalPath)
+		fmt.Printf("    target:   %s\n", file.Collision.OriginalTarget)
+		fmt.Printf("    clashes:  %s\n", file.Collision.ConflictsWith)
+		fmt.Printf("    action:   %s (%s)\n", file.Collision.Action, file.Collision.Reason)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,55 @@
+//go:build linux
+
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// ficlone is the FICLONE ioctl request number (_IOW(0x94, 9, int))
+const ficlone = 0x40049409
+
+// cloneFile creates dst as a copy-on-write clone of src using the FICLONE ioctl.
+// Filesystems without clone support (ext4, tmpfs, cross-device) yield errReflinkUnsupported.
+func cloneFile(src, dst string, overwrite bool) error {
+	sourceFile, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open source file: %w", err)
+	}
+	defer sourceFile.Close()
+
+	destFile, err := createDestination(dst, overwrite)
+	if err != nil {
+		return err
+	}
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, destFile.Fd(), ficlone, sourceFile.Fd())
+	closeErr := destFile.Close()
+	if errno != 0 {
+		os.Remove(dst)
+		if isCloneUnsupported(errno) {
+			return errReflinkUnsupported
+		}
+		return fmt.Errorf("failed to clone file: %w", errno)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("failed to write destination file: %w", closeErr)
+	}
+
+	return preserveModTime(src, dst)
+}
+
+// isCloneUnsupported reports whether an ioctl error means cloning is not possible here
+func isCloneUnsupported(errno syscall.Errno) bool {
+	for _, unsupported := range []syscall.Errno{
+		syscall.EOPNOTSUPP, syscall.ENOTTY, syscall.EXDEV, syscall.EINVAL, syscall.ENOSYS,
+	} {
+		if errors.Is(errno, unsupported) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package transfer
+
+// cloneFile is only implemented on Linux; other platforms always fall back to a copy
+func cloneFile(src, dst string, overwrite bool) error {
+	return errReflinkUnsupported
+}
@@ -0,0 +1,260 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// Mode selects how a source file is placed at its target path
+type Mode string
+
+const (
+	// ModeCopy byte-copies the file (the default, non-destructive)
+	ModeCopy Mode = "copy"
+	// ModeMove renames the file, copying and deleting across devices
+	ModeMove Mode = "move"
+	// ModeHardlink creates a hard link to the source, copying across filesystems
+	ModeHardlink Mode = "hardlink"
+	// ModeSymlink creates a symbolic link with an absolute target
+	ModeSymlink Mode = "symlink"
+	// ModeSymlinkRelative creates a symbolic link relative to the link's directory
+	ModeSymlinkRelative Mode = "symlink-relative"
+	// ModeReflink creates a copy-on-write clone where supported, falling back to a copy
+	ModeReflink Mode = "reflink"
+)
+
+// Modes lists all valid transfer modes
+var Modes = []Mode{ModeCopy, ModeMove, ModeHardlink, ModeSymlink, ModeSymlinkRelative, ModeReflink}
+
+// errReflinkUnsupported is returned by cloneFile when the platform or filesystem cannot clone
+var errReflinkUnsupported = errors.New("reflink not supported")
+
+// ParseMode validates a transfer mode name
+func ParseMode(name string) (Mode, error) {
+	for _, mode := range Modes {
+		if string(mode) == name {
+			return mode, nil
+		}
+	}
+
+	names := make([]string, len(Modes))
+	for i, mode := range Modes {
+		names[i] = string(mode)
+	}
+	return "", fmt.Errorf("unknown mode %q (valid: %s)", name, strings.Join(names, ", "))
+}
+
+// Verb returns the progressive verb used in output, e.g. "Copying" or "Hardlinking"
+func (m Mode) Verb() string {
+	switch m {
+	case ModeMove:
+		return "Moving"
+	case ModeHardlink:
+		return "Hardlinking"
+	case ModeSymlink, ModeSymlinkRelative:
+		return "Symlinking"
+	case ModeReflink:
+		return "Cloning"
+	default:
+		return "Copying"
+	}
+}
+
+// Destructive reports whether the mode removes the source file
+func (m Mode) Destructive() bool {
+	return m == ModeMove
+}
+
+// Transfer places src at dst using the given mode, creating parent directories as needed.
+// Unless overwrite is set, an existing file at dst is never replaced: every mode creates
+// dst in a single step that fails when it exists, so a file appearing at dst during the
+// transfer is kept too. It returns the mode that was actually used, which differs from the
+// requested one when a reflink or a hard link falls back to a plain copy.
+func Transfer(src, dst string, mode Mode, overwrite bool) (Mode, error) {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return mode, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	used, err := place(src, dst, mode, overwrite)
+	if err != nil && !overwrite && errors.Is(err, fs.ErrExist) {
+		return used, fmt.Errorf("target already exists: %s", dst)
+	}
+	return used, err
+}
+
+// place transfers src to dst with the given mode
+func place(src, dst string, mode Mode, overwrite bool) (Mode, error) {
+	switch mode {
+	case ModeCopy:
+		return ModeCopy, copyFile(src, dst, overwrite)
+	case ModeMove:
+		return ModeMove, moveFile(src, dst, overwrite)
+	case ModeHardlink:
+		return linkFile(src, dst, overwrite)
+	case ModeSymlink, ModeSymlinkRelative:
+		return mode, symlinkFile(src, dst, mode == ModeSymlinkRelative, overwrite)
+	case ModeReflink:
+		err := cloneFile(src, dst, overwrite)
+		if errors.Is(err, errReflinkUnsupported) {
+			return ModeCopy, copyFile(src, dst, overwrite)
+		}
+		return ModeReflink, err
+	default:
+		return mode, fmt.Errorf("unknown mode %q", mode)
+	}
+}
+
+// link creates hard links; tests replace it to simulate other filesystems
+var link = os.Link
+
+// copyFile copies the contents of src to dst and preserves the modification time
+func copyFile(src, dst string, overwrite bool) error {
+	// Open source file
+	sourceFile, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open source file: %w", err)
+	}
+	defer sourceFile.Close()
+
+	destFile, err := createDestination(dst, overwrite)
+	if err != nil {
+		return err
+	}
+
+	// Copy contents
+	if _, err := io.Copy(destFile, sourceFile); err != nil {
+		destFile.Close()
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+	if err := destFile.Close(); err != nil {
+		return fmt.Errorf("failed to write destination file: %w", err)
+	}
+
+	return preserveModTime(src, dst)
+}
+
+// createDestination opens dst for writing, refusing to clobber an existing file unless allowed
+func createDestination(dst string, overwrite bool) (*os.File, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if overwrite {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	destFile, err := os.OpenFile(dst, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create destination file: %w", err)
+	}
+	return destFile, nil
+}
+
+// preserveModTime copies the modification time of src onto dst
+func preserveModTime(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat source file: %w", err)
+	}
+	if err := os.Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+		return fmt.Errorf("failed to set modification time: %w", err)
+	}
+	return nil
+}
+
+// moveFile moves src to dst. Without overwrite it links dst to src and then removes src,
+// because a rename would silently replace a file created at dst in the meantime; across
+// devices, or where hard links aren't supported, it copies and deletes instead.
+func moveFile(src, dst string, overwrite bool) error {
+	if overwrite {
+		err := os.Rename(src, dst)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, syscall.EXDEV) {
+			return fmt.Errorf("failed to move file: %w", err)
+		}
+		return copyAndRemove(src, dst, true)
+	}
+
+	err := link(src, dst)
+	if err == nil {
+		if err := os.Remove(src); err != nil {
+			return fmt.Errorf("moved but failed to remove source: %w", err)
+		}
+		return nil
+	}
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+	return copyAndRemove(src, dst, false)
+}
+
+// copyAndRemove moves src to dst by copying it and deleting the source
+func copyAndRemove(src, dst string, overwrite bool) error {
+	if err := copyFile(src, dst, overwrite); err != nil {
+		if !errors.Is(err, fs.ErrExist) {
+			os.Remove(dst)
+		}
+		return fmt.Errorf("failed to move file across devices: %w", err)
+	}
+	if err := os.Remove(src); err != nil {
+		return fmt.Errorf("copied across devices but failed to remove source: %w", err)
+	}
+	return nil
+}
+
+// linkFile creates a hard link at dst pointing to src, falling back to a copy when they
+// are on different devices
+func linkFile(src, dst string, overwrite bool) (Mode, error) {
+	if overwrite {
+		if err := removeExisting(dst); err != nil {
+			return ModeHardlink, err
+		}
+	}
+	err := link(src, dst)
+	if errors.Is(err, syscall.EXDEV) {
+		return ModeCopy, copyFile(src, dst, overwrite)
+	}
+	if err != nil {
+		return ModeHardlink, fmt.Errorf("failed to create hard link: %w", err)
+	}
+	return ModeHardlink, nil
+}
+
+// symlinkFile creates a symbolic link at dst pointing to src, either absolute or relative to dst
+func symlinkFile(src, dst string, relative, overwrite bool) error {
+	target, err := filepath.Abs(src)
+	if err != nil {
+		return fmt.Errorf("failed to resolve source path: %w", err)
+	}
+	if relative {
+		absDst, err := filepath.Abs(dst)
+		if err != nil {
+			return fmt.Errorf("failed to resolve target path: %w", err)
+		}
+		if target, err = filepath.Rel(filepath.Dir(absDst), target); err != nil {
+			return fmt.Errorf("failed to compute relative link: %w", err)
+		}
+	}
+
+	if overwrite {
+		if err := removeExisting(dst); err != nil {
+			return err
+		}
+	}
+	if err := os.Symlink(target, dst); err != nil {
+		return fmt.Errorf("failed to create symlink: %w", err)
+	}
+	return nil
+}
+
+// removeExisting deletes dst if it exists so a link can take its place
+func removeExisting(dst string) error {
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to replace existing target: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package transfer
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// writeSource creates a source file with known content and an old modification time
+func writeSource(t *testing.T, dir string) string {
+	t.Helper()
+	src := filepath.Join(dir, "src", "Kick.wav")
+	if err := os.MkdirAll(filepath.Dir(src), 0755); err != nil {
+		t.Fatalf("Failed to create source directory: %v", err)
+	}
+	if err := os.WriteFile(src, []byte("kick data"), 0644); err != nil {
+		t.Fatalf("Failed to write source file: %v", err)
+	}
+	old := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(src, old, old); err != nil {
+		t.Fatalf("Failed to set source time: %v", err)
+	}
+	return src
+}
+
+func TestTransferModes(t *testing.T) {
+	for _, mode := range Modes {
+		t.Run(string(mode), func(t *testing.T) {
+			dir := t.TempDir()
+			src := writeSource(t, dir)
+			dst := filepath.Join(dir, "target", "drums", "kick", "Kick.wav")
+
+			used, err := Transfer(src, dst, mode, false)
+			if err != nil {
+				t.Fatalf("Transfer failed: %v", err)
+			}
+
+			data, err := os.ReadFile(dst)
+			if err != nil {
+				t.Fatalf("Failed to read target: %v", err)
+			}
+			if string(data) != "kick data" {
+				t.Errorf("Unexpected target content %q", data)
+			}
+
+			_, srcErr := os.Stat(src)
+			if mode.Destructive() && !os.IsNotExist(srcErr) {
+				t.Error("Move should remove the source file")
+			}
+			if !mode.Destructive() && srcErr != nil {
+				t.Errorf("Source should be untouched, got %v", srcErr)
+			}
+
+			info, err := os.Lstat(dst)
+			if err != nil {
+				t.Fatalf("Failed to stat target: %v", err)
+			}
+			isLink := info.Mode()&os.ModeSymlink != 0
+			switch mode {
+			case ModeSymlink:
+				target, _ := os.Readlink(dst)
+				if !isLink || !filepath.IsAbs(target) {
+					t.Errorf("Expected absolute symlink, got link=%v target=%q", isLink, target)
+				}
+			case ModeSymlinkRelative:
+				target, _ := os.Readlink(dst)
+				if !isLink || filepath.IsAbs(target) {
+					t.Errorf("Expected relative symlink, got link=%v target=%q", isLink, target)
+				}
+			case ModeReflink:
+				if used != ModeReflink && used != ModeCopy {
+					t.Errorf("Reflink should report reflink or copy, got %s", used)
+				}
+			default:
+				if isLink {
+					t.Error("Target should not be a symlink")
+				}
+				if used != mode {
+					t.Errorf("Expected mode %s to be used, got %s", mode, used)
+				}
+			}
+
+			if mode == ModeCopy || mode == ModeMove {
+				if !info.ModTime().Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)) {
+					t.Errorf("Expected modification time to be preserved, got %v", info.ModTime())
+				}
+			}
+		})
+	}
+}
+
+func TestTransferRefusesOverwrite(t *testing.T) {
+	for _, mode := range Modes {
+		t.Run(string(mode), func(t *testing.T) {
+			dir := t.TempDir()
+			src := writeSource(t, dir)
+			dst := filepath.Join(dir, "existing.wav")
+			if err := os.WriteFile(dst, []byte("keep me"), 0644); err != nil {
+				t.Fatalf("Failed to write existing target: %v", err)
+			}
+
+			if _, err := Transfer(src, dst, mode, false); err == nil {
+				t.Fatal("Transfer should refuse to replace an existing file")
+			}
+			if data, _ := os.ReadFile(dst); string(data) != "keep me" {
+				t.Errorf("Existing target was modified: %q", data)
+			}
+			if _, err := os.Stat(src); err != nil {
+				t.Errorf("Source should survive a refused transfer: %v", err)
+			}
+
+			if _, err := Transfer(src, dst, mode, true); err != nil {
+				t.Fatalf("Transfer with overwrite failed: %v", err)
+			}
+			if data, _ := os.ReadFile(dst); string(data) != "kick data" {
+				t.Errorf("Expected target to be replaced, got %q", data)
+			}
+		})
+	}
+}
+
+// replaceLink swaps the hard link function for the duration of a test
+func replaceLink(t *testing.T, fn func(src, dst string) error) {
+	t.Helper()
+	original := link
+	link = fn
+	t.Cleanup(func() { link = original })
+}
+
+func TestTransferAcrossDevices(t *testing.T) {
+	replaceLink(t, func(src, dst string) error {
+		return &os.LinkError{Op: "link", Old: src, New: dst, Err: syscall.EXDEV}
+	})
+
+	tests := []struct {
+		mode Mode
+		used Mode
+	}{
+		{ModeHardlink, ModeCopy},
+		{ModeMove, ModeMove},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			dir := t.TempDir()
+			src := writeSource(t, dir)
+			dst := filepath.Join(dir, "target", "Kick.wav")
+
+			used, err := Transfer(src, dst, tt.mode, false)
+			if err != nil {
+				t.Fatalf("Transfer should fall back to a copy, got: %v", err)
+			}
+			if used != tt.used {
+				t.Errorf("Expected mode %s to be used, got %s", tt.used, used)
+			}
+			if data, _ := os.ReadFile(dst); string(data) != "kick data" {
+				t.Errorf("Unexpected target content %q", data)
+			}
+			if _, err := os.Stat(src); tt.mode.Destructive() != os.IsNotExist(err) {
+				t.Errorf("Unexpected source state after %s: %v", tt.mode, err)
+			}
+		})
+	}
+}
+
+func TestMoveKeepsTargetCreatedDuringTransfer(t *testing.T) {
+	// Another process creates the target just before the move places the file
+	replaceLink(t, func(src, dst string) error {
+		if err := os.WriteFile(dst, []byte("keep me"), 0644); err != nil {
+			return err
+		}
+		return os.Link(src, dst)
+	})
+
+	dir := t.TempDir()
+	src := writeSource(t, dir)
+	dst := filepath.Join(dir, "target", "Kick.wav")
+
+	if _, err := Transfer(src, dst, ModeMove, false); err == nil {
+		t.Fatal("Move should refuse to replace a file created during the transfer")
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "keep me" {
+		t.Errorf("Target created during the transfer was replaced: %q", data)
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("Source should survive a refused move: %v", err)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for _, mode := range Modes {
+		if parsed, err := ParseMode(string(mode)); err != nil || parsed != mode {
+			t.Errorf("ParseMode(%q) = %q, %v", mode, parsed, err)
+		}
+	}
+
+	if _, err := ParseMode("teleport"); err == nil {
+		t.Error("ParseMode should reject unknown modes")
+	}
+}